@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+
+	api "github.com/aau-network-security/gollector/api/proto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	UnauthorisedAnalystErr = status.Error(codes.PermissionDenied, "analyst is not authorised to de-anonymize")
+)
+
+// returns the name of the analyst of which the token is in the metadata of the request
+func (s *Server) analystFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", UnauthorisedAnalystErr
+	}
+	tokens := md.Get("token")
+	if len(tokens) == 0 || tokens[0] == "" {
+		return "", UnauthorisedAnalystErr
+	}
+	for _, a := range s.Conf.Api.Analysts {
+		if a.Token != "" && subtle.ConstantTimeCompare([]byte(a.Token), []byte(tokens[0])) == 1 {
+			return a.Name, nil
+		}
+	}
+	return "", UnauthorisedAnalystErr
+}
+
+// de-anonymizes FQDNs for authorised analysts, of which each lookup is logged
+func (s *Server) Deanonymize(ctx context.Context, fqdns *api.AnonymizedFqdns) (*api.DeanonymizedFqdns, error) {
+	analyst, err := s.analystFromContext(ctx)
+	if err != nil {
+		log.Warn().Msgf("rejected de-anonymization of %d fqdns by unauthorised analyst", len(fqdns.Fqdns))
+		return nil, err
+	}
+
+	res := api.DeanonymizedFqdns{}
+	for _, anon := range fqdns.Fqdns {
+		log.Info().Str("analyst", analyst).Str("fqdn", anon).Msgf("de-anonymizing fqdn")
+		d := api.DeanonymizedFqdn{
+			Anonymized: anon,
+		}
+		fqdn, err := s.Store.DeanonymizeFqdn(anon)
+		if err != nil {
+			d.Error = err.Error()
+		} else {
+			d.Fqdn = fqdn
+		}
+		res.Fqdns = append(res.Fqdns, &d)
+	}
+	return &res, nil
+}
@@ -11,9 +11,16 @@ type Config struct {
 }
 
 type Api struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
-	Tls  Tls    `yaml:"tls"`
+	Host     string    `yaml:"host"`
+	Port     int       `yaml:"port"`
+	Tls      Tls       `yaml:"tls"`
+	Analysts []Analyst `yaml:"analysts"`
+}
+
+// an analyst that is authorised to de-anonymize domain names
+type Analyst struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
 }
 
 type Tls struct {
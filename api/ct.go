@@ -53,26 +53,47 @@ func (s *Server) StoreLogEntries(str api.CtApi_StoreLogEntriesServer) error {
 				Error: "",
 			}
 
-			cert, err := certFromLogEntry(le)
-			if err != nil {
-				s.Log.Log(err, app.LogOptions{
-					Msg: "failed to parse certificate",
-					Tags: map[string]string{
-						"log": le.Log.Url,
-					},
-				})
-				res = &api.Result{
-					Ok:    false,
-					Error: err.Error(),
+			l := ct.Log{
+				Description:       le.Log.Description,
+				Key:               le.Log.Key,
+				Url:               le.Log.Url,
+				MaximumMergeDelay: int(le.Log.MaximumMergeDelay),
+				DnsApiEndpoint:    le.Log.DnsApiEndpoint,
+			}
+
+			// unparsable entries are recorded as scanned, as retrieving them again would not make them parsable
+			var cert *x509.Certificate
+			skipped := le.Unparsable
+			if !skipped {
+				cert, err = certFromLogEntry(le)
+				if err != nil {
+					s.Log.Log(err, app.LogOptions{
+						Msg: "failed to parse certificate",
+						Tags: map[string]string{
+							"log": le.Log.Url,
+						},
+					})
+					res = &api.Result{
+						Ok:    false,
+						Error: err.Error(),
+					}
+					skipped = true
 				}
-			} else {
-				l := ct.Log{
-					Description:       le.Log.Description,
-					Key:               le.Log.Key,
-					Url:               le.Log.Url,
-					MaximumMergeDelay: int(le.Log.MaximumMergeDelay),
-					DnsApiEndpoint:    le.Log.DnsApiEndpoint,
+			}
+			if skipped {
+				if err := s.Store.StoreSkippedLogEntry(muid, l, uint(le.Index)); err != nil {
+					s.Log.Log(err, app.LogOptions{
+						Msg: "failed to store skipped log entry",
+						Tags: map[string]string{
+							"log": le.Log.Url,
+						},
+					})
+					res = &api.Result{
+						Ok:    false,
+						Error: err.Error(),
+					}
 				}
+			} else {
 				entry := store.LogEntry{
 					Cert:      cert,
 					IsPrecert: le.IsPrecert,
@@ -121,3 +142,68 @@ func (s *Server) GetLastDBEntry(ctx context.Context, url *api.KnownLogURL) (*api
 	}
 	return &api.Index{Start: logEntryIndex}, nil
 }
+
+func (s *Server) GetMissingRanges(ctx context.Context, url *api.KnownLogURL) (*api.IndexRanges, error) {
+	ranges, err := s.Store.GetMissingRanges(url.LogURL)
+	if err != nil {
+		return nil, err
+	}
+	res := api.IndexRanges{}
+	for _, r := range ranges {
+		res.Ranges = append(res.Ranges, &api.IndexRange{
+			Start: int64(r.Start),
+			End:   int64(r.End),
+		})
+	}
+	return &res, nil
+}
+
+func (s *Server) StoreTreeHead(ctx context.Context, th *api.TreeHead) (*api.Result, error) {
+	if th.Log == nil {
+		return nil, status.Error(codes.InvalidArgument, "log cannot be empty")
+	}
+	l := ct.Log{
+		Description:       th.Log.Description,
+		Key:               th.Log.Key,
+		Url:               th.Log.Url,
+		MaximumMergeDelay: int(th.Log.MaximumMergeDelay),
+		DnsApiEndpoint:    th.Log.DnsApiEndpoint,
+	}
+	sth := store.TreeHead{
+		Log:          l,
+		TreeSize:     th.TreeSize,
+		Timestamp:    timeFromUnix(th.Timestamp),
+		RootHash:     th.RootHash,
+		Signature:    th.Signature,
+		Consistent:   th.Consistent,
+		Misbehaviour: th.Misbehaviour,
+	}
+	if err := s.Store.StoreTreeHead(sth); err != nil {
+		return &api.Result{
+			Ok:    false,
+			Error: err.Error(),
+		}, nil
+	}
+	return &api.Result{
+		Ok: true,
+	}, nil
+}
+
+func (s *Server) GetLastTreeHead(ctx context.Context, url *api.KnownLogURL) (*api.TreeHead, error) {
+	th, err := s.Store.GetLastTreeHead(url.LogURL)
+	if err != nil {
+		return nil, err
+	}
+	// an empty tree head indicates that there is no previous tree head
+	if th == nil {
+		return &api.TreeHead{}, nil
+	}
+	return &api.TreeHead{
+		TreeSize:     th.TreeSize,
+		Timestamp:    th.Timestamp.UnixNano() / 1e06,
+		RootHash:     th.RootHash,
+		Signature:    th.Signature,
+		Consistent:   th.Consistent,
+		Misbehaviour: th.Misbehaviour,
+	}, nil
+}
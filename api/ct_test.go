@@ -5,7 +5,11 @@ import (
 	"encoding/hex"
 	api "github.com/aau-network-security/gollector/api/proto"
 	"github.com/aau-network-security/gollector/app"
+	"github.com/aau-network-security/gollector/collectors/ct"
 	"github.com/aau-network-security/gollector/store"
+	"github.com/aau-network-security/gollector/store/models"
+	"github.com/aau-network-security/gollector/testing/ctlog"
+	ct2 "github.com/google/certificate-transparency-go"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -13,7 +17,9 @@ import (
 	"io"
 	"io/ioutil"
 	"net"
+	"sync"
 	"testing"
+	"time"
 )
 
 func getBufDialer(lis *bufconn.Listener) func(context.Context, string) (net.Conn, error) {
@@ -146,3 +152,123 @@ func TestServer_StoreLogEntries(t *testing.T) {
 		})
 	}
 }
+
+// scans a fake log and stores its entries, from which certificates and FQDNs should be created
+func TestServer_StoreLogEntriesFromFakeLog(t *testing.T) {
+	fl, err := ctlog.New()
+	if err != nil {
+		t.Fatalf("failed to create log: %s", err)
+	}
+	defer fl.Close()
+	ts := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, san := range []string{"a.example.org", "b.example.org", "c.example.org"} {
+		if _, err := fl.AddPrecert(ts, san); err != nil {
+			t.Fatalf("failed to add entry to log: %s", err)
+		}
+		if _, err := fl.AddCert(ts, san); err != nil {
+			t.Fatalf("failed to add entry to log: %s", err)
+		}
+	}
+
+	s, g, muid, err := store.OpenStore(store.TestConfig, store.TestOpts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+	serv := Server{
+		Conf:  Config{},
+		Store: s,
+		Log:   app.NewZeroLogger(nil, zerolog.DebugLevel),
+	}
+	lis := bufconn.Listen(1024 * 1024)
+	go func() {
+		serv.Run(lis)
+	}()
+
+	cc, err := grpc.Dial("", grpc.WithContextDialer(getBufDialer(lis)), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	client := api.NewCtApiClient(cc)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{
+		"muid": muid,
+	}))
+
+	l := ct.Log{
+		Description: "fake log",
+		Url:         fl.URL(),
+	}
+	m := sync.Mutex{}
+	batch := api.LogEntryBatch{}
+	entryFn := func(entry *ct2.LogEntry) error {
+		m.Lock()
+		defer m.Unlock()
+		le := api.LogEntry{
+			Index:     entry.Index,
+			Timestamp: int64(entry.Leaf.TimestampedEntry.Timestamp),
+			Log: &api.Log{
+				Description: l.Description,
+				Url:         l.Url,
+			},
+		}
+		if entry.Precert != nil {
+			le.Certificate = entry.Precert.TBSCertificate.Raw
+			le.IsPrecert = true
+		} else {
+			le.Certificate = entry.X509Cert.Raw
+		}
+		batch.LogEntries = append(batch.LogEntries, &le)
+		return nil
+	}
+	opts := ct.Options{
+		WorkerCount: 1,
+		EndIndex:    int64(fl.Size()),
+	}
+	if _, err := ct.Scan(ctx, &l, entryFn, opts); err != nil {
+		t.Fatalf("failed to scan log: %s", err)
+	}
+
+	str, err := client.StoreLogEntries(ctx)
+	if err != nil {
+		t.Fatalf("failed to create stream to store log entries: %s", err)
+	}
+	if err := str.Send(&batch); err != nil {
+		t.Fatalf("failed to send log entry batch: %s", err)
+	}
+	if err := str.CloseSend(); err != nil {
+		t.Fatalf("failed to close connection: %s", err)
+	}
+	for {
+		res, err := str.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !res.Ok {
+			t.Fatalf("failed to store log entry: %s", res.Error)
+		}
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+
+	counts := []struct {
+		count uint
+		model interface{}
+	}{
+		{6, &models.LogEntry{}},
+		{6, &models.Certificate{}},
+		{3, &models.Fqdn{}},
+		{3, &models.PrecertToCert{}},
+	}
+	for _, tc := range counts {
+		var count uint
+		if err := g.Model(tc.model).Count(&count).Error; err != nil {
+			t.Fatalf("failed to retrieve model count: %s", err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d %T elements, but got %d", tc.count, tc.model, count)
+		}
+	}
+}
@@ -71,7 +71,7 @@ func (x ZoneEntry_ZoneEntryType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ZoneEntry_ZoneEntryType.Descriptor instead.
 func (ZoneEntry_ZoneEntryType) EnumDescriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{10, 0}
+	return file_api_proto_rawDescGZIP(), []int{13, 0}
 }
 
 type Empty struct {
@@ -318,6 +318,7 @@ type LogEntry struct {
 	Timestamp   int64  `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // unix time in ms
 	Log         *Log   `protobuf:"bytes,4,opt,name=Log,proto3" json:"Log,omitempty"`
 	IsPrecert   bool   `protobuf:"varint,5,opt,name=IsPrecert,proto3" json:"IsPrecert,omitempty"`
+	Unparsable  bool   `protobuf:"varint,6,opt,name=Unparsable,proto3" json:"Unparsable,omitempty"` // the entry could not be parsed, so only its index is recorded as scanned
 }
 
 func (x *LogEntry) Reset() {
@@ -387,6 +388,13 @@ func (x *LogEntry) GetIsPrecert() bool {
 	return false
 }
 
+func (x *LogEntry) GetUnparsable() bool {
+	if x != nil {
+		return x.Unparsable
+	}
+	return false
+}
+
 type Log struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -568,6 +576,204 @@ func (x *Index) GetStart() int64 {
 	return 0
 }
 
+// a range of log entries [Start, End)
+type IndexRange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Start int64 `protobuf:"varint,1,opt,name=Start,proto3" json:"Start,omitempty"`
+	End   int64 `protobuf:"varint,2,opt,name=End,proto3" json:"End,omitempty"`
+}
+
+func (x *IndexRange) Reset() {
+	*x = IndexRange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IndexRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexRange) ProtoMessage() {}
+
+func (x *IndexRange) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexRange.ProtoReflect.Descriptor instead.
+func (*IndexRange) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *IndexRange) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *IndexRange) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type IndexRanges struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ranges []*IndexRange `protobuf:"bytes,1,rep,name=Ranges,proto3" json:"Ranges,omitempty"`
+}
+
+func (x *IndexRanges) Reset() {
+	*x = IndexRanges{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IndexRanges) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexRanges) ProtoMessage() {}
+
+func (x *IndexRanges) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexRanges.ProtoReflect.Descriptor instead.
+func (*IndexRanges) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IndexRanges) GetRanges() []*IndexRange {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+type TreeHead struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Log          *Log   `protobuf:"bytes,1,opt,name=Log,proto3" json:"Log,omitempty"`
+	TreeSize     int64  `protobuf:"varint,2,opt,name=TreeSize,proto3" json:"TreeSize,omitempty"`
+	Timestamp    int64  `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // unix time in ms
+	RootHash     []byte `protobuf:"bytes,4,opt,name=RootHash,proto3" json:"RootHash,omitempty"`
+	Signature    []byte `protobuf:"bytes,5,opt,name=Signature,proto3" json:"Signature,omitempty"`       // DigitallySigned, TLS encoded
+	Consistent   bool   `protobuf:"varint,6,opt,name=Consistent,proto3" json:"Consistent,omitempty"`    // consistency with the previous tree head has been proven
+	Misbehaviour string `protobuf:"bytes,7,opt,name=Misbehaviour,proto3" json:"Misbehaviour,omitempty"` // empty, unless the log misbehaved
+}
+
+func (x *TreeHead) Reset() {
+	*x = TreeHead{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TreeHead) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeHead) ProtoMessage() {}
+
+func (x *TreeHead) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeHead.ProtoReflect.Descriptor instead.
+func (*TreeHead) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TreeHead) GetLog() *Log {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *TreeHead) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *TreeHead) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *TreeHead) GetRootHash() []byte {
+	if x != nil {
+		return x.RootHash
+	}
+	return nil
+}
+
+func (x *TreeHead) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *TreeHead) GetConsistent() bool {
+	if x != nil {
+		return x.Consistent
+	}
+	return false
+}
+
+func (x *TreeHead) GetMisbehaviour() string {
+	if x != nil {
+		return x.Misbehaviour
+	}
+	return ""
+}
+
 type ZoneEntryBatch struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -579,7 +785,7 @@ type ZoneEntryBatch struct {
 func (x *ZoneEntryBatch) Reset() {
 	*x = ZoneEntryBatch{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[9]
+		mi := &file_api_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -592,7 +798,7 @@ func (x *ZoneEntryBatch) String() string {
 func (*ZoneEntryBatch) ProtoMessage() {}
 
 func (x *ZoneEntryBatch) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[9]
+	mi := &file_api_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -605,7 +811,7 @@ func (x *ZoneEntryBatch) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ZoneEntryBatch.ProtoReflect.Descriptor instead.
 func (*ZoneEntryBatch) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{9}
+	return file_api_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ZoneEntryBatch) GetZoneEntries() []*ZoneEntry {
@@ -628,7 +834,7 @@ type ZoneEntry struct {
 func (x *ZoneEntry) Reset() {
 	*x = ZoneEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[10]
+		mi := &file_api_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -641,7 +847,7 @@ func (x *ZoneEntry) String() string {
 func (*ZoneEntry) ProtoMessage() {}
 
 func (x *ZoneEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[10]
+	mi := &file_api_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -654,7 +860,7 @@ func (x *ZoneEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ZoneEntry.ProtoReflect.Descriptor instead.
 func (*ZoneEntry) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{10}
+	return file_api_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ZoneEntry) GetApex() string {
@@ -690,7 +896,7 @@ type Result struct {
 func (x *Result) Reset() {
 	*x = Result{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[11]
+		mi := &file_api_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -703,7 +909,7 @@ func (x *Result) String() string {
 func (*Result) ProtoMessage() {}
 
 func (x *Result) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[11]
+	mi := &file_api_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -716,7 +922,7 @@ func (x *Result) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Result.ProtoReflect.Descriptor instead.
 func (*Result) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{11}
+	return file_api_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *Result) GetOk() bool {
@@ -744,7 +950,7 @@ type SplunkEntryBatch struct {
 func (x *SplunkEntryBatch) Reset() {
 	*x = SplunkEntryBatch{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[12]
+		mi := &file_api_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -757,7 +963,7 @@ func (x *SplunkEntryBatch) String() string {
 func (*SplunkEntryBatch) ProtoMessage() {}
 
 func (x *SplunkEntryBatch) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[12]
+	mi := &file_api_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -770,7 +976,7 @@ func (x *SplunkEntryBatch) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SplunkEntryBatch.ProtoReflect.Descriptor instead.
 func (*SplunkEntryBatch) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{12}
+	return file_api_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *SplunkEntryBatch) GetSplunkEntries() []*SplunkEntry {
@@ -792,7 +998,7 @@ type SplunkEntry struct {
 func (x *SplunkEntry) Reset() {
 	*x = SplunkEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[13]
+		mi := &file_api_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -805,7 +1011,7 @@ func (x *SplunkEntry) String() string {
 func (*SplunkEntry) ProtoMessage() {}
 
 func (x *SplunkEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[13]
+	mi := &file_api_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -818,7 +1024,7 @@ func (x *SplunkEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SplunkEntry.ProtoReflect.Descriptor instead.
 func (*SplunkEntry) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{13}
+	return file_api_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *SplunkEntry) GetQuery() string {
@@ -846,7 +1052,7 @@ type EntradaEntryBatch struct {
 func (x *EntradaEntryBatch) Reset() {
 	*x = EntradaEntryBatch{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[14]
+		mi := &file_api_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -859,7 +1065,7 @@ func (x *EntradaEntryBatch) String() string {
 func (*EntradaEntryBatch) ProtoMessage() {}
 
 func (x *EntradaEntryBatch) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[14]
+	mi := &file_api_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -872,7 +1078,7 @@ func (x *EntradaEntryBatch) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntradaEntryBatch.ProtoReflect.Descriptor instead.
 func (*EntradaEntryBatch) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{14}
+	return file_api_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *EntradaEntryBatch) GetEntradaEntries() []*EntradaEntry {
@@ -895,7 +1101,7 @@ type EntradaEntry struct {
 func (x *EntradaEntry) Reset() {
 	*x = EntradaEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[15]
+		mi := &file_api_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -908,7 +1114,7 @@ func (x *EntradaEntry) String() string {
 func (*EntradaEntry) ProtoMessage() {}
 
 func (x *EntradaEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[15]
+	mi := &file_api_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -921,7 +1127,7 @@ func (x *EntradaEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntradaEntry.ProtoReflect.Descriptor instead.
 func (*EntradaEntry) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{15}
+	return file_api_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *EntradaEntry) GetFqdn() string {
@@ -956,7 +1162,7 @@ type Offset struct {
 func (x *Offset) Reset() {
 	*x = Offset{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_msgTypes[16]
+		mi := &file_api_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -969,7 +1175,7 @@ func (x *Offset) String() string {
 func (*Offset) ProtoMessage() {}
 
 func (x *Offset) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[16]
+	mi := &file_api_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -982,7 +1188,7 @@ func (x *Offset) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Offset.ProtoReflect.Descriptor instead.
 func (*Offset) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{16}
+	return file_api_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *Offset) GetOffset() int64 {
@@ -992,142 +1198,660 @@ func (x *Offset) GetOffset() int64 {
 	return 0
 }
 
-var File_api_proto protoreflect.FileDescriptor
+type WatchRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_api_proto_rawDesc = []byte{
-	0x0a, 0x09, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x07, 0x0a, 0x05, 0x45,
-	0x6d, 0x70, 0x74, 0x79, 0x22, 0x50, 0x0a, 0x18, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x65, 0x61,
-	0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x34, 0x0a, 0x0d, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49,
-	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72,
-	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x52, 0x0d, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65,
-	0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x22, 0x3c, 0x0a, 0x04, 0x4d, 0x65, 0x74, 0x61, 0x12, 0x20,
-	0x0a, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x12, 0x12, 0x0a, 0x04, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x48, 0x6f, 0x73, 0x74, 0x22, 0x1f, 0x0a, 0x0d, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d,
-	0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x02, 0x49, 0x64, 0x22, 0x3a, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x29, 0x0a, 0x0a, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74,
-	0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x4c, 0x6f, 0x67,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65,
-	0x73, 0x22, 0x96, 0x01, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x20,
-	0x0a, 0x0b, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x0b, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
-	0x12, 0x14, 0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
-	0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73,
-	0x74, 0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x03, 0x4c, 0x6f, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x04, 0x2e, 0x4c, 0x6f, 0x67, 0x52, 0x03, 0x4c, 0x6f, 0x67, 0x12, 0x1c, 0x0a, 0x09,
-	0x49, 0x73, 0x50, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52,
-	0x09, 0x49, 0x73, 0x50, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x22, 0xc1, 0x01, 0x0a, 0x03, 0x4c,
-	0x6f, 0x67, 0x12, 0x20, 0x0a, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
-	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x72, 0x6c, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x03, 0x55, 0x72, 0x6c, 0x12, 0x2c, 0x0a, 0x11, 0x4d, 0x61, 0x78, 0x69,
-	0x6d, 0x75, 0x6d, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x11, 0x4d, 0x61, 0x78, 0x69, 0x6d, 0x75, 0x6d, 0x4d, 0x65, 0x72, 0x67,
-	0x65, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
-	0x65, 0x64, 0x42, 0x79, 0x18, 0x05, 0x20, 0x03, 0x28, 0x03, 0x52, 0x0a, 0x4f, 0x70, 0x65, 0x72,
-	0x61, 0x74, 0x65, 0x64, 0x42, 0x79, 0x12, 0x26, 0x0a, 0x0e, 0x44, 0x6e, 0x73, 0x41, 0x70, 0x69,
-	0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
-	0x44, 0x6e, 0x73, 0x41, 0x70, 0x69, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0x25,
-	0x0a, 0x0b, 0x4b, 0x6e, 0x6f, 0x77, 0x6e, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x12, 0x16, 0x0a,
-	0x06, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4c,
-	0x6f, 0x67, 0x55, 0x52, 0x4c, 0x22, 0x1d, 0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x14,
-	0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x53,
-	0x74, 0x61, 0x72, 0x74, 0x22, 0x3e, 0x0a, 0x0e, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x2c, 0x0a, 0x0b, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e,
-	0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x5a, 0x6f,
-	0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74,
-	0x72, 0x69, 0x65, 0x73, 0x22, 0xae, 0x01, 0x0a, 0x09, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x41, 0x70, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x04, 0x41, 0x70, 0x65, 0x78, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
-	0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73,
-	0x74, 0x61, 0x6d, 0x70, 0x12, 0x2c, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x18, 0x2e, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x5a,
-	0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x54, 0x79,
-	0x70, 0x65, 0x22, 0x41, 0x0a, 0x0d, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54,
-	0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x0a, 0x46, 0x49, 0x52, 0x53, 0x54, 0x5f, 0x53, 0x45, 0x45,
-	0x4e, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x45, 0x47, 0x49, 0x53, 0x54, 0x52, 0x41, 0x54,
-	0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x45, 0x58, 0x50, 0x49, 0x52, 0x41, 0x54,
-	0x49, 0x4f, 0x4e, 0x10, 0x02, 0x22, 0x2e, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
-	0x0e, 0x0a, 0x02, 0x4f, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b, 0x12,
-	0x14, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x46, 0x0a, 0x10, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x32, 0x0a, 0x0d, 0x53, 0x70, 0x6c,
-	0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x0c, 0x2e, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d,
-	0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x41, 0x0a,
-	0x0b, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05,
-	0x51, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x51, 0x75, 0x65,
-	0x72, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
-	0x22, 0x4a, 0x0a, 0x11, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x35, 0x0a, 0x0e, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61,
-	0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
-	0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x45, 0x6e,
-	0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x6a, 0x0a, 0x0c,
-	0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04,
-	0x46, 0x71, 0x64, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x46, 0x71, 0x64, 0x6e,
-	0x12, 0x22, 0x0a, 0x0c, 0x4d, 0x69, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x4d, 0x69, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x73,
-	0x74, 0x61, 0x6d, 0x70, 0x12, 0x22, 0x0a, 0x0c, 0x4d, 0x61, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73,
-	0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x4d, 0x61, 0x78, 0x54,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x20, 0x0a, 0x06, 0x4f, 0x66, 0x66, 0x73,
-	0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x06, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x32, 0xc4, 0x01, 0x0a, 0x0e, 0x4d,
-	0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x41, 0x70, 0x69, 0x12, 0x36, 0x0a,
-	0x10, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e,
-	0x74, 0x12, 0x05, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x1a, 0x19, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74,
-	0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x0f, 0x53, 0x74, 0x6f, 0x70, 0x4d, 0x65, 0x61,
-	0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75,
-	0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x22, 0x00, 0x12, 0x26, 0x0a, 0x0a, 0x53, 0x74, 0x61, 0x72, 0x74, 0x53, 0x74, 0x61, 0x67, 0x65,
-	0x12, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64,
-	0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x25, 0x0a, 0x09, 0x53, 0x74,
-	0x6f, 0x70, 0x53, 0x74, 0x61, 0x67, 0x65, 0x12, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72,
-	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0x00, 0x32, 0x63, 0x0a, 0x05, 0x43, 0x74, 0x41, 0x70, 0x69, 0x12, 0x30, 0x0a, 0x0f, 0x53, 0x74,
-	0x6f, 0x72, 0x65, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x0e, 0x2e,
-	0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x1a, 0x07, 0x2e,
-	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x28, 0x0a, 0x0e,
-	0x47, 0x65, 0x74, 0x4c, 0x61, 0x73, 0x74, 0x44, 0x42, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x0c,
-	0x2e, 0x4b, 0x6e, 0x6f, 0x77, 0x6e, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x1a, 0x06, 0x2e, 0x49,
-	0x6e, 0x64, 0x65, 0x78, 0x22, 0x00, 0x32, 0x3f, 0x0a, 0x0b, 0x5a, 0x6f, 0x6e, 0x65, 0x46, 0x69,
-	0x6c, 0x65, 0x41, 0x70, 0x69, 0x12, 0x30, 0x0a, 0x0e, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x5a, 0x6f,
-	0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x0f, 0x2e, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e,
-	0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x32, 0x42, 0x0a, 0x09, 0x53, 0x70, 0x6c, 0x75, 0x6e,
-	0x6b, 0x41, 0x70, 0x69, 0x12, 0x35, 0x0a, 0x11, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x50, 0x61, 0x73,
-	0x73, 0x69, 0x76, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x11, 0x2e, 0x53, 0x70, 0x6c, 0x75,
-	0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x1a, 0x07, 0x2e, 0x52,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x32, 0x64, 0x0a, 0x0a, 0x45,
-	0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x41, 0x70, 0x69, 0x12, 0x36, 0x0a, 0x11, 0x53, 0x74, 0x6f,
-	0x72, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x12,
-	0x2e, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74,
-	0x63, 0x68, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30,
-	0x01, 0x12, 0x1e, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x06,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x07, 0x2e, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22,
-	0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	Name        string   `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Type        string   `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"` // exact, suffix, regex, keyword or similar
+	Value       string   `protobuf:"bytes,3,opt,name=Value,proto3" json:"Value,omitempty"`
+	MaxDistance int64    `protobuf:"varint,4,opt,name=MaxDistance,proto3" json:"MaxDistance,omitempty"` // only used by similarity rules
+	Allow       []string `protobuf:"bytes,5,rep,name=Allow,proto3" json:"Allow,omitempty"`              // apexes that never match the rule
 }
 
-var (
-	file_api_proto_rawDescOnce sync.Once
-	file_api_proto_rawDescData = file_api_proto_rawDesc
-)
-
-func file_api_proto_rawDescGZIP() []byte {
-	file_api_proto_rawDescOnce.Do(func() {
-		file_api_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_proto_rawDescData)
+func (x *WatchRule) Reset() {
+	*x = WatchRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRule) ProtoMessage() {}
+
+func (x *WatchRule) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRule.ProtoReflect.Descriptor instead.
+func (*WatchRule) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WatchRule) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WatchRule) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *WatchRule) GetMaxDistance() int64 {
+	if x != nil {
+		return x.MaxDistance
+	}
+	return 0
+}
+
+func (x *WatchRule) GetAllow() []string {
+	if x != nil {
+		return x.Allow
+	}
+	return nil
+}
+
+type WatchRules struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rules []*WatchRule `protobuf:"bytes,1,rep,name=Rules,proto3" json:"Rules,omitempty"`
+}
+
+func (x *WatchRules) Reset() {
+	*x = WatchRules{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRules) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRules) ProtoMessage() {}
+
+func (x *WatchRules) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRules.ProtoReflect.Descriptor instead.
+func (*WatchRules) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WatchRules) GetRules() []*WatchRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type Apexes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Apexes []string `protobuf:"bytes,1,rep,name=Apexes,proto3" json:"Apexes,omitempty"`
+}
+
+func (x *Apexes) Reset() {
+	*x = Apexes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Apexes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Apexes) ProtoMessage() {}
+
+func (x *Apexes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Apexes.ProtoReflect.Descriptor instead.
+func (*Apexes) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Apexes) GetApexes() []string {
+	if x != nil {
+		return x.Apexes
+	}
+	return nil
+}
+
+type FirstSeen struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Apex      string `protobuf:"bytes,1,opt,name=Apex,proto3" json:"Apex,omitempty"`
+	Source    string `protobuf:"bytes,2,opt,name=Source,proto3" json:"Source,omitempty"`        // zone, ct or passive
+	Timestamp int64  `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // unix time in ms
+}
+
+func (x *FirstSeen) Reset() {
+	*x = FirstSeen{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FirstSeen) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FirstSeen) ProtoMessage() {}
+
+func (x *FirstSeen) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FirstSeen.ProtoReflect.Descriptor instead.
+func (*FirstSeen) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *FirstSeen) GetApex() string {
+	if x != nil {
+		return x.Apex
+	}
+	return ""
+}
+
+func (x *FirstSeen) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *FirstSeen) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type FirstSeenList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Entries []*FirstSeen `protobuf:"bytes,1,rep,name=Entries,proto3" json:"Entries,omitempty"`
+}
+
+func (x *FirstSeenList) Reset() {
+	*x = FirstSeenList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FirstSeenList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FirstSeenList) ProtoMessage() {}
+
+func (x *FirstSeenList) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FirstSeenList.ProtoReflect.Descriptor instead.
+func (*FirstSeenList) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *FirstSeenList) GetEntries() []*FirstSeen {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type AnonymizedFqdns struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fqdns []string `protobuf:"bytes,1,rep,name=Fqdns,proto3" json:"Fqdns,omitempty"`
+}
+
+func (x *AnonymizedFqdns) Reset() {
+	*x = AnonymizedFqdns{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnonymizedFqdns) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnonymizedFqdns) ProtoMessage() {}
+
+func (x *AnonymizedFqdns) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnonymizedFqdns.ProtoReflect.Descriptor instead.
+func (*AnonymizedFqdns) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AnonymizedFqdns) GetFqdns() []string {
+	if x != nil {
+		return x.Fqdns
+	}
+	return nil
+}
+
+type DeanonymizedFqdn struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Anonymized string `protobuf:"bytes,1,opt,name=Anonymized,proto3" json:"Anonymized,omitempty"`
+	Fqdn       string `protobuf:"bytes,2,opt,name=Fqdn,proto3" json:"Fqdn,omitempty"` // empty if the FQDN cannot be de-anonymized
+	Error      string `protobuf:"bytes,3,opt,name=Error,proto3" json:"Error,omitempty"`
+}
+
+func (x *DeanonymizedFqdn) Reset() {
+	*x = DeanonymizedFqdn{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeanonymizedFqdn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeanonymizedFqdn) ProtoMessage() {}
+
+func (x *DeanonymizedFqdn) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeanonymizedFqdn.ProtoReflect.Descriptor instead.
+func (*DeanonymizedFqdn) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DeanonymizedFqdn) GetAnonymized() string {
+	if x != nil {
+		return x.Anonymized
+	}
+	return ""
+}
+
+func (x *DeanonymizedFqdn) GetFqdn() string {
+	if x != nil {
+		return x.Fqdn
+	}
+	return ""
+}
+
+func (x *DeanonymizedFqdn) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type DeanonymizedFqdns struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fqdns []*DeanonymizedFqdn `protobuf:"bytes,1,rep,name=Fqdns,proto3" json:"Fqdns,omitempty"`
+}
+
+func (x *DeanonymizedFqdns) Reset() {
+	*x = DeanonymizedFqdns{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeanonymizedFqdns) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeanonymizedFqdns) ProtoMessage() {}
+
+func (x *DeanonymizedFqdns) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeanonymizedFqdns.ProtoReflect.Descriptor instead.
+func (*DeanonymizedFqdns) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *DeanonymizedFqdns) GetFqdns() []*DeanonymizedFqdn {
+	if x != nil {
+		return x.Fqdns
+	}
+	return nil
+}
+
+var File_api_proto protoreflect.FileDescriptor
+
+var file_api_proto_rawDesc = []byte{
+	0x0a, 0x09, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x07, 0x0a, 0x05, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x22, 0x50, 0x0a, 0x18, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x65, 0x61,
+	0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x34, 0x0a, 0x0d, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x52, 0x0d, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x22, 0x3c, 0x0a, 0x04, 0x4d, 0x65, 0x74, 0x61, 0x12, 0x20,
+	0x0a, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x12, 0x0a, 0x04, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x48, 0x6f, 0x73, 0x74, 0x22, 0x1f, 0x0a, 0x0d, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x49, 0x64, 0x22, 0x3a, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x29, 0x0a, 0x0a, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x4c, 0x6f, 0x67,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65,
+	0x73, 0x22, 0xb6, 0x01, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x20,
+	0x0a, 0x0b, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0b, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x03, 0x4c, 0x6f, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x04, 0x2e, 0x4c, 0x6f, 0x67, 0x52, 0x03, 0x4c, 0x6f, 0x67, 0x12, 0x1c, 0x0a, 0x09,
+	0x49, 0x73, 0x50, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x09, 0x49, 0x73, 0x50, 0x72, 0x65, 0x63, 0x65, 0x72, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x55, 0x6e,
+	0x70, 0x61, 0x72, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a,
+	0x55, 0x6e, 0x70, 0x61, 0x72, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x22, 0xc1, 0x01, 0x0a, 0x03, 0x4c,
+	0x6f, 0x67, 0x12, 0x20, 0x0a, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x72, 0x6c, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x55, 0x72, 0x6c, 0x12, 0x2c, 0x0a, 0x11, 0x4d, 0x61, 0x78, 0x69,
+	0x6d, 0x75, 0x6d, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x11, 0x4d, 0x61, 0x78, 0x69, 0x6d, 0x75, 0x6d, 0x4d, 0x65, 0x72, 0x67,
+	0x65, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x65, 0x64, 0x42, 0x79, 0x18, 0x05, 0x20, 0x03, 0x28, 0x03, 0x52, 0x0a, 0x4f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x64, 0x42, 0x79, 0x12, 0x26, 0x0a, 0x0e, 0x44, 0x6e, 0x73, 0x41, 0x70, 0x69,
+	0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x44, 0x6e, 0x73, 0x41, 0x70, 0x69, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0x25,
+	0x0a, 0x0b, 0x4b, 0x6e, 0x6f, 0x77, 0x6e, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x12, 0x16, 0x0a,
+	0x06, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4c,
+	0x6f, 0x67, 0x55, 0x52, 0x4c, 0x22, 0x1d, 0x0a, 0x05, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x14,
+	0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x22, 0x34, 0x0a, 0x0a, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52, 0x61, 0x6e,
+	0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x45, 0x6e, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x45, 0x6e, 0x64, 0x22, 0x32, 0x0a, 0x0b, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x06, 0x52, 0x61, 0x6e,
+	0x67, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x49, 0x6e, 0x64, 0x65,
+	0x78, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x06, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x22, 0xda,
+	0x01, 0x0a, 0x08, 0x54, 0x72, 0x65, 0x65, 0x48, 0x65, 0x61, 0x64, 0x12, 0x16, 0x0a, 0x03, 0x4c,
+	0x6f, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x04, 0x2e, 0x4c, 0x6f, 0x67, 0x52, 0x03,
+	0x4c, 0x6f, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x54, 0x72, 0x65, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x54, 0x72, 0x65, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x1a, 0x0a,
+	0x08, 0x52, 0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x08, 0x52, 0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x73, 0x69,
+	0x73, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x43, 0x6f, 0x6e,
+	0x73, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x4d, 0x69, 0x73, 0x62, 0x65,
+	0x68, 0x61, 0x76, 0x69, 0x6f, 0x75, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x4d,
+	0x69, 0x73, 0x62, 0x65, 0x68, 0x61, 0x76, 0x69, 0x6f, 0x75, 0x72, 0x22, 0x3e, 0x0a, 0x0e, 0x5a,
+	0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x2c, 0x0a,
+	0x0b, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b,
+	0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0xae, 0x01, 0x0a, 0x09,
+	0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x41, 0x70, 0x65,
+	0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x41, 0x70, 0x65, 0x78, 0x12, 0x1c, 0x0a,
+	0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x2c, 0x0a, 0x04, 0x54,
+	0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x5a, 0x6f, 0x6e, 0x65,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54,
+	0x79, 0x70, 0x65, 0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x22, 0x41, 0x0a, 0x0d, 0x5a, 0x6f, 0x6e,
+	0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x0a, 0x46, 0x49,
+	0x52, 0x53, 0x54, 0x5f, 0x53, 0x45, 0x45, 0x4e, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x45,
+	0x47, 0x49, 0x53, 0x54, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a,
+	0x45, 0x58, 0x50, 0x49, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x02, 0x22, 0x2e, 0x0a, 0x06,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x4f, 0x6b, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x46, 0x0a, 0x10,
+	0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x12, 0x32, 0x0a, 0x0d, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x22, 0x41, 0x0a, 0x0b, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x51, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x4a, 0x0a, 0x11, 0x45, 0x6e, 0x74, 0x72, 0x61,
+	0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x35, 0x0a, 0x0e,
+	0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x0e, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e, 0x74, 0x72,
+	0x69, 0x65, 0x73, 0x22, 0x6a, 0x0a, 0x0c, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x46, 0x71, 0x64, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x46, 0x71, 0x64, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x4d, 0x69, 0x6e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x4d,
+	0x69, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x22, 0x0a, 0x0c, 0x4d,
+	0x61, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0c, 0x4d, 0x61, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22,
+	0x20, 0x0a, 0x06, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x4f, 0x66, 0x66,
+	0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x4f, 0x66, 0x66, 0x73, 0x65,
+	0x74, 0x22, 0x81, 0x01, 0x0a, 0x09, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x75, 0x6c, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x20, 0x0a,
+	0x0b, 0x4d, 0x61, 0x78, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0b, 0x4d, 0x61, 0x78, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05,
+	0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x22, 0x2e, 0x0a, 0x0a, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x75,
+	0x6c, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x05, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x05,
+	0x52, 0x75, 0x6c, 0x65, 0x73, 0x22, 0x20, 0x0a, 0x06, 0x41, 0x70, 0x65, 0x78, 0x65, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x41, 0x70, 0x65, 0x78, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x06, 0x41, 0x70, 0x65, 0x78, 0x65, 0x73, 0x22, 0x55, 0x0a, 0x09, 0x46, 0x69, 0x72, 0x73, 0x74,
+	0x53, 0x65, 0x65, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x41, 0x70, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x41, 0x70, 0x65, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x35,
+	0x0a, 0x0d, 0x46, 0x69, 0x72, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x4c, 0x69, 0x73, 0x74, 0x12,
+	0x24, 0x0a, 0x07, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0a, 0x2e, 0x46, 0x69, 0x72, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x52, 0x07, 0x45, 0x6e,
+	0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x27, 0x0a, 0x0f, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69,
+	0x7a, 0x65, 0x64, 0x46, 0x71, 0x64, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x46, 0x71, 0x64, 0x6e,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x46, 0x71, 0x64, 0x6e, 0x73, 0x22, 0x5c,
+	0x0a, 0x10, 0x44, 0x65, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x46, 0x71,
+	0x64, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a,
+	0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x46, 0x71, 0x64, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x46, 0x71, 0x64, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x3c, 0x0a, 0x11,
+	0x44, 0x65, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x46, 0x71, 0x64, 0x6e,
+	0x73, 0x12, 0x27, 0x0a, 0x05, 0x46, 0x71, 0x64, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x44, 0x65, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x46,
+	0x71, 0x64, 0x6e, 0x52, 0x05, 0x46, 0x71, 0x64, 0x6e, 0x73, 0x32, 0xc4, 0x01, 0x0a, 0x0e, 0x4d,
+	0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x41, 0x70, 0x69, 0x12, 0x36, 0x0a,
+	0x10, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x12, 0x05, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x1a, 0x19, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74,
+	0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x0f, 0x53, 0x74, 0x6f, 0x70, 0x4d, 0x65, 0x61,
+	0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75,
+	0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x22, 0x00, 0x12, 0x26, 0x0a, 0x0a, 0x53, 0x74, 0x61, 0x72, 0x74, 0x53, 0x74, 0x61, 0x67, 0x65,
+	0x12, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x25, 0x0a, 0x09, 0x53, 0x74,
+	0x6f, 0x70, 0x53, 0x74, 0x61, 0x67, 0x65, 0x12, 0x0e, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
+	0x00, 0x32, 0xea, 0x01, 0x0a, 0x05, 0x43, 0x74, 0x41, 0x70, 0x69, 0x12, 0x30, 0x0a, 0x0f, 0x53,
+	0x74, 0x6f, 0x72, 0x65, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x0e,
+	0x2e, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x1a, 0x07,
+	0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x28, 0x0a,
+	0x0e, 0x47, 0x65, 0x74, 0x4c, 0x61, 0x73, 0x74, 0x44, 0x42, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x0c, 0x2e, 0x4b, 0x6e, 0x6f, 0x77, 0x6e, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x1a, 0x06, 0x2e,
+	0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x00, 0x12, 0x25, 0x0a, 0x0d, 0x53, 0x74, 0x6f, 0x72, 0x65,
+	0x54, 0x72, 0x65, 0x65, 0x48, 0x65, 0x61, 0x64, 0x12, 0x09, 0x2e, 0x54, 0x72, 0x65, 0x65, 0x48,
+	0x65, 0x61, 0x64, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x2c,
+	0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4c, 0x61, 0x73, 0x74, 0x54, 0x72, 0x65, 0x65, 0x48, 0x65, 0x61,
+	0x64, 0x12, 0x0c, 0x2e, 0x4b, 0x6e, 0x6f, 0x77, 0x6e, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x1a,
+	0x09, 0x2e, 0x54, 0x72, 0x65, 0x65, 0x48, 0x65, 0x61, 0x64, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x10,
+	0x47, 0x65, 0x74, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73,
+	0x12, 0x0c, 0x2e, 0x4b, 0x6e, 0x6f, 0x77, 0x6e, 0x4c, 0x6f, 0x67, 0x55, 0x52, 0x4c, 0x1a, 0x0c,
+	0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x22, 0x00, 0x32, 0x3f,
+	0x0a, 0x0b, 0x5a, 0x6f, 0x6e, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x41, 0x70, 0x69, 0x12, 0x30, 0x0a,
+	0x0e, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x0f, 0x2e, 0x5a, 0x6f, 0x6e, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x32,
+	0x42, 0x0a, 0x09, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x41, 0x70, 0x69, 0x12, 0x35, 0x0a, 0x11,
+	0x53, 0x74, 0x6f, 0x72, 0x65, 0x50, 0x61, 0x73, 0x73, 0x69, 0x76, 0x65, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x11, 0x2e, 0x53, 0x70, 0x6c, 0x75, 0x6e, 0x6b, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x28,
+	0x01, 0x30, 0x01, 0x32, 0x64, 0x0a, 0x0a, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61, 0x41, 0x70,
+	0x69, 0x12, 0x36, 0x0a, 0x11, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64,
+	0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x12, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x61, 0x64, 0x61,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x1e, 0x0a, 0x09, 0x47, 0x65, 0x74,
+	0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x07,
+	0x2e, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0x00, 0x32, 0x80, 0x01, 0x0a, 0x0c, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x6c, 0x69, 0x73, 0x74, 0x41, 0x70, 0x69, 0x12, 0x22, 0x0a, 0x08, 0x53, 0x65,
+	0x74, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x0b, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x75,
+	0x6c, 0x65, 0x73, 0x1a, 0x07, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x21,
+	0x0a, 0x08, 0x47, 0x65, 0x74, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x1a, 0x0b, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x22,
+	0x00, 0x12, 0x29, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x46, 0x69, 0x72, 0x73, 0x74, 0x53, 0x65, 0x65,
+	0x6e, 0x12, 0x07, 0x2e, 0x41, 0x70, 0x65, 0x78, 0x65, 0x73, 0x1a, 0x0e, 0x2e, 0x46, 0x69, 0x72,
+	0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x00, 0x32, 0x49, 0x0a, 0x10,
+	0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x70, 0x69,
+	0x12, 0x35, 0x0a, 0x0b, 0x44, 0x65, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x12,
+	0x10, 0x2e, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x46, 0x71, 0x64, 0x6e,
+	0x73, 0x1a, 0x12, 0x2e, 0x44, 0x65, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x69, 0x7a, 0x65, 0x64,
+	0x46, 0x71, 0x64, 0x6e, 0x73, 0x22, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_api_proto_rawDescOnce sync.Once
+	file_api_proto_rawDescData = file_api_proto_rawDesc
+)
+
+func file_api_proto_rawDescGZIP() []byte {
+	file_api_proto_rawDescOnce.Do(func() {
+		file_api_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_proto_rawDescData)
 	})
 	return file_api_proto_rawDescData
 }
 
 var file_api_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
 var file_api_proto_goTypes = []interface{}{
 	(ZoneEntry_ZoneEntryType)(0),     // 0: ZoneEntry.ZoneEntryType
 	(*Empty)(nil),                    // 1: Empty
@@ -1139,48 +1863,78 @@ var file_api_proto_goTypes = []interface{}{
 	(*Log)(nil),                      // 7: Log
 	(*KnownLogURL)(nil),              // 8: KnownLogURL
 	(*Index)(nil),                    // 9: Index
-	(*ZoneEntryBatch)(nil),           // 10: ZoneEntryBatch
-	(*ZoneEntry)(nil),                // 11: ZoneEntry
-	(*Result)(nil),                   // 12: Result
-	(*SplunkEntryBatch)(nil),         // 13: SplunkEntryBatch
-	(*SplunkEntry)(nil),              // 14: SplunkEntry
-	(*EntradaEntryBatch)(nil),        // 15: EntradaEntryBatch
-	(*EntradaEntry)(nil),             // 16: EntradaEntry
-	(*Offset)(nil),                   // 17: Offset
+	(*IndexRange)(nil),               // 10: IndexRange
+	(*IndexRanges)(nil),              // 11: IndexRanges
+	(*TreeHead)(nil),                 // 12: TreeHead
+	(*ZoneEntryBatch)(nil),           // 13: ZoneEntryBatch
+	(*ZoneEntry)(nil),                // 14: ZoneEntry
+	(*Result)(nil),                   // 15: Result
+	(*SplunkEntryBatch)(nil),         // 16: SplunkEntryBatch
+	(*SplunkEntry)(nil),              // 17: SplunkEntry
+	(*EntradaEntryBatch)(nil),        // 18: EntradaEntryBatch
+	(*EntradaEntry)(nil),             // 19: EntradaEntry
+	(*Offset)(nil),                   // 20: Offset
+	(*WatchRule)(nil),                // 21: WatchRule
+	(*WatchRules)(nil),               // 22: WatchRules
+	(*Apexes)(nil),                   // 23: Apexes
+	(*FirstSeen)(nil),                // 24: FirstSeen
+	(*FirstSeenList)(nil),            // 25: FirstSeenList
+	(*AnonymizedFqdns)(nil),          // 26: AnonymizedFqdns
+	(*DeanonymizedFqdn)(nil),         // 27: DeanonymizedFqdn
+	(*DeanonymizedFqdns)(nil),        // 28: DeanonymizedFqdns
 }
 var file_api_proto_depIdxs = []int32{
 	4,  // 0: StartMeasurementResponse.MeasurementId:type_name -> MeasurementId
 	6,  // 1: LogEntryBatch.LogEntries:type_name -> LogEntry
 	7,  // 2: LogEntry.Log:type_name -> Log
-	11, // 3: ZoneEntryBatch.ZoneEntries:type_name -> ZoneEntry
-	0,  // 4: ZoneEntry.Type:type_name -> ZoneEntry.ZoneEntryType
-	14, // 5: SplunkEntryBatch.SplunkEntries:type_name -> SplunkEntry
-	16, // 6: EntradaEntryBatch.EntradaEntries:type_name -> EntradaEntry
-	3,  // 7: MeasurementApi.StartMeasurement:input_type -> Meta
-	4,  // 8: MeasurementApi.StopMeasurement:input_type -> MeasurementId
-	4,  // 9: MeasurementApi.StartStage:input_type -> MeasurementId
-	4,  // 10: MeasurementApi.StopStage:input_type -> MeasurementId
-	5,  // 11: CtApi.StoreLogEntries:input_type -> LogEntryBatch
-	8,  // 12: CtApi.GetLastDBEntry:input_type -> KnownLogURL
-	10, // 13: ZoneFileApi.StoreZoneEntry:input_type -> ZoneEntryBatch
-	13, // 14: SplunkApi.StorePassiveEntry:input_type -> SplunkEntryBatch
-	15, // 15: EntradaApi.StoreEntradaEntry:input_type -> EntradaEntryBatch
-	1,  // 16: EntradaApi.GetOffset:input_type -> Empty
-	2,  // 17: MeasurementApi.StartMeasurement:output_type -> StartMeasurementResponse
-	1,  // 18: MeasurementApi.StopMeasurement:output_type -> Empty
-	1,  // 19: MeasurementApi.StartStage:output_type -> Empty
-	1,  // 20: MeasurementApi.StopStage:output_type -> Empty
-	12, // 21: CtApi.StoreLogEntries:output_type -> Result
-	9,  // 22: CtApi.GetLastDBEntry:output_type -> Index
-	12, // 23: ZoneFileApi.StoreZoneEntry:output_type -> Result
-	12, // 24: SplunkApi.StorePassiveEntry:output_type -> Result
-	12, // 25: EntradaApi.StoreEntradaEntry:output_type -> Result
-	17, // 26: EntradaApi.GetOffset:output_type -> Offset
-	17, // [17:27] is the sub-list for method output_type
-	7,  // [7:17] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	10, // 3: IndexRanges.Ranges:type_name -> IndexRange
+	7,  // 4: TreeHead.Log:type_name -> Log
+	14, // 5: ZoneEntryBatch.ZoneEntries:type_name -> ZoneEntry
+	0,  // 6: ZoneEntry.Type:type_name -> ZoneEntry.ZoneEntryType
+	17, // 7: SplunkEntryBatch.SplunkEntries:type_name -> SplunkEntry
+	19, // 8: EntradaEntryBatch.EntradaEntries:type_name -> EntradaEntry
+	21, // 9: WatchRules.Rules:type_name -> WatchRule
+	24, // 10: FirstSeenList.Entries:type_name -> FirstSeen
+	27, // 11: DeanonymizedFqdns.Fqdns:type_name -> DeanonymizedFqdn
+	3,  // 12: MeasurementApi.StartMeasurement:input_type -> Meta
+	4,  // 13: MeasurementApi.StopMeasurement:input_type -> MeasurementId
+	4,  // 14: MeasurementApi.StartStage:input_type -> MeasurementId
+	4,  // 15: MeasurementApi.StopStage:input_type -> MeasurementId
+	5,  // 16: CtApi.StoreLogEntries:input_type -> LogEntryBatch
+	8,  // 17: CtApi.GetLastDBEntry:input_type -> KnownLogURL
+	12, // 18: CtApi.StoreTreeHead:input_type -> TreeHead
+	8,  // 19: CtApi.GetLastTreeHead:input_type -> KnownLogURL
+	8,  // 20: CtApi.GetMissingRanges:input_type -> KnownLogURL
+	13, // 21: ZoneFileApi.StoreZoneEntry:input_type -> ZoneEntryBatch
+	16, // 22: SplunkApi.StorePassiveEntry:input_type -> SplunkEntryBatch
+	18, // 23: EntradaApi.StoreEntradaEntry:input_type -> EntradaEntryBatch
+	1,  // 24: EntradaApi.GetOffset:input_type -> Empty
+	22, // 25: WatchlistApi.SetRules:input_type -> WatchRules
+	1,  // 26: WatchlistApi.GetRules:input_type -> Empty
+	23, // 27: WatchlistApi.GetFirstSeen:input_type -> Apexes
+	26, // 28: AnonymizationApi.Deanonymize:input_type -> AnonymizedFqdns
+	2,  // 29: MeasurementApi.StartMeasurement:output_type -> StartMeasurementResponse
+	1,  // 30: MeasurementApi.StopMeasurement:output_type -> Empty
+	1,  // 31: MeasurementApi.StartStage:output_type -> Empty
+	1,  // 32: MeasurementApi.StopStage:output_type -> Empty
+	15, // 33: CtApi.StoreLogEntries:output_type -> Result
+	9,  // 34: CtApi.GetLastDBEntry:output_type -> Index
+	15, // 35: CtApi.StoreTreeHead:output_type -> Result
+	12, // 36: CtApi.GetLastTreeHead:output_type -> TreeHead
+	11, // 37: CtApi.GetMissingRanges:output_type -> IndexRanges
+	15, // 38: ZoneFileApi.StoreZoneEntry:output_type -> Result
+	15, // 39: SplunkApi.StorePassiveEntry:output_type -> Result
+	15, // 40: EntradaApi.StoreEntradaEntry:output_type -> Result
+	20, // 41: EntradaApi.GetOffset:output_type -> Offset
+	15, // 42: WatchlistApi.SetRules:output_type -> Result
+	22, // 43: WatchlistApi.GetRules:output_type -> WatchRules
+	25, // 44: WatchlistApi.GetFirstSeen:output_type -> FirstSeenList
+	28, // 45: AnonymizationApi.Deanonymize:output_type -> DeanonymizedFqdns
+	29, // [29:46] is the sub-list for method output_type
+	12, // [12:29] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_init() }
@@ -1298,7 +2052,7 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ZoneEntryBatch); i {
+			switch v := v.(*IndexRange); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1310,7 +2064,7 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ZoneEntry); i {
+			switch v := v.(*IndexRanges); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1322,7 +2076,7 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Result); i {
+			switch v := v.(*TreeHead); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1334,7 +2088,7 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SplunkEntryBatch); i {
+			switch v := v.(*ZoneEntryBatch); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1346,7 +2100,7 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SplunkEntry); i {
+			switch v := v.(*ZoneEntry); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1358,7 +2112,7 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EntradaEntryBatch); i {
+			switch v := v.(*Result); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1370,7 +2124,7 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EntradaEntry); i {
+			switch v := v.(*SplunkEntryBatch); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1382,6 +2136,42 @@ func file_api_proto_init() {
 			}
 		}
 		file_api_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SplunkEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EntradaEntryBatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EntradaEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Offset); i {
 			case 0:
 				return &v.state
@@ -1393,6 +2183,102 @@ func file_api_proto_init() {
 				return nil
 			}
 		}
+		file_api_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRules); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Apexes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FirstSeen); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FirstSeenList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnonymizedFqdns); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeanonymizedFqdn); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeanonymizedFqdns); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1400,9 +2286,9 @@ func file_api_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   17,
+			NumMessages:   28,
 			NumExtensions: 0,
-			NumServices:   5,
+			NumServices:   7,
 		},
 		GoTypes:           file_api_proto_goTypes,
 		DependencyIndexes: file_api_proto_depIdxs,
@@ -214,6 +214,9 @@ var MeasurementApi_ServiceDesc = grpc.ServiceDesc{
 type CtApiClient interface {
 	StoreLogEntries(ctx context.Context, opts ...grpc.CallOption) (CtApi_StoreLogEntriesClient, error)
 	GetLastDBEntry(ctx context.Context, in *KnownLogURL, opts ...grpc.CallOption) (*Index, error)
+	StoreTreeHead(ctx context.Context, in *TreeHead, opts ...grpc.CallOption) (*Result, error)
+	GetLastTreeHead(ctx context.Context, in *KnownLogURL, opts ...grpc.CallOption) (*TreeHead, error)
+	GetMissingRanges(ctx context.Context, in *KnownLogURL, opts ...grpc.CallOption) (*IndexRanges, error)
 }
 
 type ctApiClient struct {
@@ -264,12 +267,42 @@ func (c *ctApiClient) GetLastDBEntry(ctx context.Context, in *KnownLogURL, opts
 	return out, nil
 }
 
+func (c *ctApiClient) StoreTreeHead(ctx context.Context, in *TreeHead, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/CtApi/StoreTreeHead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ctApiClient) GetLastTreeHead(ctx context.Context, in *KnownLogURL, opts ...grpc.CallOption) (*TreeHead, error) {
+	out := new(TreeHead)
+	err := c.cc.Invoke(ctx, "/CtApi/GetLastTreeHead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ctApiClient) GetMissingRanges(ctx context.Context, in *KnownLogURL, opts ...grpc.CallOption) (*IndexRanges, error) {
+	out := new(IndexRanges)
+	err := c.cc.Invoke(ctx, "/CtApi/GetMissingRanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CtApiServer is the server API for CtApi service.
 // All implementations must embed UnimplementedCtApiServer
 // for forward compatibility
 type CtApiServer interface {
 	StoreLogEntries(CtApi_StoreLogEntriesServer) error
 	GetLastDBEntry(context.Context, *KnownLogURL) (*Index, error)
+	StoreTreeHead(context.Context, *TreeHead) (*Result, error)
+	GetLastTreeHead(context.Context, *KnownLogURL) (*TreeHead, error)
+	GetMissingRanges(context.Context, *KnownLogURL) (*IndexRanges, error)
 	mustEmbedUnimplementedCtApiServer()
 }
 
@@ -283,6 +316,15 @@ func (UnimplementedCtApiServer) StoreLogEntries(CtApi_StoreLogEntriesServer) err
 func (UnimplementedCtApiServer) GetLastDBEntry(context.Context, *KnownLogURL) (*Index, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetLastDBEntry not implemented")
 }
+func (UnimplementedCtApiServer) StoreTreeHead(context.Context, *TreeHead) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StoreTreeHead not implemented")
+}
+func (UnimplementedCtApiServer) GetLastTreeHead(context.Context, *KnownLogURL) (*TreeHead, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLastTreeHead not implemented")
+}
+func (UnimplementedCtApiServer) GetMissingRanges(context.Context, *KnownLogURL) (*IndexRanges, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMissingRanges not implemented")
+}
 func (UnimplementedCtApiServer) mustEmbedUnimplementedCtApiServer() {}
 
 // UnsafeCtApiServer may be embedded to opt out of forward compatibility for this service.
@@ -340,6 +382,60 @@ func _CtApi_GetLastDBEntry_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CtApi_StoreTreeHead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TreeHead)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CtApiServer).StoreTreeHead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CtApi/StoreTreeHead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CtApiServer).StoreTreeHead(ctx, req.(*TreeHead))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CtApi_GetLastTreeHead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KnownLogURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CtApiServer).GetLastTreeHead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CtApi/GetLastTreeHead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CtApiServer).GetLastTreeHead(ctx, req.(*KnownLogURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CtApi_GetMissingRanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KnownLogURL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CtApiServer).GetMissingRanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CtApi/GetMissingRanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CtApiServer).GetMissingRanges(ctx, req.(*KnownLogURL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CtApi_ServiceDesc is the grpc.ServiceDesc for CtApi service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -351,6 +447,18 @@ var CtApi_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetLastDBEntry",
 			Handler:    _CtApi_GetLastDBEntry_Handler,
 		},
+		{
+			MethodName: "StoreTreeHead",
+			Handler:    _CtApi_StoreTreeHead_Handler,
+		},
+		{
+			MethodName: "GetLastTreeHead",
+			Handler:    _CtApi_GetLastTreeHead_Handler,
+		},
+		{
+			MethodName: "GetMissingRanges",
+			Handler:    _CtApi_GetMissingRanges_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -753,3 +861,247 @@ var EntradaApi_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "api.proto",
 }
+
+// WatchlistApiClient is the client API for WatchlistApi service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WatchlistApiClient interface {
+	SetRules(ctx context.Context, in *WatchRules, opts ...grpc.CallOption) (*Result, error)
+	GetRules(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*WatchRules, error)
+	GetFirstSeen(ctx context.Context, in *Apexes, opts ...grpc.CallOption) (*FirstSeenList, error)
+}
+
+type watchlistApiClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWatchlistApiClient(cc grpc.ClientConnInterface) WatchlistApiClient {
+	return &watchlistApiClient{cc}
+}
+
+func (c *watchlistApiClient) SetRules(ctx context.Context, in *WatchRules, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/WatchlistApi/SetRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *watchlistApiClient) GetRules(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*WatchRules, error) {
+	out := new(WatchRules)
+	err := c.cc.Invoke(ctx, "/WatchlistApi/GetRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *watchlistApiClient) GetFirstSeen(ctx context.Context, in *Apexes, opts ...grpc.CallOption) (*FirstSeenList, error) {
+	out := new(FirstSeenList)
+	err := c.cc.Invoke(ctx, "/WatchlistApi/GetFirstSeen", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WatchlistApiServer is the server API for WatchlistApi service.
+// All implementations must embed UnimplementedWatchlistApiServer
+// for forward compatibility
+type WatchlistApiServer interface {
+	SetRules(context.Context, *WatchRules) (*Result, error)
+	GetRules(context.Context, *Empty) (*WatchRules, error)
+	GetFirstSeen(context.Context, *Apexes) (*FirstSeenList, error)
+	mustEmbedUnimplementedWatchlistApiServer()
+}
+
+// UnimplementedWatchlistApiServer must be embedded to have forward compatible implementations.
+type UnimplementedWatchlistApiServer struct {
+}
+
+func (UnimplementedWatchlistApiServer) SetRules(context.Context, *WatchRules) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRules not implemented")
+}
+func (UnimplementedWatchlistApiServer) GetRules(context.Context, *Empty) (*WatchRules, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRules not implemented")
+}
+func (UnimplementedWatchlistApiServer) GetFirstSeen(context.Context, *Apexes) (*FirstSeenList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFirstSeen not implemented")
+}
+func (UnimplementedWatchlistApiServer) mustEmbedUnimplementedWatchlistApiServer() {}
+
+// UnsafeWatchlistApiServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WatchlistApiServer will
+// result in compilation errors.
+type UnsafeWatchlistApiServer interface {
+	mustEmbedUnimplementedWatchlistApiServer()
+}
+
+func RegisterWatchlistApiServer(s grpc.ServiceRegistrar, srv WatchlistApiServer) {
+	s.RegisterService(&WatchlistApi_ServiceDesc, srv)
+}
+
+func _WatchlistApi_SetRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WatchRules)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WatchlistApiServer).SetRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/WatchlistApi/SetRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WatchlistApiServer).SetRules(ctx, req.(*WatchRules))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WatchlistApi_GetRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WatchlistApiServer).GetRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/WatchlistApi/GetRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WatchlistApiServer).GetRules(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WatchlistApi_GetFirstSeen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Apexes)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WatchlistApiServer).GetFirstSeen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/WatchlistApi/GetFirstSeen",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WatchlistApiServer).GetFirstSeen(ctx, req.(*Apexes))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WatchlistApi_ServiceDesc is the grpc.ServiceDesc for WatchlistApi service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WatchlistApi_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "WatchlistApi",
+	HandlerType: (*WatchlistApiServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetRules",
+			Handler:    _WatchlistApi_SetRules_Handler,
+		},
+		{
+			MethodName: "GetRules",
+			Handler:    _WatchlistApi_GetRules_Handler,
+		},
+		{
+			MethodName: "GetFirstSeen",
+			Handler:    _WatchlistApi_GetFirstSeen_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api.proto",
+}
+
+// AnonymizationApiClient is the client API for AnonymizationApi service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnonymizationApiClient interface {
+	Deanonymize(ctx context.Context, in *AnonymizedFqdns, opts ...grpc.CallOption) (*DeanonymizedFqdns, error)
+}
+
+type anonymizationApiClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnonymizationApiClient(cc grpc.ClientConnInterface) AnonymizationApiClient {
+	return &anonymizationApiClient{cc}
+}
+
+func (c *anonymizationApiClient) Deanonymize(ctx context.Context, in *AnonymizedFqdns, opts ...grpc.CallOption) (*DeanonymizedFqdns, error) {
+	out := new(DeanonymizedFqdns)
+	err := c.cc.Invoke(ctx, "/AnonymizationApi/Deanonymize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnonymizationApiServer is the server API for AnonymizationApi service.
+// All implementations must embed UnimplementedAnonymizationApiServer
+// for forward compatibility
+type AnonymizationApiServer interface {
+	Deanonymize(context.Context, *AnonymizedFqdns) (*DeanonymizedFqdns, error)
+	mustEmbedUnimplementedAnonymizationApiServer()
+}
+
+// UnimplementedAnonymizationApiServer must be embedded to have forward compatible implementations.
+type UnimplementedAnonymizationApiServer struct {
+}
+
+func (UnimplementedAnonymizationApiServer) Deanonymize(context.Context, *AnonymizedFqdns) (*DeanonymizedFqdns, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deanonymize not implemented")
+}
+func (UnimplementedAnonymizationApiServer) mustEmbedUnimplementedAnonymizationApiServer() {}
+
+// UnsafeAnonymizationApiServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnonymizationApiServer will
+// result in compilation errors.
+type UnsafeAnonymizationApiServer interface {
+	mustEmbedUnimplementedAnonymizationApiServer()
+}
+
+func RegisterAnonymizationApiServer(s grpc.ServiceRegistrar, srv AnonymizationApiServer) {
+	s.RegisterService(&AnonymizationApi_ServiceDesc, srv)
+}
+
+func _AnonymizationApi_Deanonymize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnonymizedFqdns)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnonymizationApiServer).Deanonymize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/AnonymizationApi/Deanonymize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnonymizationApiServer).Deanonymize(ctx, req.(*AnonymizedFqdns))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AnonymizationApi_ServiceDesc is the grpc.ServiceDesc for AnonymizationApi service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnonymizationApi_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "AnonymizationApi",
+	HandlerType: (*AnonymizationApiServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Deanonymize",
+			Handler:    _AnonymizationApi_Deanonymize_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api.proto",
+}
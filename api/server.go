@@ -43,6 +43,8 @@ type Server struct {
 	prt.EntradaApiServer
 	prt.SplunkApiServer
 	prt.ZoneFileApiServer
+	prt.WatchlistApiServer
+	prt.AnonymizationApiServer
 	Conf  Config
 	Store *store.Store
 	Log   app.ErrLogger
@@ -78,6 +80,8 @@ func (s *Server) Run(lis net.Listener) error {
 	prt.RegisterZoneFileApiServer(serv, s)
 	prt.RegisterSplunkApiServer(serv, s)
 	prt.RegisterEntradaApiServer(serv, s)
+	prt.RegisterWatchlistApiServer(serv, s)
+	prt.RegisterAnonymizationApiServer(serv, s)
 
 	log.Info().Msgf("running gRPC server on %s", lis.Addr().String())
 	return serv.Serve(lis)
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	api "github.com/aau-network-security/gollector/api/proto"
+	"github.com/aau-network-security/gollector/watchlist"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	WatchlistDisabledErr = status.Error(codes.FailedPrecondition, "watchlist is not enabled")
+)
+
+// replaces the rules of the watchlist, which are not persisted across restarts
+func (s *Server) SetRules(ctx context.Context, rules *api.WatchRules) (*api.Result, error) {
+	w := s.Store.Watchlist()
+	if w == nil {
+		return nil, WatchlistDisabledErr
+	}
+	var res []watchlist.Rule
+	for _, r := range rules.Rules {
+		res = append(res, watchlist.Rule{
+			Name:        r.Name,
+			Type:        watchlist.RuleType(r.Type),
+			Value:       r.Value,
+			MaxDistance: int(r.MaxDistance),
+			Allow:       r.Allow,
+		})
+	}
+	if err := w.Set(res); err != nil {
+		return &api.Result{
+			Ok:    false,
+			Error: err.Error(),
+		}, nil
+	}
+	return &api.Result{
+		Ok: true,
+	}, nil
+}
+
+func (s *Server) GetRules(ctx context.Context, _ *api.Empty) (*api.WatchRules, error) {
+	w := s.Store.Watchlist()
+	if w == nil {
+		return nil, WatchlistDisabledErr
+	}
+	res := api.WatchRules{}
+	for _, r := range w.Rules() {
+		res.Rules = append(res.Rules, &api.WatchRule{
+			Name:        r.Name,
+			Type:        string(r.Type),
+			Value:       r.Value,
+			MaxDistance: int64(r.MaxDistance),
+			Allow:       r.Allow,
+		})
+	}
+	return &res, nil
+}
+
+// returns the time at which each source observed the apexes first
+func (s *Server) GetFirstSeen(ctx context.Context, apexes *api.Apexes) (*api.FirstSeenList, error) {
+	firstSeen, err := s.Store.GetFirstSeen(apexes.Apexes)
+	if err != nil {
+		return nil, err
+	}
+	res := api.FirstSeenList{}
+	for _, fs := range firstSeen {
+		res.Entries = append(res.Entries, &api.FirstSeen{
+			Apex:      fs.Apex,
+			Source:    fs.Source,
+			Timestamp: fs.Timestamp.UnixNano() / 1e06,
+		})
+	}
+	return &res, nil
+}
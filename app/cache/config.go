@@ -1,8 +1,11 @@
 package main
 
 import (
+	"encoding/hex"
 	"github.com/aau-network-security/gollector/api"
 	"github.com/aau-network-security/gollector/app"
+	"github.com/aau-network-security/gollector/store"
+	"github.com/aau-network-security/gollector/watchlist"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
@@ -18,22 +21,94 @@ type cacheSize struct {
 	ZoneEntry int `yaml:"zone-entry"`
 }
 
+type publicSuffixList struct {
+	Path      string `yaml:"path"` // the compiled-in list is used if empty
+	IcannOnly bool   `yaml:"icann-only"`
+}
+
 type storeOpts struct {
-	BatchSize int       `yaml:"batch-size"`
-	CacheSize cacheSize `yaml:"cache-size"`
+	BatchSize        int                 `yaml:"batch-size"`
+	CacheSize        cacheSize           `yaml:"cache-size"`
+	PublicSuffixList publicSuffixList    `yaml:"public-suffix-list"`
+	Retention        store.RetentionOpts `yaml:"retention"`
+	PartitionsAhead  int                 `yaml:"partitions-ahead"` // defaults to store.DefaultPartitionsAhead
 }
 
 type anonymizeSalt struct {
 	TldSalt, PSuffixSalt, ApexSalt, FqdnSalt string
 }
 
+type anonymizationKey struct {
+	Id               string `yaml:"id"`
+	Secret           string `yaml:"secret"` // hex encoded
+	PrefixPreserving bool   `yaml:"prefix-preserving"`
+	Reversible       bool   `yaml:"reversible"`
+}
+
+// keys by which domain names are anonymized with HMAC-SHA256 instead of salted hashes, of which keys other than the
+// active key are only used for de-anonymization, and the sources of which domain names are anonymized
+type anonymization struct {
+	ActiveKey string                    `yaml:"active-key"`
+	Keys      []anonymizationKey        `yaml:"keys"`
+	Policy    store.AnonymizationPolicy `yaml:"policy"` // the default policy is used if empty
+}
+
+// returns the policy by which the domain names of each source are anonymized
+func (a *anonymization) policy() (store.AnonymizationPolicy, error) {
+	if len(a.Policy) == 0 {
+		return store.DefaultAnonymizationPolicy, nil
+	}
+	if err := a.Policy.Validate(); err != nil {
+		return nil, err
+	}
+	return a.Policy, nil
+}
+
+// returns the anonymizers of all keys, and the anonymizer of the active key
+func (a *anonymization) keyring() (store.Keyring, *store.HmacLabelAnonymizer, error) {
+	keyring := make(store.Keyring)
+	for _, k := range a.Keys {
+		secret, err := hex.DecodeString(k.Secret)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "decode secret of key '%s'", k.Id)
+		}
+		key := store.AnonymizationKey{
+			Id:     k.Id,
+			Secret: secret,
+		}
+		opts := store.HmacOpts{
+			PrefixPreserving: k.PrefixPreserving,
+			Reversible:       k.Reversible,
+		}
+		la, err := store.NewHmacLabelAnonymizer(key, opts)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "key '%s'", k.Id)
+		}
+		keyring[k.Id] = la
+	}
+	active, ok := keyring[a.ActiveKey]
+	if !ok {
+		return nil, nil, store.UnknownAnonymizationKeyErr
+	}
+	return keyring, active, nil
+}
+
+// the apexes of which the permutations are added to the watchlist
+type typoConfig struct {
+	Protected []string `yaml:"protected"`
+	Suffixes  []string `yaml:"suffixes"`
+}
+
 type config struct {
-	AnonymizeSalt anonymizeSalt `yaml:"anonymize-salt"`
-	Sentry        app.Sentry    `yaml:"sentry"`
-	Api           api.Config    `yaml:"api"`
-	StoreOpts     storeOpts     `yaml:"store"`
-	PprofPort     int           `yaml:"pprof-port"`
-	LogLevel      string        `yaml:"log-level"`
+	AnonymizeSalt anonymizeSalt    `yaml:"anonymize-salt"`
+	Anonymization anonymization    `yaml:"anonymization"`
+	Sentry        app.Sentry       `yaml:"sentry"`
+	Api           api.Config       `yaml:"api"`
+	StoreOpts     storeOpts        `yaml:"store"`
+	PprofPort     int              `yaml:"pprof-port"`
+	LogLevel      string           `yaml:"log-level"`
+	Watchlist     watchlist.Config `yaml:"watchlist"`
+	Typo          typoConfig       `yaml:"typo"`
 }
 
 func readConfig(path string) (config, error) {
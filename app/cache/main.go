@@ -11,7 +11,9 @@ import (
 
 	"github.com/aau-network-security/gollector/api"
 	"github.com/aau-network-security/gollector/app"
+	"github.com/aau-network-security/gollector/collectors/typo"
 	"github.com/aau-network-security/gollector/store"
+	"github.com/aau-network-security/gollector/watchlist"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -23,6 +25,9 @@ func main() {
 	})
 
 	confFile := flag.String("config", "config/config.yml", "location of configuration file")
+	resplit := flag.Bool("resplit", false, "re-split the stored domain names by the public suffix list and exit")
+	partition := flag.Bool("partition", false, "partition the unpartitioned tables of entries by month and exit")
+	detachBefore := flag.String("detach-before", "", "detach the partitions of entries of months before the given month (YYYY-MM) and exit")
 	flag.Parse()
 
 	conf, err := readConfig(*confFile)
@@ -85,8 +90,91 @@ func main() {
 		store.NewSha256LabelAnonymizer(conf.AnonymizeSalt.ApexSalt),
 		store.NewSha256LabelAnonymizer(conf.AnonymizeSalt.FqdnSalt),
 	)
+	if len(conf.Anonymization.Keys) > 0 {
+		keyring, la, err := conf.Anonymization.keyring()
+		if err != nil {
+			log.Fatal().Msgf("error while creating anonymization keys: %s", err)
+		}
+		a = store.NewAnonymizer(la, la, la, la)
+		s = s.WithKeyring(keyring)
+	}
 	s = s.WithAnonymizer(a)
 
+	policy, err := conf.Anonymization.policy()
+	if err != nil {
+		log.Fatal().Msgf("error in anonymization policy: %s", err)
+	}
+	s = s.WithAnonymizationPolicy(policy)
+
+	psl := store.CompiledPublicSuffixList(conf.StoreOpts.PublicSuffixList.IcannOnly)
+	if conf.StoreOpts.PublicSuffixList.Path != "" {
+		psl, err = store.LoadPublicSuffixList(conf.StoreOpts.PublicSuffixList.Path, conf.StoreOpts.PublicSuffixList.IcannOnly)
+		if err != nil {
+			log.Fatal().Msgf("error while loading public suffix list: %s", err)
+		}
+	}
+	log.Info().Msgf("using public suffix list '%s'", psl.Version)
+	s = s.WithPublicSuffixList(psl)
+
+	if *resplit {
+		stats, err := s.ResplitDomains(10000)
+		if err != nil {
+			log.Fatal().Msgf("error while re-splitting domain names: %s", err)
+		}
+		log.Info().Msgf("converted %d domain names to A-labels, re-split %d fqdns and %d apexes", stats.Converted, stats.Fqdns, stats.Apexes)
+		return
+	}
+
+	if *partition {
+		if err := s.PartitionTables(); err != nil {
+			log.Fatal().Msgf("error while partitioning tables: %s", err)
+		}
+		return
+	}
+
+	if *detachBefore != "" {
+		before, err := time.Parse("2006-01", *detachBefore)
+		if err != nil {
+			log.Fatal().Msgf("failed to parse month: %s", err)
+		}
+		detached, err := s.DetachPartitions(before)
+		for _, name := range detached {
+			log.Info().Msgf("detached partition %s", name)
+		}
+		if err != nil {
+			log.Fatal().Msgf("error while detaching partitions: %s", err)
+		}
+		return
+	}
+
+	ahead := conf.StoreOpts.PartitionsAhead
+	if ahead == 0 {
+		ahead = store.DefaultPartitionsAhead
+	}
+	stopPartitions := s.SchedulePartitions(ahead)
+	defer stopPartitions()
+
+	if conf.StoreOpts.Retention.Enabled() {
+		stop := s.SchedulePurge(conf.StoreOpts.Retention)
+		defer stop()
+	}
+
+	rules := conf.Watchlist.Rules
+	for _, apex := range conf.Typo.Protected {
+		perms, err := typo.Generate(apex, conf.Typo.Suffixes)
+		if err != nil {
+			log.Fatal().Str("apex", apex).Msgf("error while generating permutations: %s", err)
+		}
+		rules = append(rules, typo.Rules(perms)...)
+	}
+	w, err := watchlist.New(rules)
+	if err != nil {
+		log.Fatal().Msgf("error while creating watchlist: %s", err)
+	}
+	notifier := watchlist.NewNotifier(conf.Watchlist.Sinks(), watchlist.DefaultBufferSize)
+	defer notifier.Close()
+	s = s.WithWatchlist(w, notifier)
+
 	tags := map[string]string{
 		"app": "cache",
 	}
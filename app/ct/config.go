@@ -2,9 +2,11 @@ package main
 
 import (
 	"github.com/aau-network-security/gollector/app"
+	"github.com/aau-network-security/gollector/collectors/ct"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
+	"time"
 )
 
 type TimeWindow struct {
@@ -13,15 +15,25 @@ type TimeWindow struct {
 	End    string `yaml:"end"`
 }
 
+type Tail struct {
+	Enabled    bool          `yaml:"enabled"`
+	Interval   time.Duration `yaml:"interval"`    // time between polls of the STH of each log
+	MaxBackoff time.Duration `yaml:"max-backoff"` // maximum time to wait after a log returned errors
+}
+
 type config struct {
-	TimeWindow  TimeWindow  `yaml:"time-window"`
-	WorkerCount int         `yaml:"worker_count"`
-	ApiAddr     app.Address `yaml:"api-address"`
-	Meta        app.Meta    `yaml:"meta"`
-	All         bool        `yaml:"all"`
-	Included    []string    `yaml:"included"` // urls to include
-	Excluded    []string    `yaml:"excluded"` // urls to exclude
-	LogLevel    string      `yaml:"log-level"`
+	TimeWindow  TimeWindow    `yaml:"time-window"`
+	WorkerCount int           `yaml:"worker_count"`
+	ApiAddr     app.Address   `yaml:"api-address"`
+	Meta        app.Meta      `yaml:"meta"`
+	All         bool          `yaml:"all"`
+	Included    []string      `yaml:"included"` // urls to include
+	Excluded    []string      `yaml:"excluded"` // urls to exclude
+	LogList     string        `yaml:"log-list"` // URL or path of the log list, defaults to all known logs
+	Filter      ct.FilterOpts `yaml:"filter"`
+	Tail        Tail          `yaml:"tail"`
+	RateLimit   ct.RateLimits `yaml:"rate-limit"`
+	LogLevel    string        `yaml:"log-level"`
 }
 
 func readConfig(path string) (config, error) {
@@ -34,5 +46,9 @@ func readConfig(path string) (config, error) {
 		return conf, errors.Wrap(err, "unmarshal config file")
 	}
 
+	if conf.LogList == "" {
+		conf.LogList = ct.AllLogsUrl
+	}
+
 	return conf, nil
 }
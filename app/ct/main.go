@@ -38,6 +38,106 @@ func certFromLogEntry(entry *ct2.LogEntry) (*x509.Certificate, bool, error) {
 	return cert, isPrecert, nil
 }
 
+// sends log entries to the cache
+type sender interface {
+	Send(ctx context.Context, el interface{}) error
+}
+
+func protoLog(l ct.Log) *prt.Log {
+	var operatedBy []int64
+	for _, ob := range l.OperatedBy {
+		operatedBy = append(operatedBy, int64(ob))
+	}
+
+	return &prt.Log{
+		Description:       l.Description,
+		Key:               l.Key,
+		Url:               l.Url,
+		MaximumMergeDelay: int64(l.MaximumMergeDelay),
+		OperatedBy:        operatedBy,
+		DnsApiEndpoint:    l.DnsApiEndpoint,
+	}
+}
+
+// returns a function that sends the indices of the entries of the given log that cannot be parsed to the cache, such
+// that they are recorded as scanned
+func skipFunc(ctx context.Context, bs sender, l ct.Log) ct.SkipFunc {
+	log := protoLog(l)
+
+	return func(index int64) error {
+		le := prt.LogEntry{
+			Index:      index,
+			Log:        log,
+			Unparsable: true,
+		}
+
+		if err := bs.Send(ctx, &le); err != nil {
+			return errors.Wrap(err, "error while sending skipped log entry to server")
+		}
+		return nil
+	}
+}
+
+// returns a function that sends the entries of the given log to the cache
+func entryFunc(ctx context.Context, bs sender, l ct.Log) ct.EntryFunc {
+	log := protoLog(l)
+	skipFn := skipFunc(ctx, bs, l)
+
+	return func(entry *ct2.LogEntry) error {
+		cert, isPrecert, err := certFromLogEntry(entry)
+		if err != nil {
+			if skipErr := skipFn(entry.Index); skipErr != nil {
+				return skipErr
+			}
+			return err
+		}
+
+		le := prt.LogEntry{
+			Certificate: cert.Raw,
+			Index:       entry.Index,
+			Timestamp:   int64(entry.Leaf.TimestampedEntry.Timestamp),
+			Log:         log,
+			IsPrecert:   isPrecert,
+		}
+
+		if err := bs.Send(ctx, &le); err != nil {
+			return errors.Wrap(err, "error while sending log entry to server")
+		}
+		return nil
+	}
+}
+
+// logs the number of requests made to the log, and how many of them failed
+func logMetrics(l *ct.Log) {
+	lc, err := l.GetClient()
+	if err != nil {
+		return
+	}
+	metrics := lc.Metrics()
+	log.Info().
+		Str("log", l.Name()).
+		Int64("requests", metrics.Requests).
+		Int64("errors", metrics.Errors).
+		Int64("throttled", metrics.Throttled).
+		Msgf("request metrics")
+}
+
+// verifies the current tree head of the log, and returns its tree size
+func verifyTreeHead(ctx context.Context, l *ct.Log, cc prt.CtApiClient) (int64, error) {
+	lc, err := l.GetClient()
+	if err != nil {
+		return 0, err
+	}
+	sth, err := lc.GetSTH(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "get CT STH")
+	}
+	if err := ct.CheckTreeHead(ctx, l, cc, sth); err != nil {
+		return 0, err
+	}
+	return int64(sth.TreeSize), nil
+}
+
 func main() {
 	ctx := context.Background()
 
@@ -110,13 +210,24 @@ func main() {
 		log.Fatal().Msgf("failed to create buffered stream to api: %s", err)
 	}
 
-	logList, err := ct.AllLogs()
+	logList, err := ct.LogListFrom(conf.LogList)
 	if err != nil {
 		log.Fatal().Msgf("error while retrieving list of existing logs: %s", err)
 	}
 
-	logList = logList.Filter(conf.All, conf.Included, conf.Excluded)
+	logList = logList.Filter(conf.All, conf.Included, conf.Excluded).FilterBy(conf.Filter)
 	logs := logList.Logs
+	for i := range logs {
+		logs[i].RateLimit = conf.RateLimit.For(logs[i].Url)
+	}
+
+	if conf.Tail.Enabled {
+		tailLogs(ctx, conf, logs, bs, ctApiClient)
+		if err := bs.CloseSend(ctx); err != nil {
+			log.Fatal().Msgf("error while closing connection to server: %s", err)
+		}
+		return
+	}
 
 	wg := sync.WaitGroup{}
 
@@ -137,6 +248,7 @@ func main() {
 					Str("log", l.Name()).
 					Str("progress", fmt.Sprintf("%d/%d", progress, len(logs))).
 					Msgf("retrieved %d log entries", count)
+				logMetrics(&l)
 				m.Unlock()
 				wg.Done()
 			}()
@@ -146,7 +258,21 @@ func main() {
 				log.Warn().Str("log", l.Name()).Msgf("failed to get the last index from the database: %s", err)
 				return
 			}
+
+			verifiedSize, err := verifyTreeHead(ctx, &l, ctApiClient)
+			switch err.(type) {
+			case nil:
+				if verifiedSize < endIndex {
+					endIndex = verifiedSize
+				}
+			case ct.MisbehaviourErr:
+				log.Error().Str("log", l.Name()).Msgf("skipping log: %s", err)
+				return
+			default:
+				log.Warn().Str("log", l.Name()).Msgf("failed to verify tree head: %s", err)
+			}
 			startIndex := startIndexInDb
+			lowerIndex := int64(0) // gaps below this index are not scanned
 
 			if conf.TimeWindow.Active {
 				startTime, err := time.Parse("2006-01-02", conf.TimeWindow.Start)
@@ -161,24 +287,31 @@ func main() {
 					return
 				}
 
+				// the bounds of the estimated indices are used, such that no entries within the window are missed
 				log.Debug().Str("log", l.Name()).Msgf("obtaining start index from time")
-				startIndexByDate, err := ct.IndexByDate(ctx, &l, startTime)
+				startEst, err := ct.EstimateIndexByDate(ctx, &l, startTime)
 				if err != nil {
 					log.Warn().Str("log", l.Name()).Msgf("failed to obtain index from start time: %s", err)
 					return
 				}
+				startIndexByDate := startEst.Lower
 
 				log.Debug().Str("log", l.Name()).Msgf("obtaining end index from time..")
-				endIndexByDate, err := ct.IndexByDate(ctx, &l, endTime)
+				endEst, err := ct.EstimateIndexByDate(ctx, &l, endTime)
 				if err != nil {
-					log.Warn().Str("log", l.Name()).Msgf("failed to obtain index from start time: %s", err)
+					log.Warn().Str("log", l.Name()).Msgf("failed to obtain index from end time: %s", err)
 					return
 				}
+				endIndexByDate := endEst.Upper
+				log.Debug().
+					Str("log", l.Name()).
+					Msgf("uncertainty of start and end index is %d and %d entries", startEst.Uncertainty(), endEst.Uncertainty())
 				if startIndexByDate == endIndexByDate {
 					log.Warn().Str("log", l.Name()).Msgf("given time window completely falls outside the window of the CT log")
 					return
 				}
 
+				lowerIndex = startIndexByDate
 				if startIndexByDate > startIndex {
 					log.Debug().Msgf("start index of database (%d) is lower than index based on timestamp (%d)", startIndex, startIndexByDate)
 					startIndex = startIndexByDate
@@ -197,12 +330,18 @@ func main() {
 				Str("log", l.Name()).
 				Msgf("end index %d", endIndex)
 
-			totalCount := endIndex - startIndex
-			if totalCount < 0 {
-				log.Error().Str("log", l.Name()).Msgf("cannot continue with a negative entry count: %d", totalCount)
-				return
+			// gaps left by failed batches are scanned before new entries
+			gaps, err := ct.MissingRangesDB(ctx, &l, ctApiClient)
+			if err != nil {
+				log.Warn().Str("log", l.Name()).Msgf("failed to get the missing ranges from the database: %s", err)
+			}
+			ranges := ct.ScheduleRanges(gaps, lowerIndex, startIndex, endIndex)
+
+			var totalCount int64
+			for _, r := range ranges {
+				totalCount += r.End - r.Start
 			}
-			log.Debug().Str("log", l.Name()).Msgf("scanning %d entries", totalCount)
+			log.Debug().Str("log", l.Name()).Msgf("scanning %d entries in %d ranges", totalCount, len(ranges))
 			bar := p.AddBar(totalCount,
 				mpb.PrependDecorators(
 					decor.Name(l.Name()),
@@ -214,51 +353,30 @@ func main() {
 					)))
 			defer bar.Abort(false)
 
+			sendFn := entryFunc(ctx, bs, l)
 			entryFn := func(entry *ct2.LogEntry) error {
 				bar.Increment()
+				return sendFn(entry)
+			}
+			sendSkipFn := skipFunc(ctx, bs, l)
+			skipFn := func(index int64) error {
+				bar.Increment()
+				return sendSkipFn(index)
+			}
 
-				cert, isPrecert, err := certFromLogEntry(entry)
-				if err != nil {
-					return err
-				}
-
-				var operatedBy []int64
-				for _, ob := range l.OperatedBy {
-					operatedBy = append(operatedBy, int64(ob))
-				}
-
-				log := prt.Log{
-					Description:       l.Description,
-					Key:               l.Key,
-					Url:               l.Url,
-					MaximumMergeDelay: int64(l.MaximumMergeDelay),
-					OperatedBy:        operatedBy,
-					DnsApiEndpoint:    l.DnsApiEndpoint,
-				}
-
-				le := prt.LogEntry{
-					Certificate: cert.Raw,
-					Index:       entry.Index,
-					Timestamp:   int64(entry.Leaf.TimestampedEntry.Timestamp),
-					Log:         &log,
-					IsPrecert:   isPrecert,
+			for _, r := range ranges {
+				opts := ct.Options{
+					WorkerCount: conf.WorkerCount,
+					StartIndex:  r.Start,
+					EndIndex:    r.End,
+					SkipFn:      skipFn,
 				}
 
-				if err := bs.Send(ctx, &le); err != nil {
-					return errors.Wrap(err, "error while sending log entry to server")
+				n, err := ct.Scan(ctx, &l, entryFn, opts)
+				count += n
+				if err != nil {
+					log.Debug().Str("log", l.Name()).Msgf("error while scanning range [%d, %d) of log: %s", r.Start, r.End, l.Url)
 				}
-				return nil
-			}
-
-			opts := ct.Options{
-				WorkerCount: conf.WorkerCount,
-				StartIndex:  startIndex,
-				EndIndex:    endIndex,
-			}
-
-			count, err = ct.Scan(ctx, &l, entryFn, opts)
-			if err != nil {
-				log.Debug().Str("log", l.Name()).Msgf("error while scanning log: %s", l.Url)
 			}
 		}(l)
 	}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	prt "github.com/aau-network-security/gollector/api/proto"
+	"github.com/aau-network-security/gollector/collectors/ct"
+	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// continuously retrieves the entries that are appended to the logs, until the process is interrupted. Each log is tailed
+// in its own goroutine, such that the failure of a log does not affect the others.
+func tailLogs(ctx context.Context, conf config, logs []ct.Log, bs sender, cc prt.CtApiClient) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		log.Info().Msgf("stopping to tail logs")
+		cancel()
+	}()
+
+	opts := ct.TailOpts{
+		Interval:    conf.Tail.Interval,
+		MaxBackoff:  conf.Tail.MaxBackoff,
+		WorkerCount: conf.WorkerCount,
+		TreeHeads:   cc,
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(logs))
+	for _, l := range logs {
+		go func(l ct.Log) {
+			defer wg.Done()
+
+			// continue where the previous run left off
+			var start int64
+			for {
+				var err error
+				start, _, err = ct.IndexByLastEntryDB(ctx, &l, cc)
+				if err == nil {
+					break
+				}
+				log.Warn().Str("log", l.Name()).Msgf("failed to get the last index from the database: %s", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Minute):
+				}
+			}
+
+			log.Info().Str("log", l.Name()).Msgf("tailing log from index %d", start)
+			// entries that cannot be parsed are recorded as scanned, as the log is never scanned before start again
+			lopts := opts
+			lopts.SkipFn = skipFunc(ctx, bs, l)
+			if err := ct.Tail(ctx, &l, start, entryFunc(ctx, bs, l), lopts); err != nil {
+				log.Error().Str("log", l.Name()).Msgf("failed to tail log: %s", err)
+			}
+			logMetrics(&l)
+		}(l)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/aau-network-security/gollector/app"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+)
+
+type config struct {
+	Protected []string    `yaml:"protected"`
+	Suffixes  []string    `yaml:"suffixes"`
+	Output    string      `yaml:"output"`
+	ApiAddr   app.Address `yaml:"api-address"`
+	LogLevel  string      `yaml:"log-level"`
+}
+
+func (c *config) isValid() error {
+	ce := app.NewConfigErr()
+	if len(c.Protected) == 0 {
+		ce.Add("at least one protected apex must be specified")
+	}
+	if ce.IsError() {
+		return &ce
+	}
+	return nil
+}
+
+func readConfig(path string) (config, error) {
+	var conf config
+	f, err := ioutil.ReadFile(path)
+	if err != nil {
+		return conf, errors.Wrap(err, "read config file")
+	}
+	if err := yaml.Unmarshal(f, &conf); err != nil {
+		return conf, errors.Wrap(err, "unmarshal config file")
+	}
+
+	return conf, nil
+}
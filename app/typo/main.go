@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	prt "github.com/aau-network-security/gollector/api/proto"
+	"github.com/aau-network-security/gollector/collectors/typo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"io"
+	"os"
+	"time"
+)
+
+// the number of apexes for which the first observations are requested at once
+const chunkSize = 1000
+
+func main() {
+	ctx := context.Background()
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{
+		Out:        os.Stderr,
+		TimeFormat: time.RFC3339,
+	})
+
+	confFile := flag.String("config", "config/config.yml", "location of configuration file")
+	flag.Parse()
+
+	conf, err := readConfig(*confFile)
+	if err != nil {
+		log.Fatal().Msgf("error while reading configuration: %s", err)
+	}
+
+	if err := conf.isValid(); err != nil {
+		log.Fatal().Msgf("invalid typo configuration: %s", err)
+	}
+
+	logLevel, err := zerolog.ParseLevel(conf.LogLevel)
+	if err != nil {
+		log.Fatal().Msgf("error while parsing log level: %s", err)
+	}
+	zerolog.SetGlobalLevel(logLevel)
+
+	var perms []typo.Permutation
+	for _, apex := range conf.Protected {
+		p, err := typo.Generate(apex, conf.Suffixes)
+		if err != nil {
+			log.Fatal().Str("apex", apex).Msgf("error while generating permutations: %s", err)
+		}
+		log.Debug().Str("apex", apex).Msgf("generated %d permutations", len(p))
+		perms = append(perms, p...)
+	}
+
+	cc, err := conf.ApiAddr.Dial()
+	if err != nil {
+		log.Fatal().Msgf("failed to dial: %s", err)
+	}
+	client := prt.NewWatchlistApiClient(cc)
+
+	firstSeen := make(map[string]map[string]time.Time)
+	for i := 0; i < len(perms); i += chunkSize {
+		apexes := prt.Apexes{}
+		for _, p := range perms[i:min(i+chunkSize, len(perms))] {
+			apexes.Apexes = append(apexes.Apexes, p.Apex)
+		}
+		res, err := client.GetFirstSeen(ctx, &apexes)
+		if err != nil {
+			log.Fatal().Msgf("failed to retrieve first observations: %s", err)
+		}
+		for _, fs := range res.Entries {
+			if _, ok := firstSeen[fs.Apex]; !ok {
+				firstSeen[fs.Apex] = make(map[string]time.Time)
+			}
+			firstSeen[fs.Apex][fs.Source] = time.Unix(0, fs.Timestamp*1e06)
+		}
+	}
+	lookalikes := typo.Observed(perms, firstSeen)
+	log.Info().Msgf("%d out of %d permutations have been observed", len(lookalikes), len(perms))
+
+	var out io.Writer = os.Stdout
+	if conf.Output != "" {
+		f, err := os.Create(conf.Output)
+		if err != nil {
+			log.Fatal().Msgf("failed to create report: %s", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := typo.WriteReport(out, lookalikes); err != nil {
+		log.Fatal().Msgf("failed to write report: %s", err)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
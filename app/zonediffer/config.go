@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/aau-network-security/gollector/app"
+	"github.com/aau-network-security/gollector/app/zonediffer/zone"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
@@ -13,8 +14,30 @@ type Resume struct {
 	FinishedTldsFile string `yaml:"finished-tlds-file"`
 }
 
+type Input struct {
+	FilenamePattern string                    `yaml:"filename-pattern"`
+	DateFormat      string                    `yaml:"date-format"`
+	Formats         map[string]zone.TldFormat `yaml:"formats"`
+}
+
+// returns the options for the zone file provider, where missing values are replaced by their defaults
+func (i *Input) ProviderOpts() zone.ProviderOpts {
+	opts := zone.DefaultProviderOpts
+	if i.FilenamePattern != "" {
+		opts.FilenamePattern = i.FilenamePattern
+	}
+	if i.DateFormat != "" {
+		opts.DateFormat = i.DateFormat
+	}
+	if i.Formats != nil {
+		opts.Formats = i.Formats
+	}
+	return opts
+}
+
 type config struct {
 	InputDir    string      `yaml:"input-dir"`
+	Input       Input       `yaml:"input"`
 	ApiAddr     app.Address `yaml:"api-address"`
 	Meta        app.Meta    `yaml:"meta"`
 	LogLevel    string      `yaml:"log-level"`
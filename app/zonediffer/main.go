@@ -100,7 +100,7 @@ func main() {
 	}
 	ignoredTlds, err := readTldsFromFile(conf.Resume.FinishedTldsFile)
 	if err != nil {
-		log.Fatal().Msgf("error while reading file of ignored TLDs: %s")
+		log.Fatal().Msgf("error while reading file of ignored TLDs: %s", err)
 	}
 
 	cc, err := conf.ApiAddr.Dial()
@@ -156,7 +156,7 @@ func main() {
 	log.Info().Msgf("considering zone files between '%s' and '%s", conf.Start.String(), conf.End.String())
 
 	log.Debug().Msgf("creating zone file provider")
-	zfp, err := zone.NewZonefileProvider(conf.InputDir, conf.Start, conf.End)
+	zfp, err := zone.NewZonefileProvider(conf.InputDir, conf.Start, conf.End, conf.Input.ProviderOpts())
 	if err != nil {
 		log.Fatal().Msgf("error while creating zone file provider: %s", err)
 	}
@@ -2,18 +2,19 @@ package zone
 
 import (
 	"bufio"
-	"compress/gzip"
+	"encoding/csv"
+	"fmt"
 	"github.com/miekg/dns"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
 	"io"
 	"io/ioutil"
 	"math"
 	"os"
 	"path"
-	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -30,8 +31,86 @@ type ZoneFile interface {
 	io.Closer
 }
 
+type Format string
+
+const (
+	// a zone file that follows RFC 1035
+	FormatZone Format = "zone"
+	// a file with a single domain name per line
+	FormatList Format = "list"
+	// a CSV file with a domain name in one of the columns
+	FormatCsv Format = "csv"
+)
+
+var (
+	UnknownFormatErr   = errors.New("unknown zone file format")
+	UnknownEncodingErr = errors.New("unknown character encoding")
+	InvalidPatternErr  = errors.New("filename pattern must contain the named groups 'tld' and 'date'")
+	DefaultFormat      = TldFormat{Format: FormatZone}
+	// formats of TLDs that are not read as RFC 1035 zone files unless configured otherwise
+	BuiltinFormats = map[string]TldFormat{
+		"dk": {Format: FormatList, Encoding: "ISO-8859-1"},
+	}
+	DefaultProviderOpts = ProviderOpts{
+		FilenamePattern: `^(?P<tld>[^.]+)\.(?P<date>[^.]+)\.[^.]+$`,
+		DateFormat:      "2006-01-02",
+		Formats:         map[string]TldFormat{},
+	}
+)
+
+// describes how the zone files of a single TLD must be read
+type TldFormat struct {
+	Format     Format `yaml:"format"`
+	Encoding   string `yaml:"encoding"`    // IANA name of the character encoding, e.g. 'ISO-8859-1'
+	Column     int    `yaml:"column"`      // (csv only) index of the column that contains the domain name
+	SkipHeader bool   `yaml:"skip-header"` // (csv only) ignore the first line of the file
+}
+
+func (tf *TldFormat) decoder() (*encoding.Decoder, error) {
+	if tf.Encoding == "" {
+		return nil, nil
+	}
+	enc, err := ianaindex.IANA.Encoding(tf.Encoding)
+	if err != nil || enc == nil {
+		return nil, UnknownEncodingErr
+	}
+	return enc.NewDecoder(), nil
+}
+
+type ProviderOpts struct {
+	FilenamePattern string               // regular expression with the named groups 'tld' and 'date'
+	DateFormat      string               // layout of the 'date' group, as used by time.Parse
+	Formats         map[string]TldFormat // format per TLD, defaults to the built-in format or a RFC 1035 zone file
+}
+
+func (opts *ProviderOpts) format(tld string) TldFormat {
+	if tf, ok := opts.Formats[tld]; ok {
+		return tf
+	}
+	if tf, ok := BuiltinFormats[tld]; ok {
+		return tf
+	}
+	return DefaultFormat
+}
+
+// opens the file in the given path and returns a reader of its decompressed content
+func openFile(path string) (*os.File, io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, r, nil
+}
+
 type standardZonefile struct {
 	f   *os.File
+	r   io.ReadCloser
 	zp  *dns.ZoneParser
 	tld string
 	ts  *time.Time
@@ -40,11 +119,11 @@ type standardZonefile struct {
 func (zf *standardZonefile) Next() (*ZoneFileEntry, error) {
 	rr, ok := zf.zp.Next()
 	if !ok {
+		if err := zf.zp.Err(); err != nil {
+			return nil, err
+		}
 		return nil, io.EOF
 	}
-	if err := zf.zp.Err(); err != nil {
-		return nil, err
-	}
 
 	domain := strings.TrimSuffix(rr.Header().Name, ".")
 	if domain == zf.tld {
@@ -63,6 +142,7 @@ func (zf *standardZonefile) Tld() string {
 }
 
 func (zf *standardZonefile) Close() error {
+	zf.r.Close()
 	return zf.f.Close()
 }
 
@@ -75,20 +155,16 @@ func (zf *standardZonefile) Timestamp() *time.Time {
 }
 
 func newStandardZonefile(path, tld string, ts *time.Time) (ZoneFile, error) {
-	f, err := os.Open(path)
+	f, r, err := openFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	g, err := gzip.NewReader(f)
-	if err != nil {
-		return nil, err
-	}
-
-	zp := dns.NewZoneParser(g, "", "")
+	zp := dns.NewZoneParser(r, "", "")
 
 	zf := standardZonefile{
 		f:   f,
+		r:   r,
 		zp:  zp,
 		tld: tld,
 		ts:  ts,
@@ -96,21 +172,29 @@ func newStandardZonefile(path, tld string, ts *time.Time) (ZoneFile, error) {
 	return &zf, nil
 }
 
-type dkZoneFile struct {
+type listZoneFile struct {
 	f       *os.File
+	r       io.ReadCloser
 	s       *bufio.Scanner
+	tld     string
 	ts      *time.Time
 	decoder *encoding.Decoder
 }
 
-func (zf dkZoneFile) Next() (*ZoneFileEntry, error) {
+func (zf *listZoneFile) Next() (*ZoneFileEntry, error) {
 	if !zf.s.Scan() {
+		if err := zf.s.Err(); err != nil {
+			return nil, err
+		}
 		return nil, io.EOF
 	}
-	domain := []byte(zf.s.Text())
-	domain, err := zf.decoder.Bytes(domain)
-	if err != nil {
-		return nil, err
+	domain := zf.s.Bytes()
+	if zf.decoder != nil {
+		var err error
+		domain, err = zf.decoder.Bytes(domain)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	zfe := ZoneFileEntry{
@@ -120,46 +204,138 @@ func (zf dkZoneFile) Next() (*ZoneFileEntry, error) {
 	return &zfe, nil
 }
 
-func (zf *dkZoneFile) Name() string {
+func (zf *listZoneFile) Name() string {
 	return zf.f.Name()
 }
 
-func (zf *dkZoneFile) Close() error {
+func (zf *listZoneFile) Close() error {
+	zf.r.Close()
 	return zf.f.Close()
 }
 
-func (zf *dkZoneFile) Tld() string {
-	return "dk"
+func (zf *listZoneFile) Tld() string {
+	return zf.tld
 }
 
-func (zf *dkZoneFile) Timestamp() *time.Time {
+func (zf *listZoneFile) Timestamp() *time.Time {
 	return zf.ts
 }
 
-func NewDkZoneFile(path string, ts *time.Time) (ZoneFile, error) {
-	f, err := os.Open(path)
+func newListZoneFile(path, tld string, ts *time.Time, decoder *encoding.Decoder) (ZoneFile, error) {
+	f, r, err := openFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	g, err := gzip.NewReader(f)
+	zf := listZoneFile{
+		f:       f,
+		r:       r,
+		s:       bufio.NewScanner(r),
+		tld:     tld,
+		ts:      ts,
+		decoder: decoder,
+	}
+	return &zf, nil
+}
+
+type csvZoneFile struct {
+	f       *os.File
+	r       io.ReadCloser
+	cr      *csv.Reader
+	tld     string
+	ts      *time.Time
+	column  int
+	decoder *encoding.Decoder
+}
+
+func (zf *csvZoneFile) Next() (*ZoneFileEntry, error) {
+	record, err := zf.cr.Read()
 	if err != nil {
 		return nil, err
 	}
+	if zf.column >= len(record) {
+		return nil, fmt.Errorf("line has %d column(s), but domain is expected in column %d", len(record), zf.column)
+	}
+
+	domain := []byte(strings.TrimSpace(record[zf.column]))
+	if zf.decoder != nil {
+		domain, err = zf.decoder.Bytes(domain)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	zfe := ZoneFileEntry{
+		Domain: strings.TrimSuffix(string(domain), "."),
+	}
+	return &zfe, nil
+}
+
+func (zf *csvZoneFile) Name() string {
+	return zf.f.Name()
+}
+
+func (zf *csvZoneFile) Close() error {
+	zf.r.Close()
+	return zf.f.Close()
+}
+
+func (zf *csvZoneFile) Tld() string {
+	return zf.tld
+}
+
+func (zf *csvZoneFile) Timestamp() *time.Time {
+	return zf.ts
+}
 
-	s := bufio.NewScanner(g)
+func newCsvZoneFile(path, tld string, ts *time.Time, tf TldFormat, decoder *encoding.Decoder) (ZoneFile, error) {
+	f, r, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
 
-	decoder := charmap.ISO8859_1.NewDecoder()
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
 
-	zf := dkZoneFile{
+	zf := csvZoneFile{
 		f:       f,
-		s:       s,
+		r:       r,
+		cr:      cr,
+		tld:     tld,
 		ts:      ts,
+		column:  tf.Column,
 		decoder: decoder,
 	}
+
+	if tf.SkipHeader {
+		if _, err := cr.Read(); err != nil && err != io.EOF {
+			zf.Close()
+			return nil, err
+		}
+	}
+
 	return &zf, nil
 }
 
+// returns a zone file of the given format
+func newZoneFile(path, tld string, ts *time.Time, tf TldFormat) (ZoneFile, error) {
+	decoder, err := tf.decoder()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tf.Format {
+	case FormatZone, "":
+		return newStandardZonefile(path, tld, ts)
+	case FormatList:
+		return newListZoneFile(path, tld, ts, decoder)
+	case FormatCsv:
+		return newCsvZoneFile(path, tld, ts, tf, decoder)
+	}
+	return nil, UnknownFormatErr
+}
+
 type ZonefileProvider struct {
 	zonefiles map[string][]ZoneFile
 }
@@ -197,7 +373,24 @@ func (zfp *ZonefileProvider) Count(tld string) int {
 	return len(l)
 }
 
-func NewZonefileProvider(dir string, start, end time.Time) (*ZonefileProvider, error) {
+func NewZonefileProvider(dir string, start, end time.Time, opts ProviderOpts) (*ZonefileProvider, error) {
+	pattern, err := regexp.Compile(opts.FilenamePattern)
+	if err != nil {
+		return nil, err
+	}
+	tldIdx, dateIdx := -1, -1
+	for i, name := range pattern.SubexpNames() {
+		switch name {
+		case "tld":
+			tldIdx = i
+		case "date":
+			dateIdx = i
+		}
+	}
+	if tldIdx < 0 || dateIdx < 0 {
+		return nil, InvalidPatternErr
+	}
+
 	// read all files in dir
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
@@ -211,17 +404,14 @@ func NewZonefileProvider(dir string, start, end time.Time) (*ZonefileProvider, e
 	zfcount := 0
 	for i, info := range files {
 		fname := info.Name()
-		ext := filepath.Ext(fname)
-		basename := fname[:len(fname)-len(ext)]
-		splitted := strings.Split(basename, ".")
-		if len(splitted) != 2 {
-			//return nil, errors.New(fmt.Sprintf("invalid filename structure: %s", fname))
+		matches := pattern.FindStringSubmatch(fname)
+		if matches == nil {
 			continue
 		}
 
-		tld := splitted[0]
-		tsStr := splitted[1]
-		ts, err := time.Parse("2006-01-02", tsStr)
+		tld := matches[tldIdx]
+		tsStr := matches[dateIdx]
+		ts, err := time.Parse(opts.DateFormat, tsStr)
 		if err != nil {
 			return nil, err
 		}
@@ -237,14 +427,9 @@ func NewZonefileProvider(dir string, start, end time.Time) (*ZonefileProvider, e
 		}
 
 		fpath := path.Join(dir, fname)
-		var zf ZoneFile
-		if tld == "dk" {
-			zf, err = NewDkZoneFile(fpath, &ts)
-		} else {
-			zf, err = newStandardZonefile(fpath, tld, &ts)
-		}
+		zf, err := newZoneFile(fpath, tld, &ts, opts.format(tld))
 		if err != nil {
-			return nil, err
+			return nil, errors.Wrap(err, fname)
 		}
 
 		l = append(l, zf)
@@ -30,7 +30,7 @@ func TestNewZoneFileProvider(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error while parsing start time: %s", err)
 	}
-	zfp, err := NewZonefileProvider(dir, start, end)
+	zfp, err := NewZonefileProvider(dir, start, end, DefaultProviderOpts)
 	if err != nil {
 		t.Fatalf("unexpected error while creating zone file provider: %s", err)
 	}
@@ -141,3 +141,111 @@ func TestNewZoneFileProvider(t *testing.T) {
 		i++
 	}
 }
+
+// returns all domains in the single zone file of the given TLD
+func readDomains(t *testing.T, zfp *ZonefileProvider, tld string) []string {
+	zf, err := zfp.Next(tld)
+	if err != nil {
+		t.Fatalf("unexpected error while obtaining zone file for '%s': %s", tld, err)
+	}
+	defer zf.Close()
+
+	var res []string
+	for {
+		zfe, err := zf.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error while obtaining entry from zone file '%s': %s", zf.Name(), err)
+		}
+		res = append(res, zfe.Domain)
+	}
+	return res
+}
+
+func TestCompressions(t *testing.T) {
+	ts, err := time.Parse("2006-01-02", "2021-02-02")
+	if err != nil {
+		t.Fatalf("unexpected error while parsing time: %s", err)
+	}
+
+	zfp, err := NewZonefileProvider("resources/compression/", ts, ts, DefaultProviderOpts)
+	if err != nil {
+		t.Fatalf("unexpected error while creating zone file provider: %s", err)
+	}
+
+	expected := []string{"example.test", "example2.test"}
+	for _, tld := range []string{"plain", "gz", "bz2", "xz", "zst"} {
+		t.Run(tld, func(t *testing.T) {
+			actual := readDomains(t, zfp, tld)
+			if !StringListEquals(expected, actual) {
+				t.Fatalf("expected zone file entries to be %v, but got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormats(t *testing.T) {
+	ts, err := time.Parse("2006-01-02", "2021-02-02")
+	if err != nil {
+		t.Fatalf("unexpected error while parsing time: %s", err)
+	}
+
+	// dk is read by its built-in format
+	opts := DefaultProviderOpts
+	opts.Formats = map[string]TldFormat{
+		"csv": {
+			Format:     FormatCsv,
+			Column:     1,
+			SkipHeader: true,
+		},
+	}
+
+	zfp, err := NewZonefileProvider("resources/formats/", ts, ts, opts)
+	if err != nil {
+		t.Fatalf("unexpected error while creating zone file provider: %s", err)
+	}
+
+	tests := []struct {
+		tld      string
+		expected []string
+	}{
+		{"dk", []string{"example.dk", "blåbær.dk"}},
+		{"csv", []string{"example.csv", "example2.csv"}},
+	}
+	for _, test := range tests {
+		t.Run(test.tld, func(t *testing.T) {
+			actual := readDomains(t, zfp, test.tld)
+			if !StringListEquals(test.expected, actual) {
+				t.Fatalf("expected zone file entries to be %v, but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFilenamePattern(t *testing.T) {
+	ts, err := time.Parse("2006-01-02", "2021-02-02")
+	if err != nil {
+		t.Fatalf("unexpected error while parsing time: %s", err)
+	}
+
+	opts := ProviderOpts{
+		FilenamePattern: `^zone_(?P<tld>[a-z]+)_(?P<date>\d{8})$`,
+		DateFormat:      "20060102",
+	}
+	zfp, err := NewZonefileProvider("resources/naming/", ts, ts, opts)
+	if err != nil {
+		t.Fatalf("unexpected error while creating zone file provider: %s", err)
+	}
+
+	expected := []string{"example.test", "example2.test"}
+	actual := readDomains(t, zfp, "test")
+	if !StringListEquals(expected, actual) {
+		t.Fatalf("expected zone file entries to be %v, but got %v", expected, actual)
+	}
+
+	opts.FilenamePattern = `^(?P<tld>[a-z]+)$`
+	if _, err := NewZonefileProvider("resources/naming/", ts, ts, opts); err != InvalidPatternErr {
+		t.Fatalf("expected error %q, but got %v", InvalidPatternErr, err)
+	}
+}
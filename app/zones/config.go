@@ -1,31 +1,55 @@
 package main
 
 import (
+	"fmt"
 	"github.com/aau-network-security/gollector/app"
+	"github.com/aau-network-security/gollector/collectors/zone"
+	"github.com/aau-network-security/gollector/collectors/zone/axfr"
 	czds2 "github.com/aau-network-security/gollector/collectors/zone/czds"
 	"github.com/aau-network-security/gollector/collectors/zone/ftp"
 	"github.com/aau-network-security/gollector/collectors/zone/http"
+	"github.com/aau-network-security/gollector/collectors/zone/local"
+	"github.com/aau-network-security/gollector/collectors/zone/sftp"
 	"github.com/aau-network-security/gollector/collectors/zone/ssh"
+	"github.com/aau-network-security/gollector/store"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 const (
-	ComFtpPass = "COM_FTP_PASS"
-	CzdsPass   = "CZDS_PASS"
-	DkSshPass  = "DK_SSH_PASS"
+	ComFtpPass      = "COM_FTP_PASS"
+	CzdsPass        = "CZDS_PASS"
+	DkSshPass       = "DK_SSH_PASS"
+	CatalogueDbPass = "CATALOGUE_DB_PASS"
+	AxfrTsigSecret  = "AXFR_TSIG_SECRET"
+	SftpSshPass     = "SFTP_SSH_PASS"
 )
 
+// returns an error if the mode is unknown
+func isValidMode(m zone.Mode) error {
+	switch m {
+	case "", zone.ModeArchive, zone.ModeStream, zone.ModeDiff:
+		return nil
+	}
+	return fmt.Errorf("unknown mode '%s'", m)
+}
+
 type com struct {
 	Enabled    bool       `yaml:"enabled"`
+	Mode       zone.Mode  `yaml:"mode"`
 	Ftp        ftp.Config `yaml:"ftp"`
 	SshEnabled bool       `yaml:"ssh-enabled"`
 	Ssh        ssh.Config `yaml:"ssh"`
 }
 
 func (c *com) IsValid() error {
+	if err := isValidMode(c.Mode); err != nil {
+		return err
+	}
 	if c.Enabled {
 		return nil
 	}
@@ -46,6 +70,7 @@ func (c *com) IsValid() error {
 
 type dk struct {
 	Enabled bool        `yaml:"enabled"`
+	Mode    zone.Mode   `yaml:"mode"`
 	Http    http.Config `yaml:"http"`
 	Ssh     ssh.Config  `yaml:"ssh"`
 }
@@ -55,6 +80,9 @@ func (d *dk) IsValid() error {
 		return nil
 	}
 	ce := app.NewConfigErr()
+	if err := isValidMode(d.Mode); err != nil {
+		ce.Add(err.Error())
+	}
 	if d.Ssh.AuthType != "password" {
 		ce.Add("SSH auth type must be 'password'")
 	}
@@ -68,14 +96,24 @@ func (d *dk) IsValid() error {
 }
 
 type Czds struct {
-	Enabled     bool              `yaml:"enabled"`
-	ZoneBaseUrl string            `yaml:"zone-base-url"`
-	AuthBaseUrl string            `yaml:"auth-base-url"`
-	Reason      string            `yaml:"reason"`
-	All         bool              `yaml:"all"`
-	Included    []string          `yaml:"included"`
-	Excluded    []string          `yaml:"excluded"`
-	Creds       czds2.Credentials `yaml:"credentials"`
+	Enabled     bool                 `yaml:"enabled"`
+	Mode        zone.Mode            `yaml:"mode"`
+	Modes       map[string]zone.Mode `yaml:"modes"` // overrides the mode of individual TLDs
+	ZoneBaseUrl string               `yaml:"zone-base-url"`
+	AuthBaseUrl string               `yaml:"auth-base-url"`
+	Reason      string               `yaml:"reason"`
+	All         bool                 `yaml:"all"`
+	Included    []string             `yaml:"included"`
+	Excluded    []string             `yaml:"excluded"`
+	Creds       czds2.Credentials    `yaml:"credentials"`
+}
+
+// returns the mode of the given TLD
+func (c *Czds) mode(tld string) zone.Mode {
+	if m, ok := c.Modes[tld]; ok {
+		return m
+	}
+	return c.Mode
 }
 
 func (c *Czds) IsValid() error {
@@ -83,6 +121,14 @@ func (c *Czds) IsValid() error {
 		return nil
 	}
 	ce := app.NewConfigErr()
+	if err := isValidMode(c.Mode); err != nil {
+		ce.Add(err.Error())
+	}
+	for _, m := range c.Modes {
+		if err := isValidMode(m); err != nil {
+			ce.Add(err.Error())
+		}
+	}
 	if c.Creds.Password == "" {
 		ce.Add("password cannot be empty")
 	}
@@ -92,14 +138,147 @@ func (c *Czds) IsValid() error {
 	return nil
 }
 
+type axfrZones struct {
+	Enabled bool          `yaml:"enabled"`
+	Mode    zone.Mode     `yaml:"mode"`
+	Zones   []axfr.Config `yaml:"zones"`
+}
+
+func (a *axfrZones) IsValid() error {
+	if !a.Enabled {
+		return nil
+	}
+	ce := app.NewConfigErr()
+	if err := isValidMode(a.Mode); err != nil {
+		ce.Add(err.Error())
+	}
+	for _, z := range a.Zones {
+		if z.Tld == "" || z.Server == "" {
+			ce.Add("tld and server cannot be empty")
+		}
+		if z.Tsig.Name != "" && z.Tsig.Secret == "" {
+			ce.Add(fmt.Sprintf("TSIG secret cannot be empty for '%s'", z.Tld))
+		}
+	}
+	if ce.IsError() {
+		return &ce
+	}
+	return nil
+}
+
+type sftpZone struct {
+	sftp.Config `yaml:",inline"`
+	Gzip        bool `yaml:"gzip"`
+	List        bool `yaml:"list"` // the file contains a list of domains rather than a zone file
+}
+
+// returns the protocol used to retrieve the zone, which defaults to SFTP
+func (s *sftpZone) protocol() sftp.Protocol {
+	if s.Protocol == "" {
+		return sftp.ProtocolSftp
+	}
+	return s.Protocol
+}
+
+type sftpZones struct {
+	Enabled bool       `yaml:"enabled"`
+	Mode    zone.Mode  `yaml:"mode"`
+	Zones   []sftpZone `yaml:"zones"`
+}
+
+func (s *sftpZones) IsValid() error {
+	if !s.Enabled {
+		return nil
+	}
+	ce := app.NewConfigErr()
+	if err := isValidMode(s.Mode); err != nil {
+		ce.Add(err.Error())
+	}
+	for _, z := range s.Zones {
+		if z.Tld == "" || z.Path == "" {
+			ce.Add("tld and path cannot be empty")
+		}
+		switch z.Protocol {
+		case "", sftp.ProtocolSftp, sftp.ProtocolScp:
+		default:
+			ce.Add(fmt.Sprintf("unknown protocol '%s' for '%s'", z.Protocol, z.Tld))
+		}
+		if z.Ssh.AuthType == "password" && z.Ssh.Password == "" {
+			ce.Add(fmt.Sprintf("SSH password cannot be empty for '%s'", z.Tld))
+		}
+	}
+	if ce.IsError() {
+		return &ce
+	}
+	return nil
+}
+
+type watch struct {
+	local.WatchConfig `yaml:",inline"`
+	Mode              zone.Mode `yaml:"mode"`
+	Lists             []string  `yaml:"lists"` // TLDs of which the files contain a list of domains rather than a zone file
+}
+
+func (w *watch) IsValid(targetDir string) error {
+	ce := app.NewConfigErr()
+	if err := isValidMode(w.Mode); err != nil {
+		ce.Add(err.Error())
+	}
+	if w.Dir == "" {
+		ce.Add("directory cannot be empty")
+	}
+	if w.ArchiveDir == "" {
+		ce.Add("archive directory cannot be empty")
+	}
+	if w.Dir != "" && filepath.Clean(w.Dir) == filepath.Clean(targetDir) {
+		ce.Add("directory cannot be the target directory")
+	}
+	if ce.IsError() {
+		return &ce
+	}
+	return nil
+}
+
+type catalogue struct {
+	Enabled       bool         `yaml:"enabled"`
+	Db            store.Config `yaml:"db"`
+	SkipUnchanged bool         `yaml:"skip-unchanged"`
+	Resolver      string       `yaml:"resolver"`
+	Compact       bool         `yaml:"compact"`
+}
+
+func (c *catalogue) IsValid() error {
+	if !c.Enabled {
+		if c.SkipUnchanged || c.Compact {
+			return errors.New("catalogue must be enabled to skip unchanged zones or compact archives")
+		}
+		return nil
+	}
+	ce := app.NewConfigErr()
+	if c.Db.Host == "" {
+		ce.Add("database host cannot be empty")
+	}
+	if c.SkipUnchanged && c.Resolver == "" {
+		ce.Add("resolver cannot be empty when skipping unchanged zones")
+	}
+	if ce.IsError() {
+		return &ce
+	}
+	return nil
+}
+
 type config struct {
 	Com       com         `yaml:"com"`
 	Czds      Czds        `yaml:"czds"`
 	Dk        dk          `yaml:"dk"`
+	Axfr      axfrZones   `yaml:"axfr"`
+	Sftp      sftpZones   `yaml:"sftp"`
+	Watch     watch       `yaml:"watch"`
 	ApiAddr   app.Address `yaml:"api-address"`
 	Meta      app.Meta    `yaml:"meta"`
 	Now       bool        `yaml:"now"`
 	TargetDir string      `yaml:"target-dir"`
+	Catalogue catalogue   `yaml:"catalogue"`
 }
 
 func (c *config) IsValid() error {
@@ -112,6 +291,15 @@ func (c *config) IsValid() error {
 	if err := c.Com.IsValid(); err != nil {
 		return errors.Wrap(err, "com configuration is invalid")
 	}
+	if err := c.Axfr.IsValid(); err != nil {
+		return errors.Wrap(err, "axfr configuration is invalid")
+	}
+	if err := c.Sftp.IsValid(); err != nil {
+		return errors.Wrap(err, "sftp configuration is invalid")
+	}
+	if err := c.Catalogue.IsValid(); err != nil {
+		return errors.Wrap(err, "catalogue configuration is invalid")
+	}
 	if c.TargetDir == "" {
 		return errors.New("target directory cannot be empty")
 	}
@@ -119,6 +307,22 @@ func (c *config) IsValid() error {
 	return nil
 }
 
+// returns the name of the environment variable that holds a secret of a single zone (e.g. SFTP_SSH_PASS_CO_UK)
+func zoneEnv(env, tld string) string {
+	r := strings.NewReplacer(".", "_", "-", "_")
+	return env + "_" + strings.ToUpper(r.Replace(strings.Trim(tld, ".")))
+}
+
+// returns the value of the first environment variable that is set
+func getenv(envs ...string) string {
+	for _, env := range envs {
+		if v, ok := os.LookupEnv(env); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 func readConfig(path string) (config, error) {
 	var conf config
 	f, err := ioutil.ReadFile(path)
@@ -132,8 +336,20 @@ func readConfig(path string) (config, error) {
 	conf.Com.Ftp.Password = os.Getenv(ComFtpPass)
 	conf.Czds.Creds.Password = os.Getenv(CzdsPass)
 	conf.Dk.Ssh.Password = os.Getenv(DkSshPass)
+	conf.Catalogue.Db.Password = os.Getenv(CatalogueDbPass)
+	var zoneEnvs []string
+	for i, z := range conf.Axfr.Zones {
+		env := zoneEnv(AxfrTsigSecret, z.Tld)
+		conf.Axfr.Zones[i].Tsig.Secret = getenv(env, AxfrTsigSecret)
+		zoneEnvs = append(zoneEnvs, env)
+	}
+	for i, z := range conf.Sftp.Zones {
+		env := zoneEnv(SftpSshPass, z.Tld)
+		conf.Sftp.Zones[i].Ssh.Password = getenv(env, SftpSshPass)
+		zoneEnvs = append(zoneEnvs, env)
+	}
 
-	for _, env := range []string{ComFtpPass, CzdsPass, DkSshPass} {
+	for _, env := range append([]string{ComFtpPass, CzdsPass, DkSshPass, CatalogueDbPass, AxfrTsigSecret, SftpSshPass}, zoneEnvs...) {
 		os.Setenv(env, "")
 	}
 
@@ -8,9 +8,11 @@ import (
 	prt "github.com/aau-network-security/gollector/api/proto"
 	"github.com/aau-network-security/gollector/app"
 	"github.com/aau-network-security/gollector/collectors/zone"
+	"github.com/aau-network-security/gollector/collectors/zone/axfr"
 	czds2 "github.com/aau-network-security/gollector/collectors/zone/czds"
 	"github.com/aau-network-security/gollector/collectors/zone/ftp"
 	"github.com/aau-network-security/gollector/collectors/zone/http"
+	"github.com/aau-network-security/gollector/collectors/zone/sftp"
 	"github.com/aau-network-security/gollector/collectors/zone/ssh"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -27,8 +29,11 @@ import (
 
 type zoneConfig struct {
 	zone           zone.Zone
+	mode           zone.Mode
+	source         string
 	streamWrappers []zone.StreamWrapper
 	streamHandler  zone.StreamHandler
+	inspector      zone.Inspector
 	decoder        *encoding.Decoder
 }
 
@@ -81,8 +86,11 @@ func getZoneConfigs(conf config, client czds2.Client) ([]zoneConfig, error) {
 		}
 		res = append(res, zoneConfig{
 			comZone,
+			conf.Com.Mode,
+			"ftp",
 			[]zone.StreamWrapper{zone.GzipWrapper},
 			zone.ZoneFileHandler,
+			zone.ZoneFileInspector,
 			nil,
 		})
 	}
@@ -96,8 +104,11 @@ func getZoneConfigs(conf config, client czds2.Client) ([]zoneConfig, error) {
 
 		res = append(res, zoneConfig{
 			dkZone,
+			conf.Dk.Mode,
+			"http",
 			nil,
 			zone.ListHandler,
+			zone.ListInspector,
 			charmap.ISO8859_1.NewDecoder(), // must decode Danish domains in zone file
 		})
 	}
@@ -112,8 +123,11 @@ func getZoneConfigs(conf config, client czds2.Client) ([]zoneConfig, error) {
 			z := czds2.NewFromClient(client, tld)
 			zc := zoneConfig{
 				z,
+				conf.Czds.mode(tld),
+				"czds",
 				[]zone.StreamWrapper{zone.GzipWrapper},
 				zone.ZoneFileHandler,
+				zone.ZoneFileInspector,
 				nil,
 			}
 
@@ -121,9 +135,107 @@ func getZoneConfigs(conf config, client czds2.Client) ([]zoneConfig, error) {
 		}
 	}
 
+	if conf.Axfr.Enabled {
+		for _, axfrConf := range conf.Axfr.Zones {
+			z, err := axfr.New(axfrConf)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to create AXFR zone retriever for '%s'", axfrConf.Tld)
+			}
+			res = append(res, zoneConfig{
+				z,
+				conf.Axfr.Mode,
+				"axfr",
+				nil,
+				zone.ZoneFileHandler,
+				zone.ZoneFileInspector,
+				nil,
+			})
+		}
+	}
+
+	if conf.Sftp.Enabled {
+		for _, sftpConf := range conf.Sftp.Zones {
+			z, err := sftp.New(sftpConf.Config)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to create SFTP zone retriever for '%s'", sftpConf.Tld)
+			}
+			zc := zoneConfig{
+				z,
+				conf.Sftp.Mode,
+				string(sftpConf.protocol()),
+				nil,
+				zone.ZoneFileHandler,
+				zone.ZoneFileInspector,
+				nil,
+			}
+			if sftpConf.Gzip {
+				zc.streamWrappers = []zone.StreamWrapper{zone.GzipWrapper}
+			}
+			if sftpConf.List {
+				zc.streamHandler = zone.ListHandler
+				zc.inspector = zone.ListInspector
+			}
+			res = append(res, zc)
+		}
+	}
+
 	return res, nil
 }
 
+// sends zone entries to the cache
+type sender interface {
+	Send(ctx context.Context, el interface{}) error
+}
+
+// processes a zone and sends its domains to the cache with timestamp t, returning the number of processed domains
+func processZone(ctx context.Context, zc zoneConfig, bs sender, t time.Time, opts zone.ProcessOpts) (int, error) {
+	c := 0
+	send := func(domain []byte, zeType prt.ZoneEntry_ZoneEntryType) error {
+		c++
+		if zc.decoder != nil {
+			var err error
+			domain, err = zc.decoder.Bytes(domain)
+			if err != nil {
+				return errors.Wrap(err, "decode domain")
+			}
+		}
+
+		ts := t.UnixNano() / 1e06
+
+		ze := prt.ZoneEntry{
+			Timestamp: ts,
+			Apex:      string(domain),
+			Type:      zeType,
+		}
+
+		if err := bs.Send(ctx, &ze); err != nil {
+			log.Error().Msgf("failed to store domain: %s", err)
+		}
+		return nil
+	}
+
+	opts.Mode = zc.mode
+	opts.DomainFn = func(domain []byte) error {
+		return send(domain, prt.ZoneEntry_FIRST_SEEN)
+	}
+	opts.DiffFn = func(domain []byte, registered bool) error {
+		if registered {
+			return send(domain, prt.ZoneEntry_REGISTRATION)
+		}
+		return send(domain, prt.ZoneEntry_EXPIRATION)
+	}
+	opts.StreamWrappers = zc.streamWrappers
+	opts.StreamHandler = zc.streamHandler
+	opts.Source = zc.source
+	opts.Inspector = zc.inspector
+
+	retryFn := func() error {
+		return zone.Process(zc.zone, opts)
+	}
+	err := app.Retry(retryFn, 3)
+	return c, err
+}
+
 func main() {
 	ctx := context.Background()
 
@@ -143,6 +255,34 @@ func main() {
 	if err := conf.IsValid(); err != nil {
 		log.Fatal().Msgf("invalid configuration: %s", err)
 	}
+	if flag.Arg(0) == "watch" {
+		if err := conf.Watch.IsValid(conf.TargetDir); err != nil {
+			log.Fatal().Msgf("invalid watch configuration: %s", err)
+		}
+	}
+
+	var catalogue zone.Catalogue
+	if conf.Catalogue.Enabled {
+		catalogue, err = zone.NewPostgresCatalogue(conf.Catalogue.Db)
+		if err != nil {
+			log.Fatal().Msgf("failed to open zone file catalogue: %s", err)
+		}
+	}
+
+	if flag.Arg(0) == "verify" {
+		if catalogue == nil {
+			log.Fatal().Msgf("cannot verify zone files without a catalogue")
+		}
+		if err := verifyArchives(catalogue); err != nil {
+			log.Fatal().Msgf("error while verifying zone files: %s", err)
+		}
+		return
+	}
+
+	var serialFn zone.SerialFunc
+	if conf.Catalogue.SkipUnchanged {
+		serialFn = zone.DnsSerialFunc(conf.Catalogue.Resolver)
+	}
 
 	// create target dir if not exists
 	if err := os.MkdirAll(conf.TargetDir, os.ModePerm); err != nil {
@@ -172,33 +312,9 @@ func main() {
 		}
 	}()
 
-	auth := czds2.NewAuthenticator(conf.Czds.Creds, conf.Czds.AuthBaseUrl)
-	client := czds2.NewClient(auth, conf.Czds.ZoneBaseUrl)
-
-	// request access on a daily basis
-	ticker := time.NewTicker(24 * time.Hour)
-	done := make(chan bool)
-	go func() {
-		f := func() error {
-			return client.RequestAccess(conf.Czds.Reason)
-		}
-		for {
-			if err := app.Retry(f, 2); err != nil {
-				log.Warn().Msgf("failed to request access to new zones: %s", err)
-			}
-			select {
-			case <-done:
-				return
-			case <-ticker.C:
-			}
-		}
-	}()
-	defer func() {
-		done <- true
-	}()
-
+	// runs f in a new stage of the measurement, with a stream to the cache
 	c := 0
-	fn := func(t time.Time) error {
+	stage := func(f func(ctx context.Context, bs sender) error) error {
 		defer func() {
 			c++
 		}()
@@ -234,78 +350,10 @@ func main() {
 			log.Fatal().Msgf("failed to create buffered stream to api: %s", err)
 		}
 
-		zoneConfigs, err := getZoneConfigs(conf, client)
-		if err != nil {
-			log.Fatal().Msgf("failed to obtain zone configs: %s", err)
-		}
-
-		log.Info().Msgf("retrieving %d zone files", len(zoneConfigs))
-
-		wg := sync.WaitGroup{}
-		zfSem := semaphore.NewWeighted(10) // allow 10 concurrent zone files to be retrieved
-		wg.Add(len(zoneConfigs))
-		progress := 0
-
-		for _, zc := range zoneConfigs {
-			go func(zc zoneConfig) {
-				defer wg.Done()
-				if err := zfSem.Acquire(ctx, 1); err != nil {
-					log.Error().Msgf("failed to acquire semaphore: %s", err)
-				}
-				defer zfSem.Release(1)
-
-				c := 0
-				domainFn := func(domain []byte) error {
-					c++
-					if zc.decoder != nil {
-						var err error
-						domain, err = zc.decoder.Bytes(domain)
-						if err != nil {
-							return errors.Wrap(err, "decode domain")
-						}
-					}
-
-					ts := t.UnixNano() / 1e06
-
-					ze := prt.ZoneEntry{
-						Timestamp: ts,
-						Apex:      string(domain),
-					}
-
-					if err := bs.Send(ctx, &ze); err != nil {
-						log.Error().Msgf("failed to store domain: %s", err)
-					}
-					return nil
-				}
-
-				opts := zone.ProcessOpts{
-					DomainFn:       domainFn,
-					StreamWrappers: zc.streamWrappers,
-					StreamHandler:  zc.streamHandler,
-					TargetDir:      conf.TargetDir,
-				}
-
-				retryFn := func() error {
-					return zone.Process(zc.zone, opts)
-				}
-				resultStatus := "ok"
-				if err := app.Retry(retryFn, 3); err != nil {
-					log.Error().Msgf("error while processing zone file: %s", err)
-					resultStatus = "failed"
-				}
-				progress++
-
-				log.Info().
-					Str("status", resultStatus).
-					Str("progress", fmt.Sprintf("%d/%d", progress, len(zoneConfigs))).
-					Int("processed domains", c).
-					Msgf("finished zone '%s'", zc.zone.Tld())
-
-			}(zc)
+		if err := f(ctx, bs); err != nil {
+			return err
 		}
 
-		wg.Wait()
-
 		if err := bs.CloseSend(ctx); err != nil {
 			log.Debug().Msgf("failed to close stream: %s", err)
 		}
@@ -317,6 +365,89 @@ func main() {
 		return nil
 	}
 
+	baseOpts := zone.ProcessOpts{
+		TargetDir: conf.TargetDir,
+		Catalogue: catalogue,
+		SerialFn:  serialFn,
+		Compact:   conf.Catalogue.Compact,
+	}
+
+	if flag.Arg(0) == "watch" {
+		if err := watchZones(conf.Watch, baseOpts, stage); err != nil {
+			log.Fatal().Msgf("error while watching zone files: %s", err)
+		}
+		return
+	}
+
+	auth := czds2.NewAuthenticator(conf.Czds.Creds, conf.Czds.AuthBaseUrl)
+	client := czds2.NewClient(auth, conf.Czds.ZoneBaseUrl)
+
+	// request access on a daily basis
+	ticker := time.NewTicker(24 * time.Hour)
+	done := make(chan bool)
+	go func() {
+		f := func() error {
+			return client.RequestAccess(conf.Czds.Reason)
+		}
+		for {
+			if err := app.Retry(f, 2); err != nil {
+				log.Warn().Msgf("failed to request access to new zones: %s", err)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	defer func() {
+		done <- true
+	}()
+
+	fn := func(t time.Time) error {
+		return stage(func(ctx context.Context, bs sender) error {
+			zoneConfigs, err := getZoneConfigs(conf, client)
+			if err != nil {
+				log.Fatal().Msgf("failed to obtain zone configs: %s", err)
+			}
+
+			log.Info().Msgf("retrieving %d zone files", len(zoneConfigs))
+
+			wg := sync.WaitGroup{}
+			zfSem := semaphore.NewWeighted(10) // allow 10 concurrent zone files to be retrieved
+			wg.Add(len(zoneConfigs))
+			progress := 0
+
+			for _, zc := range zoneConfigs {
+				go func(zc zoneConfig) {
+					defer wg.Done()
+					if err := zfSem.Acquire(ctx, 1); err != nil {
+						log.Error().Msgf("failed to acquire semaphore: %s", err)
+					}
+					defer zfSem.Release(1)
+
+					resultStatus := "ok"
+					c, err := processZone(ctx, zc, bs, t, baseOpts)
+					if err != nil {
+						log.Error().Msgf("error while processing zone file: %s", err)
+						resultStatus = "failed"
+					}
+					progress++
+
+					log.Info().
+						Str("status", resultStatus).
+						Str("progress", fmt.Sprintf("%d/%d", progress, len(zoneConfigs))).
+						Int("processed domains", c).
+						Msgf("finished zone '%s'", zc.zone.Tld())
+
+				}(zc)
+			}
+
+			wg.Wait()
+			return nil
+		})
+	}
+
 	st := time.Now().Add(time.Second)
 	interval := 24 * time.Hour
 	if err := app.Repeat(fn, st, interval, -1); err != nil {
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aau-network-security/gollector/collectors/zone"
+	"github.com/rs/zerolog/log"
+)
+
+// re-checks all archived zone files in the catalogue, and stores the result of each check
+func verifyArchives(catalogue zone.Catalogue) error {
+	archives, err := catalogue.List()
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("verifying %d zone file(s)", len(archives))
+
+	invalid := 0
+	for i, za := range archives {
+		status := "ok"
+		if err := zone.Verify(za); err != nil {
+			log.Warn().Str("file", za.Path).Msgf("zone file is invalid: %s", err)
+			status = "invalid"
+			invalid++
+		}
+		if err := catalogue.Update(za); err != nil {
+			return err
+		}
+		log.Debug().
+			Str("status", status).
+			Str("progress", fmt.Sprintf("%d/%d", i+1, len(archives))).
+			Msgf("verified '%s'", za.Path)
+	}
+
+	log.Info().Msgf("%d of %d zone file(s) are invalid", invalid, len(archives))
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"github.com/aau-network-security/gollector/collectors/zone"
+	"github.com/aau-network-security/gollector/collectors/zone/local"
+	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// returns the zone configuration of a file that has been dropped into the watched directory
+func (w *watch) zoneConfig(f local.File) zoneConfig {
+	zc := zoneConfig{
+		f.Zone(),
+		w.Mode,
+		"local",
+		nil,
+		zone.ZoneFileHandler,
+		zone.ZoneFileInspector,
+		nil,
+	}
+	if f.Gzip {
+		zc.streamWrappers = []zone.StreamWrapper{zone.GzipWrapper}
+	}
+	for _, tld := range w.Lists {
+		if tld == f.Tld {
+			zc.streamHandler = zone.ListHandler
+			zc.inspector = zone.ListInspector
+		}
+	}
+	return zc
+}
+
+// processes zone files as they appear in the watched directory, each in its own stage of the measurement, until the
+// process is interrupted
+func watchZones(conf watch, opts zone.ProcessOpts, stage func(func(context.Context, sender) error) error) error {
+	w, err := local.NewWatcher(conf.WatchConfig)
+	if err != nil {
+		return err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		log.Info().Msgf("stopping watcher")
+		w.Close()
+	}()
+
+	log.Info().Msgf("watching '%s' for zone files", conf.Dir)
+	fn := func(f local.File) error {
+		zc := conf.zoneConfig(f)
+		return stage(func(ctx context.Context, bs sender) error {
+			c, err := processZone(ctx, zc, bs, f.Date, opts)
+			if err != nil {
+				return err
+			}
+			log.Info().
+				Int("processed domains", c).
+				Msgf("finished zone file '%s'", f.Path)
+			return nil
+		})
+	}
+	return w.Run(fn)
+}
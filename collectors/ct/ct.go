@@ -2,10 +2,10 @@ package ct
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,25 +19,18 @@ import (
 )
 
 var (
-	NoIndexFoundErr        = errors.New("no index found")
 	UnsupportedCertTypeErr = errors.New("provided certificate is not supported")
+	MaxRetriesErr          = errors.New("max retries reached")
+	UnknownApiErr          = errors.New("unknown log API")
 )
 
-type IndexTooLargeErr struct {
-	t time.Time
-}
-
-func (err IndexTooLargeErr) Error() string {
-	return fmt.Sprintf("cannot determine index to start from, as all entries in log are after upper limit date: %s", err.t)
-}
-
-type EntryCountErr struct {
-	count int
-}
+// the API by which a log serves its entries
+type LogApi string
 
-func (err EntryCountErr) Error() string {
-	return fmt.Sprintf("retrieved %d log entries, where 2 were expected", err.count)
-}
+const (
+	ApiRfc6962 LogApi = "rfc6962"
+	ApiStatic  LogApi = "static" // tiles and checkpoints, as defined by the static CT API
+)
 
 type Sth struct {
 	TreeSize          int    `json:"tree_size"`
@@ -46,120 +39,91 @@ type Sth struct {
 	TreeHeadSignature string `json:"tree_head_signature"`
 }
 
-func indexByDate(ctx context.Context, client *Client, t time.Time, lower int64, upper int64) (int64, error) {
-	middle := (lower + upper) / 2
-
-	entries, err := client.GetEntries(ctx, middle, middle+1)
-	if err != nil {
-		return 0, errors2.Wrap(err, "get CT log entries")
-	}
-	if len(entries) != 2 {
-		return 0, EntryCountErr{len(entries)}
-	}
-	cur, next := entries[0], entries[1]
-	ts := cur.Leaf.TimestampedEntry.Timestamp
-	curTs := time.Unix(int64(ts/1000), int64(ts%1000))
-	ts = next.Leaf.TimestampedEntry.Timestamp
-	nextTs := time.Unix(int64(ts/1000), int64(ts%1000))
-
-	// found it!
-	if t.After(curTs) && t.Before(nextTs) {
-		return middle + 1, nil
-	}
-
-	// must seek left of middle
-	if t.Before(curTs) {
-		// time is under lower bound index, so the first index to return must be ZERO
-		if middle == 0 {
-			return 0, nil
-		}
-		return indexByDate(ctx, client, t, lower, middle)
-	}
-
-	// must seek right of middle
-	if t.After(nextTs) {
-		// time is over upper bound index, so return an IndexTooLargeErr
-		if middle == upper-1 {
-			return 0, IndexTooLargeErr{nextTs}
-		}
-		return indexByDate(ctx, client, t, middle, upper)
-	}
-
-	return 0, NoIndexFoundErr
-}
-
-// returns the index of the first entry in the log past a given timestamp
-func IndexByDate(ctx context.Context, l *Log, t time.Time) (int64, error) {
+func IndexByLastEntryDB(ctx context.Context, l *Log, cc prt.CtApiClient) (int64, int64, error) {
 	lc, err := l.GetClient()
 	if err != nil {
-		return 0, errors2.Wrap(err, "get log client")
+		return 0, 0, errors2.Wrap(err, "get log client")
 	}
 
 	sth, err := lc.GetSTH(ctx)
 	if err != nil {
-		return 0, errors2.Wrap(err, "get CT STH")
-	}
-
-	// get first entry
-	entries, err := lc.GetEntries(ctx, 0, 0)
-	if err != nil {
-		return 0, err
-	}
-	unixMin := entries[0].Leaf.TimestampedEntry.Timestamp
-	tsMin := time.Unix(int64(unixMin/1000), int64(unixMin%1000))
-	if tsMin.After(t) {
-		// first entry is after provided t
-		return 0, nil
+		return 0, 0, errors2.Wrap(err, "get CT STH")
 	}
 
-	// get last entry
-	treeSize := int64(sth.TreeSize)
-	entries, err = lc.GetEntries(ctx, treeSize-1, treeSize-1)
+	index, err := cc.GetLastDBEntry(ctx, &prt.KnownLogURL{
+		LogURL: l.Url,
+	})
 	if err != nil {
-		return 0, err
-	}
-	unixMax := entries[0].Leaf.TimestampedEntry.Timestamp
-	tsMax := time.Unix(int64(unixMax/1000), int64(unixMax%1000))
-	if tsMax.Before(t) {
-		// last entry is before t
-		return treeSize, nil
+		return 0, 0, errors2.Wrap(err, "get Last LogEntry DB")
 	}
 
-	idx, err := indexByDate(ctx, lc, t, 0, int64(sth.TreeSize)-1)
-	if err != nil {
-		return 0, errors2.Wrap(err, "get index by date")
-	}
+	return index.Start, int64(sth.TreeSize), nil
+}
 
-	return idx, nil
+// a range of log entries [Start, End)
+type IndexRange struct {
+	Start int64
+	End   int64
 }
 
-func IndexByLastEntryDB(ctx context.Context, l *Log, cc prt.CtApiClient) (int64, int64, error) {
-	lc, err := l.GetClient()
+// returns the ranges of entries that are missing in the database between the entries that have been stored
+func MissingRangesDB(ctx context.Context, l *Log, cc prt.CtApiClient) ([]IndexRange, error) {
+	resp, err := cc.GetMissingRanges(ctx, &prt.KnownLogURL{
+		LogURL: l.Url,
+	})
 	if err != nil {
-		return 0, 0, errors2.Wrap(err, "get log client")
+		return nil, errors2.Wrap(err, "get missing ranges DB")
 	}
-
-	sth, err := lc.GetSTH(ctx)
-	if err != nil {
-		return 0, 0, errors2.Wrap(err, "get CT STH")
+	var res []IndexRange
+	for _, r := range resp.Ranges {
+		res = append(res, IndexRange{
+			Start: r.Start,
+			End:   r.End,
+		})
 	}
+	return res, nil
+}
 
-	index, err := cc.GetLastDBEntry(ctx, &prt.KnownLogURL{
-		LogURL: l.Url,
-	})
-	if err != nil {
-		return 0, 0, errors2.Wrap(err, "get Last LogEntry DB")
+// returns the ranges to scan, where the gaps that lie within [lower, end) are scanned before the range [start, end)
+func ScheduleRanges(gaps []IndexRange, lower, start, end int64) []IndexRange {
+	var res []IndexRange
+	for _, g := range gaps {
+		if g.Start < lower {
+			g.Start = lower
+		}
+		if g.End > end {
+			g.End = end
+		}
+		if g.End > start {
+			g.End = start
+		}
+		if g.Start < g.End {
+			res = append(res, g)
+		}
+	}
+	if start < end {
+		res = append(res, IndexRange{Start: start, End: end})
 	}
+	return res
+}
 
-	return index.Start, int64(sth.TreeSize), nil
+// retrieves tree heads, consistency proofs and entries from a log, using either the RFC 6962 API or the static CT API
+type Fetcher interface {
+	BaseURI() string
+	GetSTH(ctx context.Context) (*ct.SignedTreeHead, error)
+	GetSTHConsistency(ctx context.Context, first, second uint64) ([][]byte, error)
+	GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error)
 }
 
 type Client struct {
 	cancelFn   context.CancelFunc
 	lock       *sync.Mutex
-	maxRetries float64
-	curRetries float64
-	c          *client.LogClient
+	maxRetries int
+	failures   int   // number of consecutive failed requests for entries, excluding throttled requests
+	batchSize  int64 // maximum number of entries returned by a single request, or zero if not known yet
+	timestamps map[int64]time.Time
+	transport  *limitedTransport
+	c          Fetcher
 }
 
 func (c *Client) BaseURI() string {
@@ -170,58 +134,151 @@ func (c *Client) GetSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
 	return c.c.GetSTH(ctx)
 }
 
+// retrieves the entries in the range [start, end]. Throttled requests are retried by the scanner without limit, but the
+// scan is cancelled after too many consecutive failures for other reasons.
 func (c *Client) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	resp, err := c.c.GetRawEntries(ctx, start, end)
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	resp, err := c.c.GetRawEntries(ctx, start, end)
-	if err == nil && c.curRetries > 0 {
-		c.curRetries -= 0.05
-	} else {
-		c.curRetries += 1
-		if c.curRetries >= c.maxRetries {
-			log.Warn().Msgf("max retries reached")
-			if c.cancelFn != nil {
-				c.cancelFn()
-			}
+	if err == nil {
+		c.failures = 0
+		// a log returns fewer entries than requested when the range exceeds its maximum batch size
+		if n := int64(len(resp.Entries)); n > 0 && n < end-start+1 && n > c.batchSize {
+			c.batchSize = n
+		}
+		return resp, nil
+	}
+	if rspErr, ok := err.(jsonclient.RspError); ok && isThrottled(rspErr.StatusCode) {
+		return nil, err
+	}
+	c.failures++
+	if c.failures >= c.maxRetries {
+		log.Warn().Msgf("max retries reached")
+		if c.cancelFn != nil {
+			c.cancelFn()
 		}
 	}
-	return resp, err
+	return nil, err
+}
+
+// returns the maximum number of entries the log returns for a single request. When this is not known yet, it is
+// discovered by requesting a range of DefaultBatchSize entries starting at the given index.
+func (c *Client) BatchSize(ctx context.Context, start, treeSize int64) int64 {
+	c.lock.Lock()
+	batchSize := c.batchSize
+	c.lock.Unlock()
+	if batchSize > 0 {
+		return batchSize
+	}
+
+	end := start + DefaultBatchSize - 1
+	if end >= treeSize {
+		// the batch size cannot be discovered from a range that exceeds the tree
+		return DefaultBatchSize
+	}
+	resp, err := c.c.GetRawEntries(ctx, start, end)
+	if err != nil || len(resp.Entries) == 0 {
+		return DefaultBatchSize
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.batchSize = int64(len(resp.Entries))
+	return c.batchSize
+}
+
+// returns the number of requests made to the log, and how many failed
+func (c *Client) Metrics() Metrics {
+	c.transport.m.Lock()
+	defer c.transport.m.Unlock()
+	return c.transport.metrics
+}
+
+func (c *Client) GetSTHConsistency(ctx context.Context, first, second uint64) ([][]byte, error) {
+	return c.c.GetSTHConsistency(ctx, first, second)
 }
 
 func (c *Client) GetEntries(ctx context.Context, start, end int64) ([]ct.LogEntry, error) {
-	return c.c.GetEntries(ctx, start, end)
+	resp, err := c.c.GetRawEntries(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ct.LogEntry
+	for i, entry := range resp.Entries {
+		le, err := ct.LogEntryFromLeaf(start+int64(i), &entry)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *le)
+	}
+	return entries, nil
 }
 
 func (c *Client) SetCancelFunc(fn context.CancelFunc) {
 	c.cancelFn = fn
 }
 
+func (c *Client) resetRetries() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.failures = 0
+}
+
 type Log struct {
 	Description       string `json:"description"`
+	LogId             string `json:"log_id"`
 	Key               string `json:"key"`
 	Url               string `json:"url"`
 	MaximumMergeDelay int    `json:"maximum_merge_delay"`
 	OperatedBy        []int  `json:"operated_by"`
 	DnsApiEndpoint    string `json:"dns_api_endpoint"`
-	c                 *Client
+	// the following fields are only available in the v3 log list schema
+	Operator         string
+	State            LogState
+	StateTimestamp   time.Time
+	TemporalInterval *TemporalInterval
+	Api              LogApi    `json:"api"`
+	RateLimit        RateLimit `json:"-"` // defaults to DefaultRateLimit
+	c                *Client
 }
 
 func (l *Log) GetClient() (*Client, error) {
 	if l.c != nil {
 		return l.c, nil
 	}
-	uri := fmt.Sprintf("https://%s", l.Url)
-	hc := http.Client{}
-	jsonOpts := jsonclient.Options{}
-	lc, err := client.New(uri, &hc, jsonOpts)
-	if err != nil {
-		return nil, errors2.Wrap(err, "create new log client")
+	uri := l.Url
+	if !strings.Contains(uri, "://") {
+		uri = fmt.Sprintf("https://%s", l.Url)
+	}
+	transport := newLimitedTransport(l.RateLimit)
+	hc := http.Client{
+		Transport: transport,
+	}
+	var lc Fetcher
+	switch l.Api {
+	case ApiRfc6962, "":
+		jsonOpts := jsonclient.Options{}
+		rc, err := client.New(uri, &hc, jsonOpts)
+		if err != nil {
+			return nil, errors2.Wrap(err, "create new log client")
+		}
+		lc = rc
+	case ApiStatic:
+		sf, err := newStaticFetcher(uri, l.Key, &hc)
+		if err != nil {
+			return nil, errors2.Wrap(err, "create new static log client")
+		}
+		lc = sf
+	default:
+		return nil, UnknownApiErr
 	}
 	client := &Client{
 		lock:       &sync.Mutex{},
 		maxRetries: 100,
-		curRetries: 0,
+		timestamps: make(map[int64]time.Time),
+		transport:  transport,
 		c:          lc,
 	}
 	l.c = client
@@ -279,42 +336,23 @@ func (ll *LogList) Filter(all bool, included []string, excluded []string) *LogLi
 	return &res
 }
 
-// returns a list of logs given the JSON file located at a URL
-func logsFromUrl(url string) (*LogList, error) {
-	c := http.Client{}
-	resp, err := c.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("failed to retrieve log JSON: %d", resp.StatusCode))
-	}
-
-	var logList LogList
-	if err := json.NewDecoder(resp.Body).Decode(&logList); err != nil {
-		return nil, err
-	}
-	return &logList, nil
-}
-
-// returns a list of all known logs
-func AllLogs() (*LogList, error) {
-	return logsFromUrl("https://www.gstatic.com/ct/log_list/all_logs_list.json")
-}
-
-// returns a list of all trusted logs
-func TrustedLogs() (*LogList, error) {
-	return logsFromUrl("https://www.gstatic.com/ct/log_list/log_list.json")
-}
-
 type EntryFunc func(entry *ct.LogEntry) error
 
-func handleRawLogEntryFunc(entryFn EntryFunc) func(rle *ct.RawLogEntry) {
+// handles the index of an entry that could not be parsed
+type SkipFunc func(index int64) error
+
+func handleRawLogEntryFunc(entryFn EntryFunc, skipFn SkipFunc) func(rle *ct.RawLogEntry) {
 	return func(rle *ct.RawLogEntry) {
 		logEntry, err := rle.ToLogEntry()
 		if err != nil {
-			log.Error().Msgf("failed to parse raw log entry: %s", err)
+			log.Error().Msgf("failed to parse raw log entry %d: %s", rle.Index, err)
+			if skipFn == nil {
+				return
+			}
+			if err := skipFn(rle.Index); err != nil {
+				log.Error().Msgf("failed to handle skipped log entry: %s", err)
+			}
+			return
 		}
 		if err := entryFn(logEntry); err != nil {
 			log.Error().Msgf("failed to handle log entry: %s", err)
@@ -322,9 +360,18 @@ func handleRawLogEntryFunc(entryFn EntryFunc) func(rle *ct.RawLogEntry) {
 	}
 }
 
+// matches all entries without parsing them, such that entries that cannot be parsed are passed on to the skip function
+// rather than being dropped by the scanner
+type matchAllLeaves struct{}
+
+func (matchAllLeaves) Matches(*ct.LeafEntry) bool {
+	return true
+}
+
 type Options struct {
 	StartIndex, EndIndex int64
 	WorkerCount          int
+	SkipFn               SkipFunc // called for entries that cannot be parsed, if set
 }
 
 func (o Options) Count() int64 {
@@ -334,32 +381,36 @@ func (o Options) Count() int64 {
 	return o.EndIndex - o.StartIndex
 }
 
+// scans the entries in the range of the options, and returns an error if the log failed too often or ctx is cancelled
 func Scan(ctx context.Context, l *Log, entryFn EntryFunc, opts Options) (int64, error) {
+	parent := ctx
 	ctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
 
 	lc, err := l.GetClient()
 	if err != nil {
 		return 0, err
 	}
 	lc.SetCancelFunc(cancelFn)
+	lc.resetRetries()
 
 	scannerOpts := scanner.ScannerOptions{
 		FetcherOptions: scanner.FetcherOptions{
-			BatchSize:     1000,
+			BatchSize:     int(lc.BatchSize(ctx, opts.StartIndex, opts.EndIndex)),
 			ParallelFetch: opts.WorkerCount,
 			StartIndex:    opts.StartIndex,
 			EndIndex:      opts.EndIndex,
-			Continuous:    true,
+			Continuous:    false,
 		},
-		Matcher:     &scanner.MatchAll{},
+		Matcher:     matchAllLeaves{},
 		PrecertOnly: false,
 		NumWorkers:  opts.WorkerCount,
 	}
 
 	sc := scanner.NewScanner(lc, scannerOpts)
-	rleFunc := handleRawLogEntryFunc(entryFn)
+	rleFunc := handleRawLogEntryFunc(entryFn, opts.SkipFn)
 
-	errChannel := make(chan error)
+	errChannel := make(chan error, 1)
 	go func() {
 		errChannel <- sc.Scan(ctx, rleFunc, rleFunc)
 	}()
@@ -371,21 +422,25 @@ func Scan(ctx context.Context, l *Log, entryFn EntryFunc, opts Options) (int64,
 		}
 		break
 	case <-ctx.Done():
-		break
+		if parent.Err() != nil {
+			return 0, parent.Err()
+		}
+		return 0, MaxRetriesErr
 	}
 	return opts.Count(), nil
 }
 
+// scans the log from the first entry that may be logged after the given time
 func ScanFromTime(ctx context.Context, l *Log, t time.Time, entryFn EntryFunc) (int64, error) {
-	startIndex, err := IndexByDate(ctx, l, t)
+	est, err := EstimateIndexByDate(ctx, l, t)
 	if err != nil {
 		return 0, err
 	}
 
 	opts := Options{
 		WorkerCount: 10,
-		StartIndex:  startIndex,
-		EndIndex:    0,
+		StartIndex:  est.Lower,
+		EndIndex:    est.TreeSize,
 	}
 
 	return Scan(ctx, l, entryFn, opts)
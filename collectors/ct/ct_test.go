@@ -2,11 +2,14 @@ package ct
 
 import (
 	"context"
-	"fmt"
+	"github.com/aau-network-security/gollector/testing/ctlog"
 	ct "github.com/google/certificate-transparency-go"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -38,87 +41,225 @@ func TestLogsFromUrl(t *testing.T) {
 	}
 }
 
-func getEntriesHandleFunc(t *testing.T) http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
-		start := req.URL.Query().Get("start")
-		end := req.URL.Query().Get("end")
-		fname := fmt.Sprintf("fixtures/entry_%s_%s.json", start, end)
-		fileHandler(t, fname)(w, req)
+func TestParseLogListV3(t *testing.T) {
+	logs, err := LogListFrom("fixtures/log_list_v3.json")
+	if err != nil {
+		t.Fatalf("unexpected error while parsing log list: %s", err)
+	}
+	if len(logs.Logs) != 5 {
+		t.Fatalf("expected %d logs, but got %d", 5, len(logs.Logs))
+	}
+	if len(logs.Operators) != 2 {
+		t.Fatalf("expected %d operators, but got %d", 2, len(logs.Operators))
+	}
+
+	l := logs.Logs[0]
+	if l.Url != "ct.googleapis.com/logs/argon2021/" {
+		t.Fatalf("expected url '%s', but got '%s'", "ct.googleapis.com/logs/argon2021/", l.Url)
+	}
+	if l.State != StateUsable {
+		t.Fatalf("expected state '%s', but got '%s'", StateUsable, l.State)
+	}
+	if l.Operator != "Google" || len(l.OperatedBy) != 1 || l.OperatedBy[0] != 0 {
+		t.Fatalf("expected log to be operated by Google (0), but got %s (%v)", l.Operator, l.OperatedBy)
+	}
+	expectedStart := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	if l.TemporalInterval == nil || !l.TemporalInterval.StartInclusive.Equal(expectedStart) {
+		t.Fatalf("expected temporal interval starting at %s, but got %v", expectedStart, l.TemporalInterval)
+	}
+	if logs.Logs[2].State != StateReadOnly || logs.Logs[2].TemporalInterval != nil {
+		t.Fatalf("expected unsharded read-only log, but got %s (%v)", logs.Logs[2].State, logs.Logs[2].TemporalInterval)
 	}
 }
 
-func TestIndexByDate(t *testing.T) {
-	now := time.Now()
+func TestFilterBy(t *testing.T) {
+	logs, err := LogListFrom("fixtures/log_list_v3.json")
+	if err != nil {
+		t.Fatalf("unexpected error while parsing log list: %s", err)
+	}
+
 	tests := []struct {
-		name               string
-		time               time.Time
-		expectedStartIndex int64
-		expectedEndIndex   int64
-		expectErr          bool
+		name     string
+		opts     FilterOpts
+		expected []string
 	}{
 		{
-			name:               "Within range of logs",
-			time:               time.Unix(1502376196, 0), // just before the entry with index 2
-			expectedStartIndex: 2,
-			expectedEndIndex:   5,
+			name: "no criteria",
+			expected: []string{
+				"Google 'Argon2021' log",
+				"Google 'Argon2022' log",
+				"Google 'Pilot' log",
+				"Cloudflare 'Nimbus2021' Log",
+				"Cloudflare 'Nimbus2018' Log",
+			},
+		},
+		{
+			name: "state",
+			opts: FilterOpts{States: []LogState{StateReadOnly, StateRetired}},
+			expected: []string{
+				"Google 'Pilot' log",
+				"Cloudflare 'Nimbus2018' Log",
+			},
 		},
 		{
-			name:               "Before range",
-			time:               time.Unix(0, 0), // 01-01-1970,
-			expectedStartIndex: 0,
-			expectedEndIndex:   5,
+			name: "operator",
+			opts: FilterOpts{Operators: []string{"cloudflare"}},
+			expected: []string{
+				"Cloudflare 'Nimbus2021' Log",
+				"Cloudflare 'Nimbus2018' Log",
+			},
+		},
+		{
+			name: "shard year",
+			opts: FilterOpts{ShardYears: []int{2021}},
+			expected: []string{
+				"Google 'Argon2021' log",
+				"Cloudflare 'Nimbus2021' Log",
+			},
 		},
 		{
-			name:      "After range",
-			time:      now, // timestamp after any cert in the test log data set
-			expectErr: true,
+			name:     "combined",
+			opts:     FilterOpts{States: []LogState{StateUsable}, Operators: []string{"Google"}, ShardYears: []int{2022}},
+			expected: []string{"Google 'Argon2022' log"},
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			ctx := context.Background()
+			filtered := logs.FilterBy(test.opts)
+			var actual []string
+			for _, l := range filtered.Logs {
+				actual = append(actual, l.Description)
+			}
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected logs %v, but got %v", test.expected, actual)
+			}
+			for i := range actual {
+				if actual[i] != test.expected[i] {
+					t.Fatalf("expected logs %v, but got %v", test.expected, actual)
+				}
+			}
+		})
+	}
+}
 
-			mux := http.NewServeMux()
-			mux.HandleFunc("/ct/v1/get-entries", getEntriesHandleFunc(t))
-			mux.HandleFunc("/ct/v1/get-sth", fileHandler(t, "fixtures/sth.json"))
+// counts the requests for entries to a log
+func countingHandler(h http.Handler, count *int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == ct.GetEntriesPath {
+			atomic.AddInt64(count, 1)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
 
-			s := httptest.NewServer(mux)
+func TestIndexByDate(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	mmd := 10 * time.Minute
 
-			l := Log{
-				Url: s.URL,
-			}
+	// entries are logged one minute apart, but their timestamps deviate by up to half the MMD
+	fl, err := ctlog.New()
+	if err != nil {
+		t.Fatalf("failed to create log: %s", err)
+	}
+	defer fl.Close()
+	var timestamps []time.Time
+	for i := 0; i < 200; i++ {
+		offset := time.Duration((i*7)%11-5) * mmd / 10
+		ts := start.Add(time.Duration(i)*time.Minute + offset)
+		if _, err := fl.AddCert(ts, "www.example.org"); err != nil {
+			t.Fatalf("failed to add entry to log: %s", err)
+		}
+		timestamps = append(timestamps, ts)
+	}
+	var requests int64
+	s := httptest.NewServer(countingHandler(fl.Handler(), &requests))
+	defer s.Close()
 
-			startIndex, err := IndexByDate(ctx, &l, test.time)
-			if (err == nil) == test.expectErr {
-				t.Fatalf("expected error: %t, but got: %t", test.expectErr, err == nil)
+	l := Log{
+		Url:               s.URL,
+		MaximumMergeDelay: int(mmd / time.Second),
+	}
+
+	tests := []struct {
+		name          string
+		time          time.Time
+		expectedIndex int64
+	}{
+		{
+			name:          "before first entry",
+			time:          start.Add(-time.Hour),
+			expectedIndex: 0,
+		},
+		{
+			name: "within log",
+			time: start.Add(100 * time.Minute),
+		},
+		{
+			name: "near start of log",
+			time: start.Add(3 * time.Minute),
+		},
+		{
+			name:          "after last entry",
+			time:          start.Add(time.Duration(len(timestamps)+10) * time.Minute),
+			expectedIndex: int64(len(timestamps)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			est, err := EstimateIndexByDate(context.Background(), &l, test.time)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
 			}
-			if startIndex != test.expectedStartIndex {
-				t.Fatalf("expected start index %d, but got %d", test.expectedStartIndex, startIndex)
+			if test.expectedIndex != 0 && est.Index != test.expectedIndex {
+				t.Fatalf("expected index %d, but got %d", test.expectedIndex, est.Index)
+			}
+			if est.Lower > est.Index || est.Index > est.Upper {
+				t.Fatalf("expected index %d to lie within [%d, %d]", est.Index, est.Lower, est.Upper)
+			}
+			for i, ts := range timestamps {
+				if int64(i) < est.Lower && !ts.Before(test.time) {
+					t.Fatalf("expected entry %d before lower bound %d to be logged before %s, but got %s", i, est.Lower, test.time, ts)
+				}
+				if int64(i) >= est.Upper && ts.Before(test.time) {
+					t.Fatalf("expected entry %d after upper bound %d to be logged after %s, but got %s", i, est.Upper, test.time, ts)
+				}
+			}
+			// the uncertainty is bounded by the entries logged within twice the MMD
+			if est.Uncertainty() > 2*int64(mmd/time.Minute)+1 {
+				t.Fatalf("expected uncertainty of at most %d entries, but got %d", 2*int64(mmd/time.Minute)+1, est.Uncertainty())
 			}
 		})
 	}
+
+	// probed entries are cached
+	before := atomic.LoadInt64(&requests)
+	if _, err := IndexByDate(context.Background(), &l, start.Add(100*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if after := atomic.LoadInt64(&requests); after != before {
+		t.Fatalf("expected no requests for entries, but got %d", after-before)
+	}
 }
 
 func TestScanFromTime(t *testing.T) {
-	ctx := context.Background()
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/ct/v1/get-entries", getEntriesHandleFunc(t))
-	mux.HandleFunc("/ct/v1/get-sth", fileHandler(t, "fixtures/sth.json"))
-
-	s := httptest.NewServer(mux)
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	fl := newFakeLog(t, start, 5)
+	defer fl.Close()
 
 	observedCount := 0
+	m := sync.Mutex{}
 	entryFunc := func(entry *ct.LogEntry) error {
+		m.Lock()
+		defer m.Unlock()
 		observedCount++
 		return nil
 	}
 
 	l := Log{
-		Url: s.URL,
+		Url: fl.URL(),
 	}
 
-	receivedCount, err := ScanFromTime(ctx, &l, time.Unix(0, 0), entryFunc)
+	receivedCount, err := ScanFromTime(context.Background(), &l, time.Unix(0, 0), entryFunc)
 	if err != nil {
 		t.Fatalf("unexpected error: %s", err)
 	}
@@ -132,6 +273,61 @@ func TestScanFromTime(t *testing.T) {
 	}
 }
 
+func TestTimeFromMillis(t *testing.T) {
+	expected := time.Date(2021, 1, 1, 0, 0, 0, 250*int(time.Millisecond), time.UTC)
+	if actual := timeFromMillis(1609459200250); !actual.Equal(expected) {
+		t.Fatalf("expected %s, but got %s", expected, actual)
+	}
+}
+
+func TestScheduleRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		gaps     []IndexRange
+		lower    int64
+		start    int64
+		end      int64
+		expected []IndexRange
+	}{
+		{
+			name:     "no gaps",
+			start:    10,
+			end:      20,
+			expected: []IndexRange{{10, 20}},
+		},
+		{
+			name:     "gaps first",
+			gaps:     []IndexRange{{2, 4}, {6, 8}},
+			start:    10,
+			end:      20,
+			expected: []IndexRange{{2, 4}, {6, 8}, {10, 20}},
+		},
+		{
+			name:     "gaps outside window",
+			gaps:     []IndexRange{{2, 4}, {6, 8}, {9, 12}},
+			lower:    7,
+			start:    10,
+			end:      11,
+			expected: []IndexRange{{7, 8}, {9, 10}, {10, 11}},
+		},
+		{
+			name:     "nothing new",
+			gaps:     []IndexRange{{2, 4}},
+			start:    10,
+			end:      10,
+			expected: []IndexRange{{2, 4}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := ScheduleRanges(test.gaps, test.lower, test.start, test.end)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expected ranges %v, but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
 func TestFilter(t *testing.T) {
 	tests := []struct {
 		name        string
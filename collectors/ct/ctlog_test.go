@@ -0,0 +1,168 @@
+package ct
+
+import (
+	"context"
+	prt "github.com/aau-network-security/gollector/api/proto"
+	"github.com/aau-network-security/gollector/testing/ctlog"
+	ct "github.com/google/certificate-transparency-go"
+	"google.golang.org/grpc"
+	"sync"
+	"testing"
+	"time"
+)
+
+// starts a fake log with alternating certificates and precertificates, logged one minute apart
+func newFakeLog(t *testing.T, start time.Time, count int) *ctlog.Log {
+	fl, err := ctlog.New()
+	if err != nil {
+		t.Fatalf("failed to create log: %s", err)
+	}
+	addFakeEntries(t, fl, start, count)
+	return fl
+}
+
+func addFakeEntries(t *testing.T, fl *ctlog.Log, start time.Time, count int) {
+	var err error
+	for i := 0; i < count; i++ {
+		ts := start.Add(time.Duration(i) * time.Minute)
+		if i%2 == 0 {
+			_, err = fl.AddCert(ts, "www.example.org")
+		} else {
+			_, err = fl.AddPrecert(ts, "www.example.org")
+		}
+		if err != nil {
+			t.Fatalf("failed to add entry to log: %s", err)
+		}
+	}
+}
+
+func TestScanFakeLog(t *testing.T) {
+	fl := newFakeLog(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), 25)
+	defer fl.Close()
+	// forces the scanner to handle partial responses
+	fl.SetMaxBatchSize(7)
+
+	m := sync.Mutex{}
+	observed := make(map[int64]bool)
+	precerts := 0
+	entryFn := func(entry *ct.LogEntry) error {
+		m.Lock()
+		defer m.Unlock()
+		observed[entry.Index] = true
+		if entry.Precert != nil {
+			precerts++
+		}
+		return nil
+	}
+
+	l := Log{Url: fl.URL()}
+	opts := Options{
+		WorkerCount: 2,
+		StartIndex:  3,
+		EndIndex:    23,
+	}
+	if _, err := Scan(context.Background(), &l, entryFn, opts); err != nil {
+		t.Fatalf("unexpected error while scanning log: %s", err)
+	}
+	if len(observed) != 20 {
+		t.Fatalf("expected %d observed entries, but got %d", 20, len(observed))
+	}
+	for i := int64(3); i < 23; i++ {
+		if !observed[i] {
+			t.Fatalf("expected entry %d to be observed", i)
+		}
+	}
+	if precerts != 10 {
+		t.Fatalf("expected %d precertificates, but got %d", 10, precerts)
+	}
+}
+
+func TestScanFakeLogSkipped(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	fl := newFakeLog(t, start, 2)
+	defer fl.Close()
+	if err := fl.AddMalformedCert(start.Add(2 * time.Minute)); err != nil {
+		t.Fatalf("failed to add entry to log: %s", err)
+	}
+	addFakeEntries(t, fl, start.Add(3*time.Minute), 2)
+
+	m := sync.Mutex{}
+	observed := 0
+	var skipped []int64
+	entryFn := func(entry *ct.LogEntry) error {
+		m.Lock()
+		defer m.Unlock()
+		observed++
+		return nil
+	}
+	skipFn := func(index int64) error {
+		m.Lock()
+		defer m.Unlock()
+		skipped = append(skipped, index)
+		return nil
+	}
+
+	l := Log{Url: fl.URL()}
+	opts := Options{
+		WorkerCount: 1,
+		StartIndex:  0,
+		EndIndex:    5,
+		SkipFn:      skipFn,
+	}
+	if _, err := Scan(context.Background(), &l, entryFn, opts); err != nil {
+		t.Fatalf("unexpected error while scanning log: %s", err)
+	}
+	if observed != 4 {
+		t.Fatalf("expected %d observed entries, but got %d", 4, observed)
+	}
+	if len(skipped) != 1 || skipped[0] != 2 {
+		t.Fatalf("expected entry %d to be skipped, but got %v", 2, skipped)
+	}
+}
+
+// returns a fixed index as the last entry that is stored in the database
+type lastEntryClient struct {
+	prt.CtApiClient
+	start int64
+}
+
+func (c *lastEntryClient) GetLastDBEntry(ctx context.Context, url *prt.KnownLogURL, opts ...grpc.CallOption) (*prt.Index, error) {
+	return &prt.Index{Start: c.start}, nil
+}
+
+func TestIndexByLastEntryDB(t *testing.T) {
+	fl := newFakeLog(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), 12)
+	defer fl.Close()
+
+	l := Log{Url: fl.URL()}
+	start, end, err := IndexByLastEntryDB(context.Background(), &l, &lastEntryClient{start: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if start != 5 || end != 12 {
+		t.Fatalf("expected range [%d, %d), but got [%d, %d)", 5, 12, start, end)
+	}
+}
+
+func TestCheckTreeHeadFakeLog(t *testing.T) {
+	fl := newFakeLog(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), 3)
+	defer fl.Close()
+
+	l := Log{
+		Url: fl.URL(),
+		Key: fl.Key(),
+	}
+	cc := treeHeadClient{}
+	for i := 0; i < 3; i++ {
+		sth, err := fl.STH()
+		if err != nil {
+			t.Fatalf("failed to get tree head: %s", err)
+		}
+		if err := CheckTreeHead(context.Background(), &l, &cc, sth); err != nil {
+			t.Fatalf("unexpected error while checking tree head of size %d: %s", sth.TreeSize, err)
+		}
+		if _, err := fl.AddCert(time.Now(), "example.org"); err != nil {
+			t.Fatalf("failed to add entry to log: %s", err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package ct
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	errors2 "github.com/pkg/errors"
+	"time"
+)
+
+const (
+	// used for logs of which the maximum merge delay is unknown
+	DefaultMaximumMergeDelay = 24 * time.Hour
+)
+
+var (
+	EmptyLogErr = errors.New("log does not contain any entries")
+)
+
+// converts a timestamp of a CT log, in milliseconds since the epoch
+func timeFromMillis(ms uint64) time.Time {
+	return time.Unix(int64(ms/1000), int64(ms%1000)*int64(time.Millisecond))
+}
+
+// the index of the first entry of a log that is logged at or after a given time. Logs only order entries by their
+// timestamp up to the maximum merge delay, so the first such entry lies somewhere in [Lower, Upper]: all entries
+// before Lower are logged before the time, and all entries from Upper onwards are logged at or after the time. An
+// index equal to the tree size indicates that no entry is logged at or after the time.
+type IndexEstimate struct {
+	Index    int64 // index at which the timestamps cross the given time
+	Lower    int64
+	Upper    int64
+	TreeSize int64 // size of the tree in which the index was searched
+}
+
+// returns the number of entries of which it is unknown whether they were logged before or after the time
+func (e IndexEstimate) Uncertainty() int64 {
+	return e.Upper - e.Lower
+}
+
+func (l *Log) maximumMergeDelay() time.Duration {
+	if l.MaximumMergeDelay <= 0 {
+		return DefaultMaximumMergeDelay
+	}
+	return time.Duration(l.MaximumMergeDelay) * time.Second
+}
+
+// returns the timestamp of the entry at the given index, which is cached as entries never change
+func (c *Client) timestamp(ctx context.Context, idx int64) (time.Time, error) {
+	c.lock.Lock()
+	ts, ok := c.timestamps[idx]
+	c.lock.Unlock()
+	if ok {
+		return ts, nil
+	}
+
+	resp, err := c.c.GetRawEntries(ctx, idx, idx)
+	if err != nil {
+		return time.Time{}, errors2.Wrap(err, "get CT log entries")
+	}
+	if len(resp.Entries) == 0 {
+		return time.Time{}, fmt.Errorf("log returned no entry for index %d", idx)
+	}
+	var leaf ct.MerkleTreeLeaf
+	if _, err := tls.Unmarshal(resp.Entries[0].LeafInput, &leaf); err != nil {
+		return time.Time{}, errors2.Wrap(err, "parse leaf")
+	}
+	if leaf.TimestampedEntry == nil {
+		return time.Time{}, fmt.Errorf("entry %d has no timestamp", idx)
+	}
+	ts = timeFromMillis(leaf.TimestampedEntry.Timestamp)
+
+	c.lock.Lock()
+	c.timestamps[idx] = ts
+	c.lock.Unlock()
+	return ts, nil
+}
+
+// returns the index in [0, treeSize] at which the timestamps cross t, such that the preceding entry is logged before t
+// and the entry at the index is logged at or after t
+func (c *Client) crossing(ctx context.Context, t time.Time, treeSize int64) (int64, error) {
+	lower, upper := int64(0), treeSize
+	for lower < upper {
+		middle := lower + (upper-lower)/2
+		ts, err := c.timestamp(ctx, middle)
+		if err != nil {
+			return 0, err
+		}
+		if ts.Before(t) {
+			lower = middle + 1
+		} else {
+			upper = middle
+		}
+	}
+	return lower, nil
+}
+
+// estimates the index of the first entry that is logged at or after t. Since an entry is incorporated in the log
+// within the maximum merge delay (MMD) of its timestamp, an entry logged more than the MMD before t is preceded by
+// entries logged before t only, and an entry logged more than the MMD after t is followed by entries logged after t only.
+func EstimateIndexByDate(ctx context.Context, l *Log, t time.Time) (IndexEstimate, error) {
+	var est IndexEstimate
+	lc, err := l.GetClient()
+	if err != nil {
+		return est, errors2.Wrap(err, "get log client")
+	}
+
+	sth, err := lc.GetSTH(ctx)
+	if err != nil {
+		return est, errors2.Wrap(err, "get CT STH")
+	}
+	treeSize := int64(sth.TreeSize)
+	if treeSize == 0 {
+		return est, EmptyLogErr
+	}
+	est.TreeSize = treeSize
+
+	mmd := l.maximumMergeDelay()
+	if est.Index, err = lc.crossing(ctx, t, treeSize); err != nil {
+		return est, err
+	}
+	if est.Lower, err = lc.crossing(ctx, t.Add(-mmd), treeSize); err != nil {
+		return est, err
+	}
+	if est.Upper, err = lc.crossing(ctx, t.Add(mmd), treeSize); err != nil {
+		return est, err
+	}
+	// the crossings are not ordered when timestamps are out of order
+	if est.Lower > est.Index {
+		est.Lower = est.Index
+	}
+	if est.Upper < est.Index {
+		est.Upper = est.Index
+	}
+	return est, nil
+}
+
+// returns the index of the first entry in the log past a given timestamp. As timestamps are only ordered up to the
+// maximum merge delay of the log, entries before the index may be logged after the timestamp as well; use
+// EstimateIndexByDate to obtain the bounds of the index.
+func IndexByDate(ctx context.Context, l *Log, t time.Time) (int64, error) {
+	est, err := EstimateIndexByDate(ctx, l, t)
+	if err != nil {
+		return 0, errors2.Wrap(err, "get index by date")
+	}
+	return est.Index, nil
+}
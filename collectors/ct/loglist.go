@@ -0,0 +1,247 @@
+package ct
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	AllLogsUrl     = "https://www.gstatic.com/ct/log_list/v3/all_logs_list.json"
+	TrustedLogsUrl = "https://www.gstatic.com/ct/log_list/v3/log_list.json"
+)
+
+var (
+	UnknownLogListErr = errors.New("unknown log list format")
+)
+
+// state of a log, as defined in the v3 log list schema
+type LogState string
+
+const (
+	StatePending   LogState = "pending"
+	StateQualified LogState = "qualified"
+	StateUsable    LogState = "usable"
+	StateReadOnly  LogState = "readonly"
+	StateRetired   LogState = "retired"
+	StateRejected  LogState = "rejected"
+)
+
+// the period in which the certificates of a temporally sharded log must expire
+type TemporalInterval struct {
+	StartInclusive time.Time `json:"start_inclusive"`
+	EndExclusive   time.Time `json:"end_exclusive"`
+}
+
+type v3State struct {
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type v3Log struct {
+	Description      string             `json:"description"`
+	LogId            string             `json:"log_id"`
+	Key              string             `json:"key"`
+	Url              string             `json:"url"`
+	Mmd              int                `json:"mmd"`
+	State            map[string]v3State `json:"state"`
+	TemporalInterval *TemporalInterval  `json:"temporal_interval"`
+}
+
+// a log that implements the static CT API, of which the entries are served from the monitoring URL
+type v3TiledLog struct {
+	v3Log
+	SubmissionUrl string `json:"submission_url"`
+	MonitoringUrl string `json:"monitoring_url"`
+}
+
+type v3Operator struct {
+	Name      string       `json:"name"`
+	Email     []string     `json:"email"`
+	Logs      []v3Log      `json:"logs"`
+	TiledLogs []v3TiledLog `json:"tiled_logs"`
+}
+
+type v3LogList struct {
+	Version   string       `json:"version"`
+	Operators []v3Operator `json:"operators"`
+}
+
+func (vl *v3Log) log(operatorId int, operator string, api LogApi) Log {
+	log := Log{
+		Description:       vl.Description,
+		LogId:             vl.LogId,
+		Key:               vl.Key,
+		Url:               normalizeUrl(vl.Url),
+		MaximumMergeDelay: vl.Mmd,
+		OperatedBy:        []int{operatorId},
+		Operator:          operator,
+		TemporalInterval:  vl.TemporalInterval,
+		Api:               api,
+	}
+	// a log has a single state
+	for state, s := range vl.State {
+		log.State = LogState(state)
+		log.StateTimestamp = s.Timestamp
+	}
+	return log
+}
+
+// converts a log list of the v3 schema to a log list, where operators are numbered in order of appearance
+func (l *v3LogList) logList() *LogList {
+	res := LogList{
+		Logs:      []Log{},
+		Operators: []Operator{},
+	}
+	for id, op := range l.Operators {
+		res.Operators = append(res.Operators, Operator{
+			Name: op.Name,
+			Id:   id,
+		})
+		for _, vl := range op.Logs {
+			res.Logs = append(res.Logs, vl.log(id, op.Name, ApiRfc6962))
+		}
+		for _, vl := range op.TiledLogs {
+			vl.Url = vl.MonitoringUrl
+			res.Logs = append(res.Logs, vl.log(id, op.Name, ApiStatic))
+		}
+	}
+	return &res
+}
+
+// returns the url without scheme, as used by older log lists
+func normalizeUrl(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		return url[i+3:]
+	}
+	return url
+}
+
+// parses a log list in either the v3 schema, or the deprecated schema with separate lists of logs and operators
+func ParseLogList(r io.Reader) (*LogList, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		Logs      json.RawMessage `json:"logs"`
+		Operators json.RawMessage `json:"operators"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case probe.Logs != nil:
+		var logList LogList
+		if err := json.Unmarshal(raw, &logList); err != nil {
+			return nil, err
+		}
+		return &logList, nil
+	case probe.Operators != nil:
+		var logList v3LogList
+		if err := json.Unmarshal(raw, &logList); err != nil {
+			return nil, err
+		}
+		return logList.logList(), nil
+	}
+	return nil, UnknownLogListErr
+}
+
+// returns a list of logs given the JSON file located at a URL
+func logsFromUrl(url string) (*LogList, error) {
+	c := http.Client{}
+	resp, err := c.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, errors.New(fmt.Sprintf("failed to retrieve log JSON: %d", resp.StatusCode))
+	}
+	return ParseLogList(resp.Body)
+}
+
+// returns a list of logs given a URL or the path of a local file
+func LogListFrom(location string) (*LogList, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return logsFromUrl(location)
+	}
+	f, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLogList(f)
+}
+
+// returns a list of all known logs
+func AllLogs() (*LogList, error) {
+	return logsFromUrl(AllLogsUrl)
+}
+
+// returns a list of all trusted logs
+func TrustedLogs() (*LogList, error) {
+	return logsFromUrl(TrustedLogsUrl)
+}
+
+// criteria to select logs by, where empty criteria select all logs
+type FilterOpts struct {
+	States     []LogState `yaml:"states"`
+	Operators  []string   `yaml:"operators"`   // names of operators, case-insensitive
+	ShardYears []int      `yaml:"shard-years"` // years in which the temporal interval of sharded logs starts
+}
+
+func (opts FilterOpts) matches(l Log) bool {
+	if len(opts.States) > 0 {
+		ok := false
+		for _, s := range opts.States {
+			ok = ok || s == l.State
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(opts.Operators) > 0 {
+		ok := false
+		for _, op := range opts.Operators {
+			ok = ok || strings.EqualFold(op, l.Operator)
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(opts.ShardYears) > 0 {
+		if l.TemporalInterval == nil {
+			return false
+		}
+		ok := false
+		for _, y := range opts.ShardYears {
+			ok = ok || y == l.TemporalInterval.StartInclusive.Year()
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// filter the log list according to state, operator and temporal shard
+func (ll *LogList) FilterBy(opts FilterOpts) *LogList {
+	res := LogList{
+		Logs:      []Log{},
+		Operators: ll.Operators, // copy all operators
+	}
+	for _, l := range ll.Logs {
+		if opts.matches(l) {
+			res.Logs = append(res.Logs, l)
+		}
+	}
+	return &res
+}
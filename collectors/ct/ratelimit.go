@@ -0,0 +1,175 @@
+package ct
+
+import (
+	"golang.org/x/time/rate"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// number of entries requested per get-entries call, until the actual maximum of a log has been discovered
+	DefaultBatchSize = 1000
+	// number of successful requests after which the request rate of a throttled log is increased again
+	recoveryInterval = 100
+	// time without throttling after which the request rate of a throttled log is increased again, if fewer requests
+	// succeeded
+	recoveryPeriod = time.Minute
+	// factor by which the request rate of a log is reduced at most
+	maxReduction = 64
+)
+
+var (
+	DefaultRateLimit = RateLimit{
+		RequestsPerSecond: 10,
+		Burst:             10,
+	}
+)
+
+// token bucket that limits the requests to a log
+type RateLimit struct {
+	RequestsPerSecond float64 `yaml:"requests-per-second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// default rate limit for all logs, which can be overridden per log URL
+type RateLimits struct {
+	RateLimit `yaml:",inline"`
+	Logs      map[string]RateLimit `yaml:"logs"`
+}
+
+// returns the rate limit of the log with the given URL, where unset values are taken from the defaults
+func (rl RateLimits) For(url string) RateLimit {
+	res := rl.RateLimit
+	if override, ok := rl.Logs[url]; ok {
+		if override.RequestsPerSecond != 0 {
+			res.RequestsPerSecond = override.RequestsPerSecond
+		}
+		if override.Burst != 0 {
+			res.Burst = override.Burst
+		}
+	}
+	if res.RequestsPerSecond == 0 {
+		res.RequestsPerSecond = DefaultRateLimit.RequestsPerSecond
+	}
+	if res.Burst == 0 {
+		res.Burst = DefaultRateLimit.Burst
+	}
+	return res
+}
+
+// counts of the requests to a log
+type Metrics struct {
+	Requests  int64 // all requests, including failed requests
+	Errors    int64 // requests that failed for other reasons than throttling
+	Throttled int64 // requests that were answered with HTTP 429 or 503
+}
+
+// an HTTP transport that limits the rate of requests to a log. When the log throttles a request, the request rate is
+// halved and no requests are made until the time indicated by its Retry-After header has passed. Requests that were
+// sent before the rate was halved do not halve it again, and the rate is never reduced below a fraction of its
+// maximum. The rate is restored gradually when requests succeed again.
+type limitedTransport struct {
+	base      http.RoundTripper
+	limiter   *rate.Limiter
+	max       rate.Limit
+	min       rate.Limit
+	m         sync.Mutex
+	until     time.Time
+	changed   time.Time // the last time the rate was changed
+	successes int
+	metrics   Metrics
+}
+
+func newLimitedTransport(rl RateLimit) *limitedTransport {
+	if rl.RequestsPerSecond == 0 {
+		rl.RequestsPerSecond = DefaultRateLimit.RequestsPerSecond
+	}
+	if rl.Burst == 0 {
+		rl.Burst = DefaultRateLimit.Burst
+	}
+	return &limitedTransport{
+		base:    http.DefaultTransport,
+		limiter: rate.NewLimiter(rate.Limit(rl.RequestsPerSecond), rl.Burst),
+		max:     rate.Limit(rl.RequestsPerSecond),
+		min:     rate.Limit(rl.RequestsPerSecond) / maxReduction,
+	}
+}
+
+// parses the Retry-After header, which is either a number of seconds or an HTTP date. Without a valid header, retrying
+// is left to the backoff of the caller.
+func retryAfter(h string, now time.Time) time.Duration {
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	t.m.Lock()
+	wait := time.Until(t.until)
+	t.m.Unlock()
+	if wait > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	if err := t.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	sent := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.metrics.Requests++
+	switch {
+	case err != nil:
+		t.metrics.Errors++
+	case isThrottled(resp.StatusCode):
+		t.metrics.Throttled++
+		now := time.Now()
+		if until := now.Add(retryAfter(resp.Header.Get("Retry-After"), now)); until.After(t.until) {
+			t.until = until
+		}
+		// requests that were sent before the rate was last changed do not reduce it further
+		if !sent.Before(t.changed) {
+			limit := t.limiter.Limit() / 2
+			if limit < t.min {
+				limit = t.min
+			}
+			t.limiter.SetLimit(limit)
+			t.changed = now
+		}
+		t.successes = 0
+	case resp.StatusCode != http.StatusOK:
+		t.metrics.Errors++
+	default:
+		t.successes++
+		recovered := t.successes >= recoveryInterval || time.Since(t.changed) >= recoveryPeriod
+		if recovered && t.limiter.Limit() < t.max {
+			limit := t.limiter.Limit() * 2
+			if limit > t.max {
+				limit = t.max
+			}
+			t.limiter.SetLimit(limit)
+			t.changed = time.Now()
+			t.successes = 0
+		}
+	}
+	return resp, err
+}
+
+// returns whether a response of the log indicates that it throttles requests
+func isThrottled(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
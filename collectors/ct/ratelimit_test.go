@@ -0,0 +1,173 @@
+package ct
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitsFor(t *testing.T) {
+	rl := RateLimits{
+		RateLimit: RateLimit{RequestsPerSecond: 5},
+		Logs: map[string]RateLimit{
+			"ct.example.org/slow/": {RequestsPerSecond: 1},
+			"ct.example.org/fast/": {Burst: 100},
+		},
+	}
+	tests := []struct {
+		url      string
+		expected RateLimit
+	}{
+		{"ct.example.org/other/", RateLimit{RequestsPerSecond: 5, Burst: DefaultRateLimit.Burst}},
+		{"ct.example.org/slow/", RateLimit{RequestsPerSecond: 1, Burst: DefaultRateLimit.Burst}},
+		{"ct.example.org/fast/", RateLimit{RequestsPerSecond: 5, Burst: 100}},
+	}
+	for _, test := range tests {
+		if actual := rl.For(test.url); actual != test.expected {
+			t.Fatalf("expected rate limit %v for %s, but got %v", test.expected, test.url, actual)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"Fri, 01 Jan 2021 00:00:30 GMT", 30 * time.Second},
+		{"Thu, 31 Dec 2020 00:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, test := range tests {
+		if actual := retryAfter(test.header, now); actual != test.expected {
+			t.Fatalf("expected %s for header '%s', but got %s", test.expected, test.header, actual)
+		}
+	}
+}
+
+func TestThrottledRequests(t *testing.T) {
+	throttled := int64(1)
+	var throttledAt, retriedAt time.Time
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&throttled, -1) >= 0 {
+			throttledAt = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		retriedAt = time.Now()
+		w.Write([]byte(`{"entries": []}`))
+	}))
+	defer s.Close()
+
+	l := Log{
+		Url:       s.URL,
+		RateLimit: RateLimit{RequestsPerSecond: 100, Burst: 1},
+	}
+	lc, err := l.GetClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	ctx := context.Background()
+
+	if _, err := lc.GetRawEntries(ctx, 0, 0); err == nil {
+		t.Fatalf("expected error for throttled request, but got none")
+	}
+	if lc.failures != 0 {
+		t.Fatalf("expected throttled request not to count as failure, but got %d failures", lc.failures)
+	}
+	if _, err := lc.GetRawEntries(ctx, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d := retriedAt.Sub(throttledAt); d < time.Second {
+		t.Fatalf("expected request to be retried after at least %s, but got %s", time.Second, d)
+	}
+
+	metrics := lc.Metrics()
+	expected := Metrics{Requests: 2, Throttled: 1}
+	if metrics != expected {
+		t.Fatalf("expected metrics %v, but got %v", expected, metrics)
+	}
+	if limit := lc.transport.limiter.Limit(); limit != 50 {
+		t.Fatalf("expected rate to be halved to %d, but got %f", 50, limit)
+	}
+}
+
+func TestConcurrentThrottledRequests(t *testing.T) {
+	n := 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// all requests are throttled only once they have all been received
+		wg.Done()
+		wg.Wait()
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer s.Close()
+
+	lt := newLimitedTransport(RateLimit{RequestsPerSecond: 64, Burst: n})
+	c := http.Client{Transport: lt}
+
+	var clients sync.WaitGroup
+	for i := 0; i < n; i++ {
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			resp, err := c.Get(s.URL)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	clients.Wait()
+
+	if limit := lt.limiter.Limit(); limit != 32 {
+		t.Fatalf("expected rate to be halved once to %d, but got %f", 32, limit)
+	}
+
+	// throttled requests that are sent one after another reduce the rate until its minimum
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		resp, err := c.Get(s.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+	}
+	if limit := lt.limiter.Limit(); limit != 1 {
+		t.Fatalf("expected rate to be reduced to %d, but got %f", 1, limit)
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	fl := newFakeLog(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), 30)
+	defer fl.Close()
+	fl.SetMaxBatchSize(8)
+
+	l := Log{Url: fl.URL()}
+	lc, err := l.GetClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	ctx := context.Background()
+
+	// the batch size cannot be discovered from a range that exceeds the tree
+	if n := lc.BatchSize(ctx, 0, 30); n != DefaultBatchSize {
+		t.Fatalf("expected batch size %d, but got %d", DefaultBatchSize, n)
+	}
+	if _, err := lc.GetRawEntries(ctx, 0, 20); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := lc.BatchSize(ctx, 0, 30); n != 8 {
+		t.Fatalf("expected batch size %d, but got %d", 8, n)
+	}
+}
@@ -0,0 +1,502 @@
+package ct
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/trillian/merkle/rfc6962/hasher"
+	errors2 "github.com/pkg/errors"
+)
+
+const (
+	// the number of hashes or entries in a full tile
+	tileWidth = 256
+	// the number of levels of the Merkle tree in a tile
+	tileHeight = 8
+)
+
+var (
+	InvalidCheckpointErr = errors.New("invalid checkpoint")
+	InvalidTileErr       = errors.New("invalid tile")
+	EntryOutOfRangeErr   = errors.New("entry is not in the tree")
+)
+
+// retrieves the checkpoints and tiles of a log that implements the static CT API (https://c2sp.org/static-ct-api),
+// and presents them as if they were obtained by the RFC 6962 API
+type staticFetcher struct {
+	uri     string
+	logId   []byte // the SHA-256 hash of the public key of the log, or nil if the key is unknown
+	hc      *http.Client
+	m       sync.Mutex
+	size    int64 // the tree size of the last retrieved checkpoint
+	issuers map[[sha256.Size]byte][]byte
+}
+
+func newStaticFetcher(uri string, key string, hc *http.Client) (*staticFetcher, error) {
+	f := staticFetcher{
+		uri:     strings.TrimSuffix(uri, "/"),
+		hc:      hc,
+		issuers: make(map[[sha256.Size]byte][]byte),
+	}
+	if key != "" {
+		der, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, err
+		}
+		logId := sha256.Sum256(der)
+		f.logId = logId[:]
+	}
+	return &f, nil
+}
+
+func (f *staticFetcher) BaseURI() string {
+	return f.uri
+}
+
+// retrieves a resource of the log, where failures are returned as the same error type as the RFC 6962 client does
+func (f *staticFetcher) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s", f.uri, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, jsonclient.RspError{
+			Err:        fmt.Errorf("got HTTP status %q for %s", resp.Status, path),
+			StatusCode: resp.StatusCode,
+			Body:       body,
+		}
+	}
+	return body, nil
+}
+
+// returns the tree size of the last retrieved checkpoint, and retrieves a new checkpoint if it does not contain the
+// given index
+func (f *staticFetcher) treeSize(ctx context.Context, index int64) (int64, error) {
+	f.m.Lock()
+	size := f.size
+	f.m.Unlock()
+	if index < size {
+		return size, nil
+	}
+	sth, err := f.GetSTH(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if index >= int64(sth.TreeSize) {
+		return 0, EntryOutOfRangeErr
+	}
+	return int64(sth.TreeSize), nil
+}
+
+// computes the 4-byte key ID by which the log signs checkpoints for the given origin
+func (f *staticFetcher) keyId(origin string) []byte {
+	b := append([]byte(origin), '\n', 0x05)
+	h := sha256.Sum256(append(b, f.logId...))
+	return h[:4]
+}
+
+// parses a checkpoint, of which the signature is an RFC 6962 tree head signature
+func (f *staticFetcher) parseCheckpoint(raw []byte) (*ct.SignedTreeHead, error) {
+	parts := strings.SplitN(string(raw), "\n\n", 2)
+	if len(parts) != 2 {
+		return nil, InvalidCheckpointErr
+	}
+	lines := strings.Split(parts[0], "\n")
+	if len(lines) < 3 {
+		return nil, InvalidCheckpointErr
+	}
+	origin := lines[0]
+	size, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return nil, InvalidCheckpointErr
+	}
+	rootHash, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(rootHash) != sha256.Size {
+		return nil, InvalidCheckpointErr
+	}
+	sth := ct.SignedTreeHead{
+		Version:  ct.V1,
+		TreeSize: size,
+	}
+	copy(sth.SHA256RootHash[:], rootHash)
+	copy(sth.LogID[:], f.logId)
+
+	// the checkpoint may be cosigned by others, so the signature of the log is identified by its name and key ID
+	s := bufio.NewScanner(strings.NewReader(parts[1]))
+	for s.Scan() {
+		fields := strings.Fields(strings.TrimPrefix(s.Text(), "— "))
+		if len(fields) != 2 || fields[0] != origin {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(sig) < 12 {
+			continue
+		}
+		if f.logId != nil && !bytes.Equal(sig[:4], f.keyId(origin)) {
+			continue
+		}
+		sth.Timestamp = binary.BigEndian.Uint64(sig[4:12])
+		if rest, err := tls.Unmarshal(sig[12:], &sth.TreeHeadSignature); err != nil || len(rest) > 0 {
+			return nil, InvalidCheckpointErr
+		}
+		return &sth, nil
+	}
+	return nil, InvalidCheckpointErr
+}
+
+func (f *staticFetcher) GetSTH(ctx context.Context) (*ct.SignedTreeHead, error) {
+	raw, err := f.get(ctx, "checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	sth, err := f.parseCheckpoint(raw)
+	if err != nil {
+		return nil, err
+	}
+	f.m.Lock()
+	defer f.m.Unlock()
+	if int64(sth.TreeSize) > f.size {
+		f.size = int64(sth.TreeSize)
+	}
+	return sth, nil
+}
+
+// returns the path of a tile, where the index is encoded in groups of three digits and partial tiles have a width
+func tilePath(level string, index int64, width int) string {
+	s := fmt.Sprintf("%03d", index%1000)
+	for index >= 1000 {
+		index /= 1000
+		s = fmt.Sprintf("x%03d/%s", index%1000, s)
+	}
+	path := fmt.Sprintf("tile/%s/%s", level, s)
+	if width < tileWidth {
+		path = fmt.Sprintf("%s.p/%d", path, width)
+	}
+	return path
+}
+
+// returns the width of a tile, given the number of hashes or entries at the level of the tile
+func widthOf(index int64, count int64) int {
+	if count >= (index+1)*tileWidth {
+		return tileWidth
+	}
+	return int(count - index*tileWidth)
+}
+
+// retrieves a tile of the given width, where shift relates the tree size to the number of hashes or entries at the
+// level of the tile. Partial tiles may no longer be served once the log has grown, in which case a new checkpoint is
+// retrieved and the tile is retrieved at the width of its tree size. Returns the tile along with its width.
+func (f *staticFetcher) tile(ctx context.Context, level string, index int64, width int, shift uint) ([]byte, int, error) {
+	raw, err := f.get(ctx, tilePath(level, index, width))
+	if err == nil || width == tileWidth {
+		return raw, width, err
+	}
+	if rspErr, ok := err.(jsonclient.RspError); !ok || rspErr.StatusCode != http.StatusNotFound {
+		return nil, 0, err
+	}
+	sth, sthErr := f.GetSTH(ctx)
+	if sthErr != nil {
+		return nil, 0, sthErr
+	}
+	grown := widthOf(index, int64(sth.TreeSize)>>shift)
+	if grown <= width {
+		return nil, 0, err
+	}
+	raw, err = f.get(ctx, tilePath(level, index, grown))
+	return raw, grown, err
+}
+
+// retrieves the hashes of a tile of the Merkle tree, given the current tree size
+func (f *staticFetcher) tileHashes(ctx context.Context, level int, index int64, size int64) ([][]byte, error) {
+	shift := uint(level * tileHeight)
+	raw, width, err := f.tile(ctx, strconv.Itoa(level), index, widthOf(index, size>>shift), shift)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != width*sha256.Size {
+		return nil, InvalidTileErr
+	}
+	var res [][]byte
+	for i := 0; i < width; i++ {
+		res = append(res, raw[i*sha256.Size:(i+1)*sha256.Size])
+	}
+	return res, nil
+}
+
+func hashChildren(l, r []byte) []byte {
+	return hasher.DefaultHasher.HashChildren(l, r)
+}
+
+// returns the largest power of two smaller than n
+func splitPoint(n int64) int64 {
+	k := int64(1)
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// the hashes of a tree of which the tiles are retrieved once
+type tileCache struct {
+	f     *staticFetcher
+	size  int64
+	tiles map[[2]int64][][]byte
+}
+
+// returns the hash of the complete subtree with the given height and index
+func (tc *tileCache) nodeHash(ctx context.Context, height uint, index int64) ([]byte, error) {
+	level := int(height) / tileHeight
+	r := height % tileHeight
+	first := index << r
+	key := [2]int64{int64(level), first / tileWidth}
+	hashes, ok := tc.tiles[key]
+	if !ok {
+		var err error
+		if hashes, err = tc.f.tileHashes(ctx, level, key[1], tc.size); err != nil {
+			return nil, err
+		}
+		tc.tiles[key] = hashes
+	}
+	offset := int(first % tileWidth)
+	if offset+(1<<r) > len(hashes) {
+		return nil, InvalidTileErr
+	}
+	// the hashes of the levels between tiles are computed from the lower tile
+	nodes := hashes[offset : offset+(1<<r)]
+	for len(nodes) > 1 {
+		var parents [][]byte
+		for i := 0; i < len(nodes); i += 2 {
+			parents = append(parents, hashChildren(nodes[i], nodes[i+1]))
+		}
+		nodes = parents
+	}
+	return nodes[0], nil
+}
+
+// returns the Merkle tree hash of the leaves in [start, end), where start is a multiple of the largest power of two
+// smaller than the number of leaves
+func (tc *tileCache) rangeHash(ctx context.Context, start, end int64) ([]byte, error) {
+	n := end - start
+	if n&(n-1) == 0 {
+		height := uint(0)
+		for int64(1)<<height < n {
+			height++
+		}
+		return tc.nodeHash(ctx, height, start>>height)
+	}
+	k := splitPoint(n)
+	left, err := tc.rangeHash(ctx, start, start+k)
+	if err != nil {
+		return nil, err
+	}
+	right, err := tc.rangeHash(ctx, start+k, end)
+	if err != nil {
+		return nil, err
+	}
+	return hashChildren(left, right), nil
+}
+
+// computes the consistency proof between the tree of the first m leaves and the tree of the leaves in [start, end),
+// as defined in RFC 6962
+func (tc *tileCache) subproof(ctx context.Context, m, start, end int64, complete bool) ([][]byte, error) {
+	if start+m == end {
+		if complete {
+			return nil, nil
+		}
+		h, err := tc.rangeHash(ctx, start, end)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{h}, nil
+	}
+	k := splitPoint(end - start)
+	if m <= k {
+		proof, err := tc.subproof(ctx, m, start, start+k, complete)
+		if err != nil {
+			return nil, err
+		}
+		h, err := tc.rangeHash(ctx, start+k, end)
+		if err != nil {
+			return nil, err
+		}
+		return append(proof, h), nil
+	}
+	proof, err := tc.subproof(ctx, m-k, start+k, end, false)
+	if err != nil {
+		return nil, err
+	}
+	h, err := tc.rangeHash(ctx, start, start+k)
+	if err != nil {
+		return nil, err
+	}
+	return append(proof, h), nil
+}
+
+// computes the consistency proof from the hashes in the tiles of the log, as the static CT API does not serve proofs
+func (f *staticFetcher) GetSTHConsistency(ctx context.Context, first, second uint64) ([][]byte, error) {
+	if first == 0 || first >= second {
+		return nil, nil
+	}
+	size, err := f.treeSize(ctx, int64(second)-1)
+	if err != nil {
+		return nil, err
+	}
+	tc := tileCache{
+		f:     f,
+		size:  size,
+		tiles: make(map[[2]int64][][]byte),
+	}
+	return tc.subproof(ctx, int64(first), 0, int64(second), true)
+}
+
+// returns the issuer certificate with the given fingerprint
+func (f *staticFetcher) issuer(ctx context.Context, fp [sha256.Size]byte) ([]byte, error) {
+	f.m.Lock()
+	cert, ok := f.issuers[fp]
+	f.m.Unlock()
+	if ok {
+		return cert, nil
+	}
+	cert, err := f.get(ctx, fmt.Sprintf("issuer/%s", hex.EncodeToString(fp[:])))
+	if err != nil {
+		return nil, err
+	}
+	if sha256.Sum256(cert) != fp {
+		return nil, fmt.Errorf("issuer does not match fingerprint %x", fp)
+	}
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.issuers[fp] = cert
+	return cert, nil
+}
+
+// an entry of a data tile
+type tileLeaf struct {
+	entry        ct.TimestampedEntry
+	precert      ct.ASN1Cert // only present for precertificate entries
+	fingerprints [][sha256.Size]byte
+}
+
+// parses the first entry of a data tile, and returns the remainder of the tile
+func parseTileLeaf(data []byte) (*tileLeaf, []byte, error) {
+	var tl tileLeaf
+	rest, err := tls.Unmarshal(data, &tl.entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tl.entry.EntryType == ct.PrecertLogEntryType {
+		if rest, err = tls.Unmarshal(rest, &tl.precert); err != nil {
+			return nil, nil, err
+		}
+	}
+	var chain struct {
+		Data []byte `tls:"minlen:0,maxlen:65535"`
+	}
+	if rest, err = tls.Unmarshal(rest, &chain); err != nil {
+		return nil, nil, err
+	}
+	if len(chain.Data)%sha256.Size != 0 {
+		return nil, nil, InvalidTileErr
+	}
+	for i := 0; i < len(chain.Data); i += sha256.Size {
+		var fp [sha256.Size]byte
+		copy(fp[:], chain.Data[i:])
+		tl.fingerprints = append(tl.fingerprints, fp)
+	}
+	return &tl, rest, nil
+}
+
+// converts an entry of a data tile into the leaf and chain of an RFC 6962 entry
+func (f *staticFetcher) leafEntry(ctx context.Context, tl *tileLeaf) (*ct.LeafEntry, error) {
+	var chain []ct.ASN1Cert
+	for _, fp := range tl.fingerprints {
+		cert, err := f.issuer(ctx, fp)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, ct.ASN1Cert{Data: cert})
+	}
+
+	leaf := ct.MerkleTreeLeaf{
+		Version:          ct.V1,
+		LeafType:         ct.TimestampedEntryLeafType,
+		TimestampedEntry: &tl.entry,
+	}
+	leafInput, err := tls.Marshal(leaf)
+	if err != nil {
+		return nil, err
+	}
+	var extraData []byte
+	if tl.entry.EntryType == ct.PrecertLogEntryType {
+		extraData, err = tls.Marshal(ct.PrecertChainEntry{
+			PreCertificate:   tl.precert,
+			CertificateChain: chain,
+		})
+	} else {
+		extraData, err = tls.Marshal(ct.CertificateChain{
+			Entries: chain,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ct.LeafEntry{LeafInput: leafInput, ExtraData: extraData}, nil
+}
+
+// retrieves the entries in the range [start, end] that are in the same data tile as the first entry. Like logs that
+// implement RFC 6962, fewer entries than requested may be returned.
+func (f *staticFetcher) GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error) {
+	if start < 0 || end < start {
+		return nil, EntryOutOfRangeErr
+	}
+	size, err := f.treeSize(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	index := start / tileWidth
+	raw, width, err := f.tile(ctx, "data", index, widthOf(index, size), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := ct.GetEntriesResponse{}
+	for i := index * tileWidth; i <= end && i < index*tileWidth+int64(width); i++ {
+		var tl *tileLeaf
+		if tl, raw, err = parseTileLeaf(raw); err != nil {
+			return nil, errors2.Wrap(err, "parse data tile")
+		}
+		if i < start {
+			continue
+		}
+		entry, err := f.leafEntry(ctx, tl)
+		if err != nil {
+			return nil, err
+		}
+		resp.Entries = append(resp.Entries, *entry)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,195 @@
+package ct
+
+import (
+	"bytes"
+	"context"
+	"github.com/aau-network-security/gollector/testing/ctlog"
+	ct "github.com/google/certificate-transparency-go"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTilePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		index    int64
+		width    int
+		expected string
+	}{
+		{
+			name:     "full",
+			level:    "0",
+			index:    5,
+			width:    256,
+			expected: "tile/0/005",
+		},
+		{
+			name:     "partial",
+			level:    "data",
+			index:    1234067,
+			width:    7,
+			expected: "tile/data/x001/x234/067.p/7",
+		},
+		{
+			name:     "thousand",
+			level:    "1",
+			index:    1000,
+			width:    256,
+			expected: "tile/1/x001/000",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := tilePath(test.level, test.index, test.width)
+			if actual != test.expected {
+				t.Fatalf("expected path '%s', but got '%s'", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseLogListTiled(t *testing.T) {
+	logs, err := LogListFrom("fixtures/log_list_v3_tiled.json")
+	if err != nil {
+		t.Fatalf("unexpected error while parsing log list: %s", err)
+	}
+	if len(logs.Logs) != 2 {
+		t.Fatalf("expected %d logs, but got %d", 2, len(logs.Logs))
+	}
+	if l := logs.Logs[0]; l.Api != ApiRfc6962 || l.Url != "oak.ct.letsencrypt.org/2025h2/" {
+		t.Fatalf("expected RFC 6962 log at '%s', but got %s log at '%s'", "oak.ct.letsencrypt.org/2025h2/", l.Api, l.Url)
+	}
+	l := logs.Logs[1]
+	if l.Api != ApiStatic || l.Url != "mon.sycamore.ct.letsencrypt.org/2025h2/" {
+		t.Fatalf("expected static log at '%s', but got %s log at '%s'", "mon.sycamore.ct.letsencrypt.org/2025h2/", l.Api, l.Url)
+	}
+	if l.State != StateQualified || l.Operator != "Let's Encrypt" || l.MaximumMergeDelay != 60 {
+		t.Fatalf("expected qualified log of Let's Encrypt with an MMD of 60s, but got %s log of %s with an MMD of %ds", l.State, l.Operator, l.MaximumMergeDelay)
+	}
+}
+
+// starts a fake log that serves the static CT API
+func newFakeStaticLog(t *testing.T, count int) (*ctlog.Log, Log) {
+	fl, err := ctlog.NewStatic()
+	if err != nil {
+		t.Fatalf("failed to create log: %s", err)
+	}
+	addFakeEntries(t, fl, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), count)
+	l := Log{
+		Url: fl.URL(),
+		Key: fl.Key(),
+		Api: ApiStatic,
+	}
+	return fl, l
+}
+
+func TestScanStaticLog(t *testing.T) {
+	// spans two full data tiles and a partial one
+	fl, l := newFakeStaticLog(t, 600)
+	defer fl.Close()
+
+	m := sync.Mutex{}
+	observed := make(map[int64]bool)
+	precerts := 0
+	entryFn := func(entry *ct.LogEntry) error {
+		m.Lock()
+		defer m.Unlock()
+		observed[entry.Index] = true
+		if entry.Precert != nil {
+			precerts++
+		}
+		if len(entry.Chain) != 1 || !bytes.Equal(entry.Chain[0].Data, fl.Root().Raw) {
+			t.Errorf("expected entry %d to be issued by the root", entry.Index)
+		}
+		return nil
+	}
+
+	opts := Options{
+		WorkerCount: 3,
+		StartIndex:  10,
+		EndIndex:    590,
+	}
+	if _, err := Scan(context.Background(), &l, entryFn, opts); err != nil {
+		t.Fatalf("unexpected error while scanning log: %s", err)
+	}
+	if len(observed) != 580 {
+		t.Fatalf("expected %d observed entries, but got %d", 580, len(observed))
+	}
+	for i := int64(10); i < 590; i++ {
+		if !observed[i] {
+			t.Fatalf("expected entry %d to be observed", i)
+		}
+	}
+	if precerts != 290 {
+		t.Fatalf("expected %d precertificates, but got %d", 290, precerts)
+	}
+
+	lc, err := l.GetClient()
+	if err != nil {
+		t.Fatalf("unexpected error while creating client: %s", err)
+	}
+	if bs := lc.BatchSize(context.Background(), 0, 600); bs != 256 {
+		t.Fatalf("expected batch size %d, but got %d", 256, bs)
+	}
+}
+
+func TestCheckTreeHeadStaticLog(t *testing.T) {
+	fl, l := newFakeStaticLog(t, 3)
+	defer fl.Close()
+
+	lc, err := l.GetClient()
+	if err != nil {
+		t.Fatalf("unexpected error while creating client: %s", err)
+	}
+	cc := treeHeadClient{}
+	// the tree grows beyond the first tile of each level, such that proofs combine hashes of multiple levels
+	for _, n := range []int{0, 1, 260, 0, 600} {
+		addFakeEntries(t, fl, time.Now(), n)
+		sth, err := lc.GetSTH(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error while retrieving checkpoint: %s", err)
+		}
+		if int(sth.TreeSize) != fl.Size() {
+			t.Fatalf("expected tree size %d, but got %d", fl.Size(), sth.TreeSize)
+		}
+		if err := CheckTreeHead(context.Background(), &l, &cc, sth); err != nil {
+			t.Fatalf("unexpected error while checking tree head of size %d: %s", sth.TreeSize, err)
+		}
+	}
+}
+
+func TestStaticLogGrown(t *testing.T) {
+	fl, l := newFakeStaticLog(t, 10)
+	defer fl.Close()
+
+	lc, err := l.GetClient()
+	if err != nil {
+		t.Fatalf("unexpected error while creating client: %s", err)
+	}
+	ctx := context.Background()
+	if _, err := lc.GetSTH(ctx); err != nil {
+		t.Fatalf("unexpected error while retrieving checkpoint: %s", err)
+	}
+
+	// the partial tiles of the retrieved checkpoint are no longer served once the log has grown
+	addFakeEntries(t, fl, time.Now(), 300)
+
+	resp, err := lc.GetRawEntries(ctx, 5, 20)
+	if err != nil {
+		t.Fatalf("unexpected error while retrieving entries: %s", err)
+	}
+	if len(resp.Entries) != 16 {
+		t.Fatalf("expected %d entries, but got %d", 16, len(resp.Entries))
+	}
+
+	// the proof is computed from the tiles of the tree of the retrieved checkpoint
+	proof, err := lc.GetSTHConsistency(ctx, 5, 10)
+	if err != nil {
+		t.Fatalf("unexpected error while retrieving consistency proof: %s", err)
+	}
+	if len(proof) == 0 {
+		t.Fatalf("expected consistency proof, but got none")
+	}
+}
@@ -0,0 +1,108 @@
+package ct
+
+import (
+	"context"
+	prt "github.com/aau-network-security/gollector/api/proto"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+type TailOpts struct {
+	Interval    time.Duration // time between polls of the STH, defaults to one minute
+	MaxBackoff  time.Duration // maximum time to wait after a log returned errors, defaults to one hour
+	WorkerCount int
+	TreeHeads   prt.CtApiClient // when set, tree heads are verified and stored before their entries are retrieved
+	SkipFn      SkipFunc        // called for entries that cannot be parsed, if set
+}
+
+func (opts *TailOpts) interval() time.Duration {
+	if opts.Interval == 0 {
+		return time.Minute
+	}
+	return opts.Interval
+}
+
+func (opts *TailOpts) maxBackoff() time.Duration {
+	if opts.MaxBackoff == 0 {
+		return time.Hour
+	}
+	return opts.MaxBackoff
+}
+
+// returns the time to wait after a failure, which doubles with every consecutive failure
+func (opts *TailOpts) backoff(failures int) time.Duration {
+	d := opts.interval()
+	for i := 1; i < failures && d < opts.maxBackoff(); i++ {
+		d *= 2
+	}
+	if d > opts.maxBackoff() {
+		d = opts.maxBackoff()
+	}
+	return d
+}
+
+// continuously passes the entries that are appended to the log to entryFn, starting at the given index. The STH of the
+// log is polled at the configured interval, and the log is backed off when it returns errors. A range of new entries
+// that fails to be scanned is retried in its entirety. Returns when ctx is cancelled, or with a MisbehaviourErr when the
+// log presents a tree head that cannot be trusted.
+func Tail(ctx context.Context, l *Log, start int64, entryFn EntryFunc, opts TailOpts) error {
+	lc, err := l.GetClient()
+	if err != nil {
+		return err
+	}
+
+	next := start
+	failures := 0
+	var checked *ct.SignedTreeHead
+	for {
+		wait := opts.interval()
+
+		sth, err := lc.GetSTH(ctx)
+		if err == nil && opts.TreeHeads != nil && (checked == nil || !sameTreeHead(checked, sth)) {
+			// a tree head that cannot be verified for other reasons does not prevent retrieving entries
+			switch err := CheckTreeHead(ctx, l, opts.TreeHeads, sth); err.(type) {
+			case nil:
+				checked = sth
+			case MisbehaviourErr:
+				return err
+			default:
+				log.Warn().Str("log", l.Name()).Msgf("failed to verify tree head: %s", err)
+			}
+		}
+		if err == nil {
+			size := int64(sth.TreeSize)
+			if size > next {
+				scanOpts := Options{
+					StartIndex:  next,
+					EndIndex:    size,
+					WorkerCount: opts.WorkerCount,
+					SkipFn:      opts.SkipFn,
+				}
+				var count int64
+				count, err = Scan(ctx, l, entryFn, scanOpts)
+				if err == nil {
+					log.Debug().Str("log", l.Name()).Msgf("retrieved %d new log entries", count)
+					next = size
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			failures++
+			wait = opts.backoff(failures)
+			log.Warn().Str("log", l.Name()).Msgf("failed to tail log (%d consecutive failures), retrying in %s: %s", failures, wait, err)
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package ct
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	ct "github.com/google/certificate-transparency-go"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serves the entries in fixtures/entry_0_4.json, of which only the first treeSize entries are part of the tree
+func growingLogHandler(t *testing.T, treeSize *int64, failures *int64) http.Handler {
+	raw, err := ioutil.ReadFile("fixtures/entry_0_4.json")
+	if err != nil {
+		t.Fatalf("error while reading file: %s", err)
+	}
+	var entries ct.GetEntriesResponse
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		t.Fatalf("error while parsing entries: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ct/v1/get-sth", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"tree_size": %d, "timestamp": %d, "sha256_root_hash": "q0zGRq/kcxlfxN7iOZyS0GuQDlHt4tQNsABjl9h+vvQ=", "tree_head_signature": "BAMASDBGAiEAiXDoDqORNcAHp7u+ZA0xiDVenLsoB1Cm/Ph+Mb4ybaMCIQDagHnXDR6sQqDRflws85Pwk8t80uvgf5Y3WuCzNsd5cA=="}`, atomic.LoadInt64(treeSize), time.Now().UnixNano()/1e6)
+	})
+	mux.HandleFunc("/ct/v1/get-entries", func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		end, _ := strconv.Atoi(r.URL.Query().Get("end"))
+		if size := int(atomic.LoadInt64(treeSize)); end >= size {
+			end = size - 1
+		}
+		resp := ct.GetEntriesResponse{
+			Entries: entries.Entries[start : end+1],
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatalf("error while writing HTTP response: %s", err)
+		}
+	})
+	return mux
+}
+
+func TestTail(t *testing.T) {
+	treeSize := int64(2)
+	failures := int64(2)
+	s := httptest.NewServer(growingLogHandler(t, &treeSize, &failures))
+	defer s.Close()
+
+	m := sync.Mutex{}
+	observed := make(map[int64]int)
+	entryFn := func(entry *ct.LogEntry) error {
+		m.Lock()
+		defer m.Unlock()
+		observed[entry.Index]++
+		return nil
+	}
+	count := func() int {
+		m.Lock()
+		defer m.Unlock()
+		return len(observed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		opts := TailOpts{
+			Interval:    10 * time.Millisecond,
+			MaxBackoff:  20 * time.Millisecond,
+			WorkerCount: 1,
+		}
+		errc <- Tail(ctx, &Log{Url: s.URL}, 0, entryFn, opts)
+	}()
+
+	waitFor := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for count() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d observed entries, but got %d", n, count())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	// the first polls fail, after which the initial entries are retrieved
+	waitFor(2)
+	atomic.StoreInt64(&treeSize, 5)
+	waitFor(5)
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("unexpected error while tailing log: %s", err)
+	}
+	for idx, n := range observed {
+		if n != 1 {
+			t.Fatalf("expected entry %d to be observed once, but got %d", idx, n)
+		}
+	}
+}
+
+func TestTailBackoff(t *testing.T) {
+	opts := TailOpts{
+		Interval:   time.Second,
+		MaxBackoff: 5 * time.Second,
+	}
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+	for _, test := range tests {
+		if actual := opts.backoff(test.failures); actual != test.expected {
+			t.Fatalf("expected backoff of %s after %d failures, but got %s", test.expected, test.failures, actual)
+		}
+	}
+}
+
+func TestTailSkipped(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	fl := newFakeLog(t, start, 2)
+	defer fl.Close()
+	if err := fl.AddMalformedCert(start.Add(2 * time.Minute)); err != nil {
+		t.Fatalf("failed to add entry to log: %s", err)
+	}
+	addFakeEntries(t, fl, start.Add(3*time.Minute), 2)
+
+	m := sync.Mutex{}
+	scanned := make(map[int64]int)
+	var skipped []int64
+	entryFn := func(entry *ct.LogEntry) error {
+		m.Lock()
+		defer m.Unlock()
+		scanned[entry.Index]++
+		return nil
+	}
+	skipFn := func(index int64) error {
+		m.Lock()
+		defer m.Unlock()
+		scanned[index]++
+		skipped = append(skipped, index)
+		return nil
+	}
+	count := func() int {
+		m.Lock()
+		defer m.Unlock()
+		return len(scanned)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		opts := TailOpts{
+			Interval:    10 * time.Millisecond,
+			MaxBackoff:  20 * time.Millisecond,
+			WorkerCount: 1,
+			SkipFn:      skipFn,
+		}
+		errc <- Tail(ctx, &Log{Url: fl.URL()}, 0, entryFn, opts)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for count() < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d scanned entries, but got %d", 5, count())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("unexpected error while tailing log: %s", err)
+	}
+	// the scanned entries form a range without gaps, including the entry that cannot be parsed
+	for idx := int64(0); idx < 5; idx++ {
+		if scanned[idx] != 1 {
+			t.Fatalf("expected entry %d to be scanned once, but got %d", idx, scanned[idx])
+		}
+	}
+	if len(skipped) != 1 || skipped[0] != 2 {
+		t.Fatalf("expected entry %d to be skipped, but got %v", 2, skipped)
+	}
+}
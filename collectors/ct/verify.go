@@ -0,0 +1,146 @@
+package ct
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	prt "github.com/aau-network-security/gollector/api/proto"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/trillian/merkle/logverifier"
+	"github.com/google/trillian/merkle/rfc6962/hasher"
+	errors2 "github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// indicates that a log presented a tree head that is not signed by its key, or that contradicts an earlier tree head
+type MisbehaviourErr struct {
+	Reason string
+}
+
+func (err MisbehaviourErr) Error() string {
+	return fmt.Sprintf("log misbehaviour: %s", err.Reason)
+}
+
+// verifies the signature of the tree head against the key of the log. Logs without a known key are not verified.
+func (l *Log) VerifySTH(sth *ct.SignedTreeHead) error {
+	if l.Key == "" {
+		return nil
+	}
+	pk, err := ct.PublicKeyFromB64(l.Key)
+	if err != nil {
+		return errors2.Wrap(err, "parse log key")
+	}
+	sv, err := ct.NewSignatureVerifier(pk)
+	if err != nil {
+		return errors2.Wrap(err, "create signature verifier")
+	}
+	if err := sv.VerifySTHSignature(*sth); err != nil {
+		return MisbehaviourErr{Reason: fmt.Sprintf("invalid signature of tree head of size %d: %s", sth.TreeSize, err)}
+	}
+	return nil
+}
+
+// verifies that the tree of one tree head is an append-only extension of the tree of the other tree head, using a
+// consistency proof obtained from the log. The tree heads may be given in any order.
+func VerifyConsistency(ctx context.Context, c *Client, first, second *ct.SignedTreeHead) error {
+	if first.TreeSize > second.TreeSize {
+		first, second = second, first
+	}
+	var proof [][]byte
+	if first.TreeSize > 0 && first.TreeSize < second.TreeSize {
+		var err error
+		proof, err = c.GetSTHConsistency(ctx, first.TreeSize, second.TreeSize)
+		if err != nil {
+			return errors2.Wrap(err, "get consistency proof")
+		}
+	}
+	v := logverifier.New(hasher.DefaultHasher)
+	if err := v.VerifyConsistencyProof(int64(first.TreeSize), int64(second.TreeSize), first.SHA256RootHash[:], second.SHA256RootHash[:], proof); err != nil {
+		return MisbehaviourErr{Reason: fmt.Sprintf("tree of size %d is inconsistent with tree of size %d: %s", first.TreeSize, second.TreeSize, err)}
+	}
+	return nil
+}
+
+// converts a tree head that is stored in the database
+func sthFromProto(th *prt.TreeHead) (*ct.SignedTreeHead, error) {
+	sth := ct.SignedTreeHead{
+		TreeSize:  uint64(th.TreeSize),
+		Timestamp: uint64(th.Timestamp),
+	}
+	if len(th.RootHash) != len(sth.SHA256RootHash) {
+		return nil, fmt.Errorf("invalid root hash length: %d", len(th.RootHash))
+	}
+	copy(sth.SHA256RootHash[:], th.RootHash)
+	return &sth, nil
+}
+
+// verifies the signature of the tree head, and its consistency with the last tree head of the log that is stored in the
+// database. The tree head is stored afterwards, such that a log that misbehaves is flagged. Returns a MisbehaviourErr
+// if the tree head cannot be trusted.
+func CheckTreeHead(ctx context.Context, l *Log, cc prt.CtApiClient, sth *ct.SignedTreeHead) error {
+	lc, err := l.GetClient()
+	if err != nil {
+		return errors2.Wrap(err, "get log client")
+	}
+
+	prev, err := cc.GetLastTreeHead(ctx, &prt.KnownLogURL{
+		LogURL: l.Url,
+	})
+	if err != nil {
+		return errors2.Wrap(err, "get last tree head")
+	}
+
+	verifyErr := l.VerifySTH(sth)
+	// an empty root hash indicates that no tree head has been stored before
+	if verifyErr == nil && len(prev.RootHash) > 0 {
+		prevSth, err := sthFromProto(prev)
+		if err != nil {
+			return errors2.Wrap(err, "parse last tree head")
+		}
+		verifyErr = VerifyConsistency(ctx, lc, prevSth, sth)
+	}
+
+	var misbehaviour string
+	switch verifyErr.(type) {
+	case nil:
+	case MisbehaviourErr:
+		misbehaviour = verifyErr.Error()
+		log.Error().Str("log", l.Name()).Msgf("%s", misbehaviour)
+	default:
+		return verifyErr
+	}
+
+	sig, err := tls.Marshal(sth.TreeHeadSignature)
+	if err != nil {
+		return errors2.Wrap(err, "marshal tree head signature")
+	}
+	th := prt.TreeHead{
+		Log: &prt.Log{
+			Description:       l.Description,
+			Key:               l.Key,
+			Url:               l.Url,
+			MaximumMergeDelay: int64(l.MaximumMergeDelay),
+			DnsApiEndpoint:    l.DnsApiEndpoint,
+		},
+		TreeSize:     int64(sth.TreeSize),
+		Timestamp:    int64(sth.Timestamp),
+		RootHash:     sth.SHA256RootHash[:],
+		Signature:    sig,
+		Consistent:   misbehaviour == "",
+		Misbehaviour: misbehaviour,
+	}
+	res, err := cc.StoreTreeHead(ctx, &th)
+	if err != nil {
+		return errors2.Wrap(err, "store tree head")
+	}
+	if !res.Ok {
+		return fmt.Errorf("store tree head: %s", res.Error)
+	}
+	return verifyErr
+}
+
+// returns whether the given tree heads are identical
+func sameTreeHead(a, b *ct.SignedTreeHead) bool {
+	return a.TreeSize == b.TreeSize && bytes.Equal(a.SHA256RootHash[:], b.SHA256RootHash[:])
+}
@@ -0,0 +1,257 @@
+package ct
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	prt "github.com/aau-network-security/gollector/api/proto"
+	"github.com/aau-network-security/gollector/testing/ctlog"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"google.golang.org/grpc"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type testLog struct {
+	key        *ecdsa.PrivateKey
+	leafHashes [][]byte
+}
+
+func newTestLog(t *testing.T, size int, prefix string) *testLog {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	tl := testLog{
+		key: key,
+	}
+	for i := 0; i < size; i++ {
+		h := sha256.Sum256(append([]byte{0}, fmt.Sprintf("%s-%d", prefix, i)...))
+		tl.leafHashes = append(tl.leafHashes, h[:])
+	}
+	return &tl
+}
+
+// returns the base64-encoded public key of the log, as found in log lists
+func (tl *testLog) publicKey(t *testing.T) string {
+	der, err := x509.MarshalPKIXPublicKey(&tl.key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %s", err)
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+// returns a signed tree head for the first size leaves of the log
+func (tl *testLog) sth(t *testing.T, size int) *ct.SignedTreeHead {
+	sth := ct.SignedTreeHead{
+		Version:   ct.V1,
+		TreeSize:  uint64(size),
+		Timestamp: uint64(1000 + size),
+	}
+	copy(sth.SHA256RootHash[:], ctlog.TreeHash(tl.leafHashes[:size]))
+	input, err := ct.SerializeSTHSignatureInput(sth)
+	if err != nil {
+		t.Fatalf("failed to serialize tree head: %s", err)
+	}
+	sig, err := tls.CreateSignature(*tl.key, tls.SHA256, input)
+	if err != nil {
+		t.Fatalf("failed to sign tree head: %s", err)
+	}
+	sth.TreeHeadSignature = ct.DigitallySigned(sig)
+	return &sth
+}
+
+func (tl *testLog) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ct.GetSTHConsistencyPath, func(w http.ResponseWriter, r *http.Request) {
+		first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+		second, _ := strconv.Atoi(r.URL.Query().Get("second"))
+		resp := ct.GetSTHConsistencyResponse{
+			Consistency: ctlog.ConsistencyProof(first, tl.leafHashes[:second]),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatalf("error while writing HTTP response: %s", err)
+		}
+	})
+	return mux
+}
+
+func TestVerifySTH(t *testing.T) {
+	tl := newTestLog(t, 5, "a")
+	tampered := tl.sth(t, 5)
+	tampered.TreeSize = 4
+
+	tests := []struct {
+		name        string
+		key         string
+		sth         *ct.SignedTreeHead
+		misbehaving bool
+	}{
+		{
+			name: "valid",
+			key:  tl.publicKey(t),
+			sth:  tl.sth(t, 5),
+		},
+		{
+			name:        "tampered",
+			key:         tl.publicKey(t),
+			sth:         tampered,
+			misbehaving: true,
+		},
+		{
+			name:        "other key",
+			key:         newTestLog(t, 0, "b").publicKey(t),
+			sth:         tl.sth(t, 5),
+			misbehaving: true,
+		},
+		{
+			name: "unknown key",
+			sth:  tampered,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := Log{Key: test.key}
+			err := l.VerifySTH(test.sth)
+			if _, ok := err.(MisbehaviourErr); ok != test.misbehaving {
+				t.Fatalf("expected misbehaviour to be %t, but got error %v", test.misbehaving, err)
+			}
+		})
+	}
+}
+
+func TestVerifyConsistency(t *testing.T) {
+	tl := newTestLog(t, 13, "a")
+	s := httptest.NewServer(tl.handler(t))
+	defer s.Close()
+
+	// a log that presents a different tree to other clients
+	fork := newTestLog(t, 13, "b")
+
+	l := Log{Url: s.URL}
+	c, err := l.GetClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	tests := []struct {
+		name        string
+		first       *ct.SignedTreeHead
+		second      *ct.SignedTreeHead
+		misbehaving bool
+	}{
+		{
+			name:   "consistent",
+			first:  tl.sth(t, 3),
+			second: tl.sth(t, 13),
+		},
+		{
+			name:   "power of two",
+			first:  tl.sth(t, 8),
+			second: tl.sth(t, 11),
+		},
+		{
+			name:   "reversed order",
+			first:  tl.sth(t, 13),
+			second: tl.sth(t, 6),
+		},
+		{
+			name:   "empty tree",
+			first:  tl.sth(t, 0),
+			second: tl.sth(t, 13),
+		},
+		{
+			name:   "same tree",
+			first:  tl.sth(t, 7),
+			second: tl.sth(t, 7),
+		},
+		{
+			name:        "forked tree",
+			first:       fork.sth(t, 3),
+			second:      tl.sth(t, 13),
+			misbehaving: true,
+		},
+		{
+			name:        "forked tree of same size",
+			first:       fork.sth(t, 7),
+			second:      tl.sth(t, 7),
+			misbehaving: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyConsistency(context.Background(), c, test.first, test.second)
+			if _, ok := err.(MisbehaviourErr); ok != test.misbehaving {
+				t.Fatalf("expected misbehaviour to be %t, but got error %v", test.misbehaving, err)
+			}
+			if !test.misbehaving && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+// stores tree heads in memory
+type treeHeadClient struct {
+	prt.CtApiClient
+	heads []*prt.TreeHead
+}
+
+func (c *treeHeadClient) StoreTreeHead(ctx context.Context, th *prt.TreeHead, opts ...grpc.CallOption) (*prt.Result, error) {
+	c.heads = append(c.heads, th)
+	return &prt.Result{Ok: true}, nil
+}
+
+func (c *treeHeadClient) GetLastTreeHead(ctx context.Context, url *prt.KnownLogURL, opts ...grpc.CallOption) (*prt.TreeHead, error) {
+	for i := len(c.heads) - 1; i >= 0; i-- {
+		if c.heads[i].Misbehaviour == "" {
+			return c.heads[i], nil
+		}
+	}
+	return &prt.TreeHead{}, nil
+}
+
+func TestCheckTreeHead(t *testing.T) {
+	tl := newTestLog(t, 13, "a")
+	s := httptest.NewServer(tl.handler(t))
+	defer s.Close()
+	fork := newTestLog(t, 13, "b")
+	fork.key = tl.key
+
+	l := Log{
+		Url: s.URL,
+		Key: tl.publicKey(t),
+	}
+	cc := treeHeadClient{}
+
+	steps := []struct {
+		sth         *ct.SignedTreeHead
+		misbehaving bool
+	}{
+		{sth: tl.sth(t, 4)},
+		{sth: tl.sth(t, 9)},
+		{sth: fork.sth(t, 11), misbehaving: true},
+		{sth: tl.sth(t, 13)},
+	}
+	for i, step := range steps {
+		err := CheckTreeHead(context.Background(), &l, &cc, step.sth)
+		if _, ok := err.(MisbehaviourErr); ok != step.misbehaving {
+			t.Fatalf("expected misbehaviour to be %t at step %d, but got error %v", step.misbehaving, i, err)
+		}
+		if len(cc.heads) != i+1 {
+			t.Fatalf("expected %d stored tree heads, but got %d", i+1, len(cc.heads))
+		}
+		if stored := cc.heads[i]; stored.Consistent == step.misbehaving || stored.TreeSize != int64(step.sth.TreeSize) {
+			t.Fatalf("unexpected tree head stored at step %d: %v", i, stored)
+		}
+	}
+}
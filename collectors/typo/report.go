@@ -0,0 +1,59 @@
+package typo
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"time"
+)
+
+// a permutation that has been observed, with the time at which each source observed it first
+type Lookalike struct {
+	Permutation
+	FirstSeen map[string]time.Time
+}
+
+// writes the lookalikes as CSV, with a row for each source that observed a lookalike
+func WriteReport(w io.Writer, lookalikes []Lookalike) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"apex", "protected", "kind", "source", "first_seen"}); err != nil {
+		return err
+	}
+	for _, l := range lookalikes {
+		var sources []string
+		for source := range l.FirstSeen {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		for _, source := range sources {
+			row := []string{
+				l.Apex,
+				l.Protected,
+				string(l.Kind),
+				source,
+				l.FirstSeen[source].UTC().Format(time.RFC3339),
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// returns the permutations that have been observed, given the time at which each source observed apexes first
+func Observed(perms []Permutation, firstSeen map[string]map[string]time.Time) []Lookalike {
+	var res []Lookalike
+	for _, p := range perms {
+		fs, ok := firstSeen[p.Apex]
+		if !ok {
+			continue
+		}
+		res = append(res, Lookalike{
+			Permutation: p,
+			FirstSeen:   fs,
+		})
+	}
+	return res
+}
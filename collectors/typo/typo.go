@@ -0,0 +1,166 @@
+package typo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aau-network-security/gollector/watchlist"
+	"golang.org/x/net/idna"
+)
+
+var (
+	InvalidApexErr = errors.New("apex must consist of a label and a public suffix")
+)
+
+type Kind string
+
+const (
+	KindOmission      Kind = "omission"      // a character is left out
+	KindTransposition Kind = "transposition" // two adjacent characters are swapped
+	KindBitsquatting  Kind = "bitsquatting"  // a single bit of a character is flipped
+	KindHomoglyph     Kind = "homoglyph"     // a character is replaced by one that looks alike
+	KindTldSwap       Kind = "tld-swap"      // the public suffix is replaced
+)
+
+// characters that look alike the key, either in ASCII or in Unicode, in which case they are encoded with IDNA
+var homoglyphs = map[rune][]string{
+	'a': {"4", "а", "ɑ", "à", "á", "â", "ä", "å"},
+	'b': {"8", "в", "ß"},
+	'c': {"с", "ç"},
+	'd': {"cl", "ԁ"},
+	'e': {"3", "е", "é", "è", "ê", "ë"},
+	'g': {"9", "ɡ"},
+	'h': {"н"},
+	'i': {"1", "l", "і", "í", "ì", "ï", "ı"},
+	'j': {"ј"},
+	'k': {"к"},
+	'l': {"1", "i", "ӏ", "ɩ"},
+	'm': {"rn", "м"},
+	'n': {"ո", "ñ"},
+	'o': {"0", "о", "ο", "ö", "ó", "ò"},
+	'p': {"р", "ρ"},
+	'q': {"ԛ"},
+	's': {"5", "ѕ"},
+	't': {"7", "т"},
+	'u': {"υ", "ü", "ú", "ù"},
+	'v': {"ѵ", "ν"},
+	'w': {"vv", "ѡ"},
+	'x': {"х", "χ"},
+	'y': {"у", "ý"},
+	'z': {"ᴢ"},
+}
+
+// a domain name that may be mistaken for a protected apex
+type Permutation struct {
+	Apex      string // IDNA encoded
+	Protected string
+	Kind      Kind
+}
+
+// generates the permutations of an apex, of which the public suffix is swapped with each of the given suffixes.
+// Permutations are unique and valid domain names, and do not include the apex itself.
+func Generate(apex string, suffixes []string) ([]Permutation, error) {
+	apex = strings.ToLower(strings.TrimSuffix(apex, "."))
+	splitted := strings.SplitN(apex, ".", 2)
+	if len(splitted) != 2 || splitted[0] == "" || splitted[1] == "" {
+		return nil, InvalidApexErr
+	}
+	label, suffix := splitted[0], splitted[1]
+
+	var res []Permutation
+	seen := map[string]bool{
+		apex: true,
+	}
+	add := func(l, s string, kind Kind) {
+		d, err := idna.Lookup.ToASCII(fmt.Sprintf("%s.%s", l, s))
+		if err != nil || seen[d] {
+			return
+		}
+		seen[d] = true
+		res = append(res, Permutation{
+			Apex:      d,
+			Protected: apex,
+			Kind:      kind,
+		})
+	}
+
+	for _, l := range omissions(label) {
+		add(l, suffix, KindOmission)
+	}
+	for _, l := range transpositions(label) {
+		add(l, suffix, KindTransposition)
+	}
+	for _, l := range bitsquats(label) {
+		add(l, suffix, KindBitsquatting)
+	}
+	for _, l := range homoglyphSubstitutions(label) {
+		add(l, suffix, KindHomoglyph)
+	}
+	for _, s := range suffixes {
+		add(label, strings.ToLower(strings.Trim(s, ".")), KindTldSwap)
+	}
+	return res, nil
+}
+
+func omissions(label string) []string {
+	var res []string
+	for i := range label {
+		res = append(res, label[:i]+label[i+1:])
+	}
+	return res
+}
+
+func transpositions(label string) []string {
+	var res []string
+	for i := 0; i < len(label)-1; i++ {
+		if label[i] == label[i+1] {
+			continue
+		}
+		b := []byte(label)
+		b[i], b[i+1] = b[i+1], b[i]
+		res = append(res, string(b))
+	}
+	return res
+}
+
+func isHostnameChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-'
+}
+
+func bitsquats(label string) []string {
+	var res []string
+	for i := 0; i < len(label); i++ {
+		for bit := uint(0); bit < 8; bit++ {
+			c := label[i] ^ (1 << bit)
+			if !isHostnameChar(c) {
+				continue
+			}
+			res = append(res, label[:i]+string(c)+label[i+1:])
+		}
+	}
+	return res
+}
+
+func homoglyphSubstitutions(label string) []string {
+	var res []string
+	for i, r := range label {
+		for _, h := range homoglyphs[r] {
+			res = append(res, label[:i]+h+label[i+len(string(r)):])
+		}
+	}
+	return res
+}
+
+// returns rules that match the permutations exactly, such that newly observed permutations result in watchlist hits
+func Rules(perms []Permutation) []watchlist.Rule {
+	var res []watchlist.Rule
+	for _, p := range perms {
+		res = append(res, watchlist.Rule{
+			Name:  fmt.Sprintf("typo:%s:%s", p.Protected, p.Kind),
+			Type:  watchlist.TypeExact,
+			Value: p.Apex,
+		})
+	}
+	return res
+}
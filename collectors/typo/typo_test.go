@@ -0,0 +1,119 @@
+package typo
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+func TestGenerate(t *testing.T) {
+	perms, err := Generate("Example.com.", []string{"com", "net", ".org"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	byApex := make(map[string]Permutation)
+	for _, p := range perms {
+		if _, ok := byApex[p.Apex]; ok {
+			t.Fatalf("expected permutations to be unique, but got '%s' twice", p.Apex)
+		}
+		if p.Protected != "example.com" {
+			t.Fatalf("expected protected apex '%s', but got '%s'", "example.com", p.Protected)
+		}
+		if _, err := idna.Lookup.ToASCII(p.Apex); err != nil {
+			t.Fatalf("expected permutation '%s' to be valid, but got error: %s", p.Apex, err)
+		}
+		byApex[p.Apex] = p
+	}
+
+	cyrillic, err := idna.Lookup.ToASCII("еxample.com") // with a cyrillic 'е'
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		apex string
+		kind Kind
+	}{
+		{"xample.com", KindOmission},
+		{"exmple.com", KindOmission},
+		{"xeample.com", KindTransposition},
+		{"exmaple.com", KindTransposition},
+		{"dxample.com", KindBitsquatting},
+		{"exampme.com", KindBitsquatting},
+		{"examp1e.com", KindHomoglyph},
+		{"exarnple.com", KindHomoglyph},
+		{cyrillic, KindHomoglyph},
+		{"example.net", KindTldSwap},
+		{"example.org", KindTldSwap},
+	}
+	for _, test := range tests {
+		p, ok := byApex[test.apex]
+		if !ok {
+			t.Fatalf("expected permutation '%s', but got none", test.apex)
+		}
+		if p.Kind != test.kind {
+			t.Fatalf("expected '%s' to be of kind %s, but got %s", test.apex, test.kind, p.Kind)
+		}
+	}
+
+	for _, apex := range []string{"example.com", "exam_ple.com", "-xample.com"} {
+		if _, ok := byApex[apex]; ok {
+			t.Fatalf("expected '%s' not to be a permutation", apex)
+		}
+	}
+}
+
+func TestGenerateInvalidApex(t *testing.T) {
+	for _, apex := range []string{"", "com", ".com"} {
+		if _, err := Generate(apex, nil); err != InvalidApexErr {
+			t.Fatalf("expected error for apex '%s', but got %v", apex, err)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	perms := []Permutation{
+		{Apex: "exmple.com", Protected: "example.com", Kind: KindOmission},
+	}
+	rules := Rules(perms)
+	if len(rules) != 1 {
+		t.Fatalf("expected %d rule, but got %d", 1, len(rules))
+	}
+	if rules[0].Name != "typo:example.com:omission" || rules[0].Value != "exmple.com" {
+		t.Fatalf("unexpected rule: %v", rules[0])
+	}
+}
+
+func TestReport(t *testing.T) {
+	perms := []Permutation{
+		{Apex: "exmple.com", Protected: "example.com", Kind: KindOmission},
+		{Apex: "example.net", Protected: "example.com", Kind: KindTldSwap},
+	}
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	firstSeen := map[string]map[string]time.Time{
+		"exmple.com": {
+			"zone": ts,
+			"ct":   ts.Add(time.Hour),
+		},
+		"unrelated.com": {
+			"zone": ts,
+		},
+	}
+	lookalikes := Observed(perms, firstSeen)
+	if len(lookalikes) != 1 {
+		t.Fatalf("expected %d lookalike, but got %d", 1, len(lookalikes))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, lookalikes); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "apex,protected,kind,source,first_seen\n" +
+		"exmple.com,example.com,omission,ct,2020-01-02T04:04:05Z\n" +
+		"exmple.com,example.com,omission,zone,2020-01-02T03:04:05Z\n"
+	if buf.String() != expected {
+		t.Fatalf("expected report:\n%s\nbut got:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,215 @@
+package axfr
+
+import (
+	"errors"
+	"fmt"
+	zone2 "github.com/aau-network-security/gollector/collectors/zone"
+	"github.com/miekg/dns"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	IxfrUnavailableErr = errors.New("server responded to IXFR with a full zone transfer")
+)
+
+type Tsig struct {
+	Name      string `yaml:"name"`
+	Algorithm string `yaml:"algorithm"` // defaults to hmac-sha256
+	Secret    string // base64 encoded
+}
+
+func (t *Tsig) enabled() bool {
+	return t.Name != ""
+}
+
+func (t *Tsig) algorithm() string {
+	if t.Algorithm == "" {
+		return dns.HmacSHA256
+	}
+	return dns.Fqdn(t.Algorithm)
+}
+
+type Config struct {
+	Tld    string `yaml:"tld"`
+	Server string `yaml:"server"` // address of authoritative name server, e.g. 'zonedata.iis.se:53'
+	Tsig   Tsig   `yaml:"tsig"`
+}
+
+// changes to a zone between two SOA serials, as obtained by an IXFR
+type Changes struct {
+	From, To uint32
+	Added    []dns.RR
+	Deleted  []dns.RR
+}
+
+type axfrZone struct {
+	conf Config
+}
+
+func (z *axfrZone) Tld() string {
+	return z.conf.Tld
+}
+
+// performs a zone transfer of the given type, and returns the records in the order in which they are received
+func (z *axfrZone) transfer(m *dns.Msg) (chan *dns.Envelope, error) {
+	t := dns.Transfer{
+		ReadTimeout: 30 * time.Second,
+	}
+	if z.conf.Tsig.enabled() {
+		name := dns.Fqdn(strings.ToLower(z.conf.Tsig.Name))
+		t.TsigSecret = map[string]string{
+			name: z.conf.Tsig.Secret,
+		}
+		m.SetTsig(name, z.conf.Tsig.algorithm(), 300, time.Now().Unix())
+	}
+	return t.In(m, z.conf.Server)
+}
+
+// returns the zone as obtained by an AXFR in the RFC 1035 format
+func (z *axfrZone) Stream() (io.ReadCloser, error) {
+	m := dns.Msg{}
+	m.SetAxfr(dns.Fqdn(z.conf.Tld))
+
+	envs, err := z.transfer(&m)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		first := true
+		for env := range envs {
+			if env.Error != nil {
+				pw.CloseWithError(env.Error)
+				// drain channel such that the transfer can terminate
+				for range envs {
+				}
+				return
+			}
+			for _, rr := range env.RR {
+				// an AXFR ends with a repetition of the SOA record, which is omitted
+				if _, ok := rr.(*dns.SOA); ok && !first {
+					continue
+				}
+				first = false
+				if _, err := fmt.Fprintln(pw, rr.String()); err != nil {
+					for range envs {
+					}
+					return
+				}
+			}
+		}
+		pw.Close()
+	}()
+
+	return pr, nil
+}
+
+// returns the changes to the zone since the given SOA serial
+func (z *axfrZone) Changes(serial uint32) (*Changes, error) {
+	origin := dns.Fqdn(z.conf.Tld)
+	m := dns.Msg{}
+	m.SetIxfr(origin, serial, origin, origin)
+
+	envs, err := z.transfer(&m)
+	if err != nil {
+		return nil, err
+	}
+
+	var rrs []dns.RR
+	for env := range envs {
+		if env.Error != nil {
+			return nil, env.Error
+		}
+		rrs = append(rrs, env.RR...)
+	}
+	if len(rrs) == 0 {
+		return nil, dns.ErrSoa
+	}
+
+	current, ok := rrs[0].(*dns.SOA)
+	if !ok {
+		return nil, dns.ErrSoa
+	}
+	c := Changes{
+		From: serial,
+		To:   current.Serial,
+	}
+	// zone did not change
+	if len(rrs) == 1 {
+		return &c, nil
+	}
+	if _, ok := rrs[1].(*dns.SOA); !ok {
+		return nil, IxfrUnavailableErr
+	}
+
+	// the remainder is a sequence of (old SOA, deleted records, new SOA, added records), followed by the current SOA
+	deleting := false
+	for _, rr := range rrs[1 : len(rrs)-1] {
+		if _, ok := rr.(*dns.SOA); ok {
+			deleting = !deleting
+			continue
+		}
+		if deleting {
+			c.Deleted = append(c.Deleted, rr)
+		} else {
+			c.Added = append(c.Added, rr)
+		}
+	}
+	return &c, nil
+}
+
+// returns the domains that have been registered and expired since the given SOA serial, and the current serial, based
+// on an IXFR.
+// A domain is considered registered if delegation records were only added for it, and expired if its delegation
+// records were only deleted. Domains of which records were both added and deleted are considered modified.
+func (z *axfrZone) Diff(serial uint32) ([]string, []string, uint32, error) {
+	c, err := z.Changes(serial)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	origin := strings.ToLower(strings.TrimSuffix(z.conf.Tld, "."))
+	added := delegatedDomains(c.Added, origin)
+	deleted := delegatedDomains(c.Deleted, origin)
+
+	var registered, expired []string
+	for d := range added {
+		if _, ok := deleted[d]; !ok {
+			registered = append(registered, d)
+		}
+	}
+	for d := range deleted {
+		if _, ok := added[d]; !ok {
+			expired = append(expired, d)
+		}
+	}
+	return registered, expired, c.To, nil
+}
+
+// returns the set of domains that own a delegation record (NS, A or AAAA), excluding the origin of the zone
+func delegatedDomains(rrs []dns.RR, origin string) map[string]interface{} {
+	res := make(map[string]interface{})
+	for _, rr := range rrs {
+		switch rr.(type) {
+		case *dns.NS, *dns.A, *dns.AAAA:
+			domain := strings.TrimSuffix(strings.ToLower(rr.Header().Name), ".")
+			if domain != origin {
+				res[domain] = nil
+			}
+		}
+	}
+	return res
+}
+
+func New(conf Config) (zone2.Zone, error) {
+	if conf.Tld == "" || conf.Server == "" {
+		return nil, errors.New("tld and server cannot be empty")
+	}
+	z := axfrZone{
+		conf: conf,
+	}
+	return &z, nil
+}
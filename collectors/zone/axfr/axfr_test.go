@@ -0,0 +1,227 @@
+package axfr
+
+import (
+	zone2 "github.com/aau-network-security/gollector/collectors/zone"
+	"github.com/miekg/dns"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const (
+	tsigName   = "transfer."
+	tsigSecret = "c2VjcmV0IGtleSBmb3IgdGVzdGluZw=="
+)
+
+func mustRR(t *testing.T, s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("failed to parse record: %s", err)
+	}
+	return rr
+}
+
+// serves the zone 'test.' over TCP, and returns its address along with a function that stops the server
+func testServer(t *testing.T, tsig bool) (string, func()) {
+	soa1 := mustRR(t, "test. 3600 IN SOA ns.test. admin.test. 1 3600 600 86400 300")
+	soa2 := mustRR(t, "test. 3600 IN SOA ns.test. admin.test. 2 3600 600 86400 300")
+	zone := []dns.RR{
+		soa2,
+		mustRR(t, "test. 3600 IN NS ns.test."),
+		mustRR(t, "a.test. 3600 IN NS ns.example.net."),
+		mustRR(t, "b.test. 3600 IN NS ns.example.com."),
+		mustRR(t, "d.test. 3600 IN NS ns2.example.com."),
+		soa2,
+	}
+	// changes from serial 1 to 2: c.test expired, d.test registered and a.test changed its name servers
+	ixfr := []dns.RR{
+		soa2,
+		soa1,
+		mustRR(t, "c.test. 3600 IN NS ns.example.com."),
+		mustRR(t, "a.test. 3600 IN NS ns.example.com."),
+		soa2,
+		mustRR(t, "d.test. 3600 IN NS ns2.example.com."),
+		mustRR(t, "a.test. 3600 IN NS ns.example.net."),
+		soa2,
+	}
+
+	handler := func(w dns.ResponseWriter, r *dns.Msg) {
+		if tsig && (r.IsTsig() == nil || w.TsigStatus() != nil) {
+			m := dns.Msg{}
+			m.SetRcode(r, dns.RcodeNotAuth)
+			w.WriteMsg(&m)
+			return
+		}
+
+		var rrs []dns.RR
+		switch r.Question[0].Qtype {
+		case dns.TypeAXFR:
+			rrs = zone
+		case dns.TypeIXFR:
+			serial := r.Ns[0].(*dns.SOA).Serial
+			switch serial {
+			case 1:
+				rrs = ixfr
+			case 2:
+				rrs = []dns.RR{soa2}
+			default:
+				// the server does not have the history, so it falls back to a full transfer
+				rrs = zone
+			}
+		}
+
+		ch := make(chan *dns.Envelope)
+		tr := dns.Transfer{}
+		go tr.Out(w, r, ch)
+		ch <- &dns.Envelope{RR: rrs}
+		close(ch)
+		w.Hijack()
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	srv := dns.Server{
+		Listener: l,
+		Handler:  dns.HandlerFunc(handler),
+	}
+	if tsig {
+		srv.TsigSecret = map[string]string{tsigName: tsigSecret}
+	}
+	go srv.ActivateAndServe()
+
+	return l.Addr().String(), func() { srv.Shutdown() }
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		serverTsig bool
+		tsig       Tsig
+		expectErr  bool
+	}{
+		{
+			name: "plain",
+		},
+		{
+			name:       "tsig",
+			serverTsig: true,
+			tsig:       Tsig{Name: tsigName, Secret: tsigSecret},
+		},
+		{
+			name:       "missing tsig",
+			serverTsig: true,
+			expectErr:  true,
+		},
+		{
+			name:       "wrong tsig secret",
+			serverTsig: true,
+			tsig:       Tsig{Name: tsigName, Secret: "d3Jvbmc="},
+			expectErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr, stop := testServer(t, test.serverTsig)
+			defer stop()
+
+			z, err := New(Config{Tld: "test", Server: addr, Tsig: test.tsig})
+			if err != nil {
+				t.Fatalf("failed to create zone: %s", err)
+			}
+
+			str, err := z.Stream()
+			if err != nil {
+				if test.expectErr {
+					return
+				}
+				t.Fatalf("unexpected error while obtaining stream: %s", err)
+			}
+			defer str.Close()
+
+			var domains []string
+			fn := func(domain []byte) error {
+				domains = append(domains, string(domain))
+				return nil
+			}
+			err = zone2.ZoneFileHandler(str, fn)
+			if test.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error while handling stream: %s", err)
+			}
+
+			expected := []string{"a.test", "b.test", "d.test"}
+			sort.Strings(domains)
+			if !reflect.DeepEqual(domains, expected) {
+				t.Fatalf("expected domains %v, but got %v", expected, domains)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	addr, stop := testServer(t, false)
+	defer stop()
+
+	z, err := New(Config{Tld: "test", Server: addr})
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	dz, ok := z.(zone2.DiffZone)
+	if !ok {
+		t.Fatalf("expected zone to implement DiffZone")
+	}
+
+	tests := []struct {
+		name               string
+		serial             uint32
+		expectedRegistered []string
+		expectedExpired    []string
+		expectedSerial     uint32
+		expectedErr        error
+	}{
+		{
+			name:               "changes",
+			serial:             1,
+			expectedRegistered: []string{"d.test"},
+			expectedExpired:    []string{"c.test"},
+			expectedSerial:     2,
+		},
+		{
+			name:           "unchanged",
+			serial:         2,
+			expectedSerial: 2,
+		},
+		{
+			name:        "full transfer",
+			serial:      100,
+			expectedErr: IxfrUnavailableErr,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registered, expired, serial, err := dz.Diff(test.serial)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, but got %v", test.expectedErr, err)
+			}
+			if serial != test.expectedSerial {
+				t.Fatalf("expected serial %d, but got %d", test.expectedSerial, serial)
+			}
+			if !reflect.DeepEqual(registered, test.expectedRegistered) {
+				t.Fatalf("expected registered domains %v, but got %v", test.expectedRegistered, registered)
+			}
+			if !reflect.DeepEqual(expired, test.expectedExpired) {
+				t.Fatalf("expected expired domains %v, but got %v", test.expectedExpired, expired)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package zone
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/aau-network-security/gollector/store"
+	"github.com/aau-network-security/gollector/store/models"
+	"github.com/go-pg/pg"
+	"github.com/miekg/dns"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+var (
+	NoSoaErr            = errors.New("no SOA record found")
+	SizeMismatchErr     = errors.New("size of archive does not match catalogue")
+	ChecksumMismatchErr = errors.New("checksum of archive does not match catalogue")
+)
+
+// keeps track of the zone files that have been archived to disk
+type Catalogue interface {
+	Add(*models.ZoneArchive) error
+	Update(*models.ZoneArchive) error
+	// returns the most recent entry of a TLD, or nil if there is none
+	Last(tld string) (*models.ZoneArchive, error)
+	List() ([]*models.ZoneArchive, error)
+}
+
+type pgCatalogue struct {
+	db *pg.DB
+}
+
+func (c *pgCatalogue) Add(za *models.ZoneArchive) error {
+	return c.db.Insert(za)
+}
+
+func (c *pgCatalogue) Update(za *models.ZoneArchive) error {
+	return c.db.Update(za)
+}
+
+func (c *pgCatalogue) Last(tld string) (*models.ZoneArchive, error) {
+	var za models.ZoneArchive
+	if err := c.db.Model(&za).Where("tld = ?", tld).Order("id DESC").Limit(1).Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &za, nil
+}
+
+func (c *pgCatalogue) List() ([]*models.ZoneArchive, error) {
+	var res []*models.ZoneArchive
+	if err := c.db.Model(&res).Order("id ASC").Select(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// returns a catalogue that is stored in the 'zone_archives' table of a Postgres database
+func NewPostgresCatalogue(conf store.Config) (Catalogue, error) {
+	g, err := conf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer g.Close()
+	if err := g.AutoMigrate(&models.ZoneArchive{}).Error; err != nil {
+		return nil, err
+	}
+
+	pgOpts := pg.Options{
+		User:     conf.User,
+		Password: conf.Password,
+		Addr:     fmt.Sprintf("%s:%d", conf.Host, conf.Port),
+		Database: conf.DBName,
+	}
+
+	c := pgCatalogue{
+		db: pg.Connect(&pgOpts),
+	}
+	return &c, nil
+}
+
+// statistics about the content of a zone file
+type ZoneStats struct {
+	SoaSerial   uint32
+	RecordCount int64
+}
+
+// computes the statistics of a zone file, returning an error if the content is incomplete
+type Inspector func(io.Reader) (ZoneStats, error)
+
+// inspects files that fulfill the zone file standard
+func ZoneFileInspector(r io.Reader) (ZoneStats, error) {
+	var stats ZoneStats
+	soaFound := false
+
+	zp := dns.NewZoneParser(r, "", "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		stats.RecordCount++
+		if soa, ok := rr.(*dns.SOA); ok && !soaFound {
+			stats.SoaSerial = soa.Serial
+			soaFound = true
+		}
+	}
+	if err := zp.Err(); err != nil {
+		return stats, err
+	}
+	if !soaFound {
+		return stats, NoSoaErr
+	}
+	return stats, nil
+}
+
+// inspects files that contain a list of domain names
+func ListInspector(r io.Reader) (ZoneStats, error) {
+	var stats ZoneStats
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		stats.RecordCount++
+	}
+	return stats, scanner.Err()
+}
+
+// returns the SOA serial of a zone, used to determine if a zone has changed before downloading it
+type SerialFunc func(tld string) (uint32, error)
+
+// returns a function that queries the given DNS resolver (e.g. '1.1.1.1:53') for SOA serials
+func DnsSerialFunc(resolver string) SerialFunc {
+	return func(tld string) (uint32, error) {
+		m := dns.Msg{}
+		m.SetQuestion(dns.Fqdn(tld), dns.TypeSOA)
+
+		c := dns.Client{
+			Timeout: 10 * time.Second,
+		}
+		resp, _, err := c.Exchange(&m, resolver)
+		if err != nil {
+			return 0, err
+		}
+		for _, rr := range resp.Answer {
+			if soa, ok := rr.(*dns.SOA); ok {
+				return soa.Serial, nil
+			}
+		}
+		return 0, NoSoaErr
+	}
+}
+
+// re-checks the size, checksum and compression of an archived zone file, and records the result in the catalogue entry
+func Verify(za *models.ZoneArchive) error {
+	err := verify(za)
+	za.VerifiedAt = time.Now()
+	za.Valid = err == nil
+	return err
+}
+
+func verify(za *models.ZoneArchive) error {
+	f, err := os.Open(za.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	cr := &countingReader{r: io.TeeReader(f, h)}
+
+	g, err := gzip.NewReader(cr)
+	if err != nil {
+		return err
+	}
+	// reading the entire stream validates the gzip checksum of the content
+	if _, err := io.Copy(ioutil.Discard, g); err != nil {
+		return err
+	}
+	// consume trailing bytes, such that the size and checksum cover the entire file
+	if _, err := io.Copy(ioutil.Discard, cr); err != nil {
+		return err
+	}
+
+	if cr.n != za.Size {
+		return SizeMismatchErr
+	}
+	if fmt.Sprintf("%x", h.Sum(nil)) != za.Sha256 {
+		return ChecksumMismatchErr
+	}
+	return nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
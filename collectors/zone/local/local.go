@@ -0,0 +1,232 @@
+package local
+
+import (
+	"errors"
+	zone2 "github.com/aau-network-security/gollector/collectors/zone"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultDateFormat = "2006-01-02"
+)
+
+var (
+	InvalidFilenameErr = errors.New("file name is not of the form '<tld>.<date>'")
+)
+
+type Config struct {
+	Tld  string `yaml:"tld"`
+	Path string `yaml:"path"`
+}
+
+type localZone struct {
+	conf Config
+}
+
+func (z *localZone) Tld() string {
+	return z.conf.Tld
+}
+
+func (z *localZone) Stream() (io.ReadCloser, error) {
+	return os.Open(z.conf.Path)
+}
+
+func New(conf Config) (zone2.Zone, error) {
+	if conf.Tld == "" || conf.Path == "" {
+		return nil, errors.New("tld and path cannot be empty")
+	}
+	z := localZone{
+		conf: conf,
+	}
+	return &z, nil
+}
+
+// a zone file of which the TLD and date are derived from its file name
+type File struct {
+	Path string
+	Tld  string
+	Date time.Time
+	Gzip bool
+}
+
+// parses file names of the form '<tld>.<date>', optionally followed by a '.gz' extension
+func ParseFilename(path string, dateFormat string) (File, error) {
+	f := File{
+		Path: path,
+	}
+	name := filepath.Base(path)
+	if strings.HasSuffix(name, ".gz") {
+		f.Gzip = true
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return f, InvalidFilenameErr
+	}
+	date, err := time.Parse(dateFormat, parts[1])
+	if err != nil {
+		return f, InvalidFilenameErr
+	}
+	f.Tld = parts[0]
+	f.Date = date
+	return f, nil
+}
+
+type datedZone struct {
+	localZone
+	date time.Time
+}
+
+func (z *datedZone) Date() time.Time {
+	return z.date
+}
+
+// returns the zone of the file, which is dated according to its file name
+func (f File) Zone() zone2.Zone {
+	return &datedZone{
+		localZone: localZone{
+			conf: Config{
+				Tld:  f.Tld,
+				Path: f.Path,
+			},
+		},
+		date: f.Date,
+	}
+}
+
+type WatchConfig struct {
+	Dir        string        `yaml:"dir"`
+	ArchiveDir string        `yaml:"archive-dir"`
+	DateFormat string        `yaml:"date-format"` // defaults to 2006-01-02
+	Settle     time.Duration `yaml:"settle"`      // time without modifications after which a file is considered complete, defaults to 10s
+}
+
+func (conf *WatchConfig) dateFormat() string {
+	if conf.DateFormat == "" {
+		return DefaultDateFormat
+	}
+	return conf.DateFormat
+}
+
+func (conf *WatchConfig) settle() time.Duration {
+	if conf.Settle == 0 {
+		return 10 * time.Second
+	}
+	return conf.Settle
+}
+
+// called for each complete zone file in the watched directory
+type FileFunc func(File) error
+
+// watches a directory for new zone files, and moves them to an archive directory once they have been processed
+type Watcher struct {
+	conf    WatchConfig
+	w       *fsnotify.Watcher
+	pending map[string]time.Time
+	done    chan struct{}
+}
+
+func NewWatcher(conf WatchConfig) (*Watcher, error) {
+	if conf.Dir == "" || conf.ArchiveDir == "" {
+		return nil, errors.New("directory and archive directory cannot be empty")
+	}
+	if err := os.MkdirAll(conf.ArchiveDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(conf.Dir); err != nil {
+		w.Close()
+		return nil, err
+	}
+	watcher := Watcher{
+		conf:    conf,
+		w:       w,
+		pending: make(map[string]time.Time),
+		done:    make(chan struct{}),
+	}
+	return &watcher, nil
+}
+
+// stops the watcher, after which Run returns
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.w.Close()
+}
+
+// passes the files that are already in the directory, and files that appear afterwards, to fn. Files are processed one
+// at a time once they have not been modified for the settle duration, and are left in place if fn returns an error.
+func (w *Watcher) Run(fn FileFunc) error {
+	infos, err := ioutil.ReadDir(w.conf.Dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if !info.IsDir() {
+			w.pending[filepath.Join(w.conf.Dir, info.Name())] = time.Time{}
+		}
+	}
+
+	ticker := time.NewTicker(w.conf.settle() / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return nil
+		case ev, ok := <-w.w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				delete(w.pending, ev.Name)
+				continue
+			}
+			w.pending[ev.Name] = time.Now()
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warn().Msgf("error while watching '%s': %s", w.conf.Dir, err)
+		case <-ticker.C:
+			w.processPending(fn)
+		}
+	}
+}
+
+func (w *Watcher) processPending(fn FileFunc) {
+	for path, t := range w.pending {
+		if time.Since(t) < w.conf.settle() {
+			continue
+		}
+		delete(w.pending, path)
+
+		f, err := ParseFilename(path, w.conf.dateFormat())
+		if err != nil {
+			log.Debug().Msgf("ignoring '%s': %s", path, err)
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		if err := fn(f); err != nil {
+			log.Error().Msgf("failed to process '%s': %s", path, err)
+			continue
+		}
+		dst := filepath.Join(w.conf.ArchiveDir, filepath.Base(path))
+		if err := os.Rename(path, dst); err != nil {
+			log.Error().Msgf("failed to move '%s' to archive directory: %s", path, err)
+		}
+	}
+}
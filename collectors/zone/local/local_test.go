@@ -0,0 +1,138 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedTld  string
+		expectedDate time.Time
+		expectedGzip bool
+		expectedErr  error
+	}{
+		{
+			name:         "se.2021-02-01",
+			expectedTld:  "se",
+			expectedDate: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "/zones/dk.2021-02-01.gz",
+			expectedTld:  "dk",
+			expectedDate: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+			expectedGzip: true,
+		},
+		{
+			name:        "se.zone",
+			expectedErr: InvalidFilenameErr,
+		},
+		{
+			name:        "2021-02-01",
+			expectedErr: InvalidFilenameErr,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := ParseFilename(test.name, DefaultDateFormat)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, but got %v", test.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if f.Tld != test.expectedTld {
+				t.Fatalf("expected tld '%s', but got '%s'", test.expectedTld, f.Tld)
+			}
+			if !f.Date.Equal(test.expectedDate) {
+				t.Fatalf("expected date %s, but got %s", test.expectedDate, f.Date)
+			}
+			if f.Gzip != test.expectedGzip {
+				t.Fatalf("expected gzip %t, but got %t", test.expectedGzip, f.Gzip)
+			}
+		})
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatalf("unexpected error while creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	archiveDir := filepath.Join(dir, "archive")
+
+	write := func(name string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("example.se\n"), 0644); err != nil {
+			t.Fatalf("unexpected error while writing file: %s", err)
+		}
+	}
+	// files that exist before the watcher starts are processed as well
+	write("se.2021-02-01")
+	write("unrelated.txt")
+
+	w, err := NewWatcher(WatchConfig{
+		Dir:        dir,
+		ArchiveDir: archiveDir,
+		Settle:     50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error while creating watcher: %s", err)
+	}
+
+	processed := make(chan string)
+	fn := func(f File) error {
+		processed <- f.Tld
+		if f.Tld == "fail" {
+			return os.ErrInvalid
+		}
+		return nil
+	}
+	errc := make(chan error)
+	go func() {
+		errc <- w.Run(fn)
+	}()
+
+	write("nu.2021-02-02")
+	write("fail.2021-02-02")
+
+	var tlds []string
+	for len(tlds) < 3 {
+		select {
+		case tld := <-processed:
+			tlds = append(tlds, tld)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for files to be processed, got %v", tlds)
+		}
+	}
+	// wait for the last file to be handled, before stopping the watcher
+	time.Sleep(100 * time.Millisecond)
+	w.Close()
+	if err := <-errc; err != nil {
+		t.Fatalf("unexpected error while watching: %s", err)
+	}
+
+	sort.Strings(tlds)
+	expected := []string{"fail", "nu", "se"}
+	for i := range expected {
+		if tlds[i] != expected[i] {
+			t.Fatalf("expected processed tlds %v, but got %v", expected, tlds)
+		}
+	}
+
+	for _, name := range []string{"se.2021-02-01", "nu.2021-02-02"} {
+		if _, err := os.Stat(filepath.Join(archiveDir, name)); err != nil {
+			t.Fatalf("expected '%s' to be moved to archive directory: %s", name, err)
+		}
+	}
+	for _, name := range []string{"fail.2021-02-02", "unrelated.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected '%s' to remain in directory: %s", name, err)
+		}
+	}
+}
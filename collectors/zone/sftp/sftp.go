@@ -0,0 +1,325 @@
+package sftp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	zone2 "github.com/aau-network-security/gollector/collectors/zone"
+	ssh2 "github.com/aau-network-security/gollector/collectors/zone/ssh"
+	"github.com/pkg/sftp"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	NoMatchErr         = errors.New("no remote file matches the pattern")
+	UnknownProtocolErr = errors.New("unknown protocol")
+	InvalidPatternErr  = errors.New("only the file name of the path may contain a glob pattern")
+)
+
+type Protocol string
+
+const (
+	ProtocolSftp Protocol = "sftp"
+	ProtocolScp  Protocol = "scp"
+)
+
+type Config struct {
+	Tld      string      `yaml:"tld"`
+	Protocol Protocol    `yaml:"protocol"` // defaults to sftp
+	Path     string      `yaml:"path"`     // the file name may be a glob pattern, in which case the most recent matching file is used
+	Ssh      ssh2.Config `yaml:"ssh"`
+}
+
+// a remote file along with the connections used to retrieve it
+type remoteFile struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (rf *remoteFile) Close() error {
+	var res error
+	for _, c := range rf.closers {
+		if err := c.Close(); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+type sftpZone struct {
+	conf Config
+}
+
+func (z *sftpZone) Tld() string {
+	return z.conf.Tld
+}
+
+func (z *sftpZone) Stream() (io.ReadCloser, error) {
+	sshClient, err := ssh2.Dial(z.conf.Ssh)
+	if err != nil {
+		return nil, err
+	}
+
+	var rc io.ReadCloser
+	switch z.conf.Protocol {
+	case "", ProtocolSftp:
+		rc, err = sftpStream(sshClient, z.conf.Path)
+	case ProtocolScp:
+		rc, err = scpStream(sshClient, z.conf.Path)
+	default:
+		err = UnknownProtocolErr
+	}
+	if err != nil {
+		sshClient.Close()
+		return nil, err
+	}
+	return &remoteFile{
+		Reader:  rc,
+		closers: []io.Closer{rc, sshClient},
+	}, nil
+}
+
+// opens the most recently modified file that matches the pattern
+func sftpStream(sshClient *ssh.Client, pattern string) (io.ReadCloser, error) {
+	c, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := c.Glob(pattern)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	path := ""
+	for _, m := range matches {
+		fi, err := c.Stat(m)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		if fi.IsDir() {
+			continue
+		}
+		if path == "" {
+			path = m
+			continue
+		}
+		cur, err := c.Stat(path)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		if fi.ModTime().After(cur.ModTime()) {
+			path = m
+		}
+	}
+	if path == "" {
+		c.Close()
+		return nil, NoMatchErr
+	}
+	log.Debug().Msgf("retrieving '%s' over SFTP", path)
+
+	f, err := c.Open(path)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &remoteFile{
+		Reader:  f,
+		closers: []io.Closer{f, c},
+	}, nil
+}
+
+// quotes s for use as a single argument in a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
+
+// returns the most recently modified file that matches the pattern. The directory of the pattern is listed by the
+// remote shell, and its entries are matched locally, such that the pattern is never interpreted by the shell.
+func latestRemoteFile(sshClient *ssh.Client, pattern string) (string, error) {
+	dir, base := path.Split(pattern)
+	if dir == "" {
+		dir = "."
+	}
+	if strings.ContainsAny(dir, "*?[\\") {
+		return "", InvalidPatternErr
+	}
+	if _, err := path.Match(base, ""); err != nil {
+		return "", InvalidPatternErr
+	}
+
+	sess, err := sshClient.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer sess.Close()
+
+	// lists the entries by modification time, most recent first, where directories are marked by a trailing slash
+	out, err := sess.Output("ls -1tpA -- " + shellQuote(dir))
+	if err != nil {
+		return "", NoMatchErr
+	}
+	for _, name := range strings.Split(string(out), "\n") {
+		if name == "" || strings.HasSuffix(name, "/") {
+			continue
+		}
+		if ok, _ := path.Match(base, name); ok {
+			return path.Join(dir, name), nil
+		}
+	}
+	return "", NoMatchErr
+}
+
+type scpReader struct {
+	r         *bufio.Reader
+	w         io.WriteCloser
+	sess      *ssh.Session
+	remaining int64
+}
+
+func (sr *scpReader) Read(p []byte) (int, error) {
+	if sr.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > sr.remaining {
+		p = p[:sr.remaining]
+	}
+	n, err := sr.r.Read(p)
+	sr.remaining -= int64(n)
+	if err == io.EOF && sr.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if sr.remaining == 0 && err == nil {
+		// the file content is followed by a status byte of the sender
+		if err := readScpStatus(sr.r); err != nil {
+			return n, err
+		}
+		if _, err := sr.w.Write([]byte{0}); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+func (sr *scpReader) Close() error {
+	sr.w.Close()
+	return sr.sess.Close()
+}
+
+// reads a status byte sent by an SCP source, followed by a message in case of an error
+func readScpStatus(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == 0 {
+		return nil
+	}
+	msg, _ := r.ReadString('\n')
+	return fmt.Errorf("scp: %s", strings.TrimSpace(msg))
+}
+
+// retrieves the most recently modified file that matches the pattern, using the sink side of the SCP protocol
+func scpStream(sshClient *ssh.Client, pattern string) (io.ReadCloser, error) {
+	path, err := latestRemoteFile(sshClient, pattern)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().Msgf("retrieving '%s' over SCP", path)
+
+	sess, err := sshClient.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	w, err := sess.StdinPipe()
+	if err != nil {
+		sess.Close()
+		return nil, err
+	}
+	stdout, err := sess.StdoutPipe()
+	if err != nil {
+		sess.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(stdout)
+
+	size, err := func() (int64, error) {
+		if err := sess.Start("scp -f " + shellQuote(path)); err != nil {
+			return 0, err
+		}
+		if _, err := w.Write([]byte{0}); err != nil {
+			return 0, err
+		}
+
+		// expects a control message of the form 'C<mode> <size> <name>'
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != 'C' {
+			r.UnreadByte()
+			if err := readScpStatus(r); err != nil {
+				return 0, err
+			}
+			return 0, fmt.Errorf("scp: unexpected message type '%c'", b)
+		}
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) != 3 {
+			return 0, fmt.Errorf("scp: malformed control message '%s'", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.Write([]byte{0}); err != nil {
+			return 0, err
+		}
+		if size == 0 {
+			if err := readScpStatus(r); err != nil {
+				return 0, err
+			}
+			if _, err := w.Write([]byte{0}); err != nil {
+				return 0, err
+			}
+		}
+		return size, nil
+	}()
+	if err != nil {
+		sess.Close()
+		return nil, err
+	}
+
+	sr := scpReader{
+		r:         r,
+		w:         w,
+		sess:      sess,
+		remaining: size,
+	}
+	return &sr, nil
+}
+
+func New(conf Config) (zone2.Zone, error) {
+	if conf.Tld == "" || conf.Path == "" {
+		return nil, errors.New("tld and path cannot be empty")
+	}
+	switch conf.Protocol {
+	case "", ProtocolSftp, ProtocolScp:
+	default:
+		return nil, UnknownProtocolErr
+	}
+	z := sftpZone{
+		conf: conf,
+	}
+	return &z, nil
+}
@@ -0,0 +1,268 @@
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	ssh2 "github.com/aau-network-security/gollector/collectors/zone/ssh"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testPassword = "password"
+
+func newSigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("failed to create signer: %s", err)
+	}
+	return signer
+}
+
+// handles a session channel by serving the sftp subsystem, or by executing commands with the local shell
+func handleSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		switch req.Type {
+		case "subsystem":
+			req.Reply(string(req.Payload[4:]) == "sftp", nil)
+			srv, err := sftp.NewServer(ch)
+			if err != nil {
+				return
+			}
+			srv.Serve()
+			return
+		case "exec":
+			req.Reply(true, nil)
+			cmd := exec.Command("sh", "-c", string(req.Payload[4:]))
+			cmd.Stdin = ch
+			cmd.Stdout = ch
+			cmd.Stderr = ch.Stderr()
+			status := make([]byte, 4)
+			if err := cmd.Run(); err != nil {
+				binary.BigEndian.PutUint32(status, 1)
+			}
+			ch.CloseWrite()
+			ch.SendRequest("exit-status", false, status)
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// starts an SSH server that allows password authentication, and returns its address
+func testServer(t *testing.T, hostKey ssh.Signer) (string, func()) {
+	conf := ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != testPassword {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	conf.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, &conf)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newCh := range chans {
+					if newCh.ChannelType() != "session" {
+						newCh.Reject(ssh.UnknownChannelType, "unknown channel type")
+						continue
+					}
+					ch, chReqs, err := newCh.Accept()
+					if err != nil {
+						continue
+					}
+					go handleSession(ch, chReqs)
+				}
+			}()
+		}
+	}()
+	return l.Addr().String(), func() { l.Close() }
+}
+
+func TestStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sftp")
+	if err != nil {
+		t.Fatalf("unexpected error while creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"test.2021-01-01.zone": "old",
+		"test.2021-01-02.zone": "new",
+		"other.zone":           "other",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error while writing file: %s", err)
+		}
+	}
+	// the oldest file by name is the most recently modified one
+	latest := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "test.2021-01-01.zone"), latest, latest); err != nil {
+		t.Fatalf("unexpected error while changing modification time: %s", err)
+	}
+
+	hostKey := newSigner(t)
+	addr, stop := testServer(t, hostKey)
+	defer stop()
+	host, port, _ := net.SplitHostPort(addr)
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey.PublicKey())
+	if err := ioutil.WriteFile(knownHostsFile, []byte(line+"\n"), 0644); err != nil {
+		t.Fatalf("unexpected error while writing known hosts: %s", err)
+	}
+	otherKnownHostsFile := filepath.Join(dir, "other_known_hosts")
+	line = knownhosts.Line([]string{knownhosts.Normalize(addr)}, newSigner(t).PublicKey())
+	if err := ioutil.WriteFile(otherKnownHostsFile, []byte(line+"\n"), 0644); err != nil {
+		t.Fatalf("unexpected error while writing known hosts: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		protocol   Protocol
+		path       string
+		knownHosts string
+		expected   string
+		expectErr  bool
+	}{
+		{
+			name:       "sftp",
+			protocol:   ProtocolSftp,
+			path:       filepath.Join(dir, "other.zone"),
+			knownHosts: knownHostsFile,
+			expected:   "other",
+		},
+		{
+			name:       "sftp glob",
+			protocol:   ProtocolSftp,
+			path:       filepath.Join(dir, "test.*.zone"),
+			knownHosts: knownHostsFile,
+			expected:   "old",
+		},
+		{
+			name:       "scp glob",
+			protocol:   ProtocolScp,
+			path:       filepath.Join(dir, "test.*.zone"),
+			knownHosts: knownHostsFile,
+			expected:   "old",
+		},
+		{
+			name:       "no match",
+			protocol:   ProtocolSftp,
+			path:       filepath.Join(dir, "*.gz"),
+			knownHosts: knownHostsFile,
+			expectErr:  true,
+		},
+		{
+			name:       "scp no match",
+			protocol:   ProtocolScp,
+			path:       filepath.Join(dir, "*.gz"),
+			knownHosts: knownHostsFile,
+			expectErr:  true,
+		},
+		{
+			name:       "scp pattern is not interpreted by shell",
+			protocol:   ProtocolScp,
+			path:       filepath.Join(dir, "test.*.zone; touch "+filepath.Join(dir, "injected")),
+			knownHosts: knownHostsFile,
+			expectErr:  true,
+		},
+		{
+			name:      "scp glob in directory",
+			protocol:  ProtocolScp,
+			path:      filepath.Join(dir, "*", "test.zone"),
+			expectErr: true,
+		},
+		{
+			name:     "no known hosts",
+			protocol: ProtocolSftp,
+			path:     filepath.Join(dir, "other.zone"),
+			expected: "other",
+		},
+		{
+			name:       "unknown host key",
+			protocol:   ProtocolSftp,
+			path:       filepath.Join(dir, "other.zone"),
+			knownHosts: otherKnownHostsFile,
+			expectErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := Config{
+				Tld:      "test",
+				Protocol: test.protocol,
+				Path:     test.path,
+				Ssh: ssh2.Config{
+					Host:       host,
+					Port:       port,
+					User:       "test",
+					AuthType:   "password",
+					Password:   testPassword,
+					KnownHosts: test.knownHosts,
+				},
+			}
+			z, err := New(conf)
+			if err != nil {
+				t.Fatalf("failed to create zone: %s", err)
+			}
+
+			str, err := z.Stream()
+			if err != nil {
+				if test.expectErr {
+					return
+				}
+				t.Fatalf("unexpected error while obtaining stream: %s", err)
+			}
+			defer str.Close()
+			if test.expectErr {
+				t.Fatalf("expected error, but got none")
+			}
+
+			content, err := ioutil.ReadAll(str)
+			if err != nil && err != io.EOF {
+				t.Fatalf("unexpected error while reading stream: %s", err)
+			}
+			if string(content) != test.expected {
+				t.Fatalf("expected content '%s', but got '%s'", test.expected, content)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "injected")); err == nil {
+		t.Fatalf("expected path not to be interpreted by the remote shell")
+	}
+}
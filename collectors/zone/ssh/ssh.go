@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -20,6 +21,9 @@ type Config struct {
 	AuthType string `yaml:"authtype"`
 	Password string
 	Key      string `yaml:"key"`
+	// location of the known_hosts file to verify host keys against. Any host key is accepted if it is not given,
+	// which is vulnerable to man-in-the-middle attacks.
+	KnownHosts string `yaml:"known-hosts"`
 }
 
 func (conf *Config) isValid() bool {
@@ -56,6 +60,44 @@ func (conf *Config) getAuth() (ssh.AuthMethod, error) {
 	return auth, nil
 }
 
+func (conf *Config) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if conf.KnownHosts == "" {
+		log.Warn().Msgf("no known_hosts file provided, not verifying host key of '%s'", conf.Host)
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	return knownhosts.New(conf.KnownHosts)
+}
+
+func (conf *Config) addr() string {
+	port := conf.Port
+	if conf.Port == "" {
+		log.Info().Msgf("no SSH port provided, defaulting to port '22'")
+		port = "22"
+	}
+	return net.JoinHostPort(conf.Host, port)
+}
+
+// returns an SSH client connected to the configured host, of which the host key has been verified
+func Dial(conf Config) (*ssh.Client, error) {
+	auth, err := conf.getAuth()
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := conf.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	clientConfig := ssh.ClientConfig{
+		User:            conf.User,
+		HostKeyCallback: hostKeyCallback,
+		Auth: []ssh.AuthMethod{
+			auth,
+		},
+	}
+	return ssh.Dial("tcp", conf.addr(), &clientConfig)
+}
+
 func HttpClient(conf Config) (*http.Client, error) {
 	f, err := DialFunc(conf)
 	if err != nil {
@@ -73,29 +115,9 @@ func HttpClient(conf Config) (*http.Client, error) {
 }
 
 func DialFunc(conf Config) (func(network, address string) (net.Conn, error), error) {
-	auth, err := conf.getAuth()
+	sshClient, err := Dial(conf)
 	if err != nil {
 		return nil, err
 	}
-
-	clientConfig := ssh.ClientConfig{
-		User: conf.User,
-		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			return nil
-		},
-		Auth: []ssh.AuthMethod{
-			auth,
-		},
-	}
-
-	port := conf.Port
-	if conf.Port == "" {
-		log.Info().Msgf("no SSH port provided, defaulting to port '22'")
-		port = "22"
-	}
-
-	host := net.JoinHostPort(conf.Host, port)
-	sshClient, err := ssh.Dial("tcp", host, &clientConfig)
-
-	return sshClient.Dial, err
+	return sshClient.Dial, nil
 }
@@ -3,11 +3,14 @@ package zone
 import (
 	"bufio"
 	"compress/gzip"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"github.com/aau-network-security/gollector/store/models"
 	"github.com/miekg/dns"
 	"github.com/rs/zerolog/log"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +19,7 @@ import (
 
 var (
 	OptsInvalidErr = errors.New("process options are invalid")
+	TruncatedErr   = errors.New("file does not end with a newline")
 )
 
 type ZoneErr struct {
@@ -29,19 +33,71 @@ func (err *ZoneErr) Error() string {
 
 type DomainFunc func([]byte) error
 
+// implemented by zones of which the date differs from the time at which they are processed, e.g. files that are
+// ingested after they have been retrieved
+type DatedZone interface {
+	Zone
+	Date() time.Time
+}
+
+// implemented by zones that can report their changes since a given SOA serial (e.g. through IXFR), which avoids
+// comparing the zone file to the previous archive in ModeDiff
+type DiffZone interface {
+	Zone
+	// returns the domains that have been registered and expired since the given SOA serial, and the current serial
+	Diff(serial uint32) ([]string, []string, uint32, error)
+}
+
+// called for domains that have been added to (registered = true) or removed from (registered = false) a zone
+type DiffFunc func(domain []byte, registered bool) error
+
+// determines what happens to a zone file after it has been retrieved
+type Mode string
+
+const (
+	// only write the zone file to disk
+	ModeArchive Mode = "archive"
+	// write the zone file to disk and pass all of its domains to the domain function
+	ModeStream Mode = "stream"
+	// write the zone file to disk and pass the domains that changed since the previous archive to the diff function
+	ModeDiff Mode = "diff"
+)
+
 type StreamWrapper func(closer io.ReadCloser) (io.ReadCloser, error)
 
 type StreamHandler func(io.Reader, DomainFunc) error
 
 type ProcessOpts struct {
+	Mode           Mode // defaults to ModeStream
 	DomainFn       DomainFunc
+	DiffFn         DiffFunc // used in ModeDiff, domains are passed to DomainFn if no previous archive exists
 	StreamWrappers []StreamWrapper
 	StreamHandler  StreamHandler
 	TargetDir      string
+	Source         string     // name of the retrieval method, as stored in the catalogue
+	Catalogue      Catalogue  // optional, records the archived zone files
+	Inspector      Inspector  // optional, computes the statistics that are stored in the catalogue
+	SerialFn       SerialFunc // optional, skips the zone if its SOA serial is unchanged since the last archive
+	Compact        bool       // do not keep a new archive if it is identical to the previous one
+}
+
+func (opts *ProcessOpts) mode() Mode {
+	if opts.Mode == "" {
+		return ModeStream
+	}
+	return opts.Mode
 }
 
 func (opts *ProcessOpts) isValid() bool {
-	return opts.DomainFn != nil
+	switch opts.mode() {
+	case ModeArchive:
+		return true
+	case ModeStream:
+		return opts.DomainFn != nil && opts.StreamHandler != nil
+	case ModeDiff:
+		return opts.DomainFn != nil && opts.DiffFn != nil && opts.StreamHandler != nil
+	}
+	return false
 }
 
 // this handler reads files that fulfill the zone file standard
@@ -97,11 +153,265 @@ type Zone interface {
 	Tld() string
 }
 
+// returns the path of the most recent archive of a TLD in the target directory that precedes the given archive,
+// or an empty string if there is none
+func previousArchive(dir, tld, current string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%s.*.gz", tld)))
+	if err != nil {
+		return "", err
+	}
+
+	prefix := fmt.Sprintf("%s.", tld)
+	res := ""
+	for _, p := range paths {
+		date := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(p), prefix), ".gz")
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			continue
+		}
+		if p < current && p > res {
+			res = p
+		}
+	}
+	return res, nil
+}
+
+// returns the set of domains in an archived zone file
+func archivedDomains(path string, handler StreamHandler) (map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer g.Close()
+
+	res := make(map[string]interface{})
+	fn := func(domain []byte) error {
+		res[string(domain)] = nil
+		return nil
+	}
+	if err := handler(g, fn); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// passes the domains in r that are not in the previous archive to the diff function as registered, followed by the
+// domains in the previous archive that are not in r as expired
+func streamDiff(z Zone, r io.Reader, opts ProcessOpts, current string) error {
+	prevPath, err := previousArchive(opts.TargetDir, z.Tld(), current)
+	if err != nil {
+		return err
+	}
+	if prevPath == "" {
+		log.Info().Msgf("no previous archive for '%s', streaming all domains", z.Tld())
+		return opts.StreamHandler(r, opts.DomainFn)
+	}
+	log.Debug().Msgf("comparing '%s' to '%s'", z.Tld(), prevPath)
+
+	prev, err := archivedDomains(prevPath, opts.StreamHandler)
+	if err != nil {
+		return err
+	}
+
+	cur := make(map[string]interface{})
+	fn := func(domain []byte) error {
+		d := string(domain)
+		if _, ok := cur[d]; ok {
+			return nil
+		}
+		cur[d] = nil
+		if _, ok := prev[d]; ok {
+			return nil
+		}
+		return opts.DiffFn(domain, true)
+	}
+	if err := opts.StreamHandler(r, fn); err != nil {
+		return err
+	}
+
+	for d := range prev {
+		if _, ok := cur[d]; !ok {
+			if err := opts.DiffFn([]byte(d), false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// passes the changes reported by the zone since the SOA serial of the most recent archive in the catalogue to the diff
+// function, and records the current serial in the catalogue without transferring the full zone. Returns false if the
+// changes could not be obtained, in which case the diff function has not been called
+func incrementalDiff(z DiffZone, opts ProcessOpts) (bool, error) {
+	last, err := opts.Catalogue.Last(z.Tld())
+	if err != nil {
+		return false, err
+	}
+	// the changes since a zone file that failed to be processed would miss the domains it failed to pass on
+	if last == nil || last.Truncated || !last.Valid || last.SoaSerial == 0 {
+		return false, nil
+	}
+
+	registered, expired, serial, err := z.Diff(last.SoaSerial)
+	if err != nil {
+		return false, err
+	}
+	log.Debug().Msgf("obtained changes of '%s' since serial %d", z.Tld(), last.SoaSerial)
+
+	for _, d := range registered {
+		if err := opts.DiffFn([]byte(d), true); err != nil {
+			return true, err
+		}
+	}
+	for _, d := range expired {
+		if err := opts.DiffFn([]byte(d), false); err != nil {
+			return true, err
+		}
+	}
+
+	// the archive is not updated, so the entry refers to the previous archive
+	za := models.ZoneArchive{
+		Tld:         z.Tld(),
+		Source:      opts.Source,
+		Date:        time.Now(),
+		Path:        last.Path,
+		Sha256:      last.Sha256,
+		Size:        last.Size,
+		SoaSerial:   serial,
+		RecordCount: last.RecordCount,
+		Incremental: true,
+		Valid:       last.Valid,
+	}
+	if dz, ok := z.(DatedZone); ok {
+		za.Date = dz.Date()
+	}
+	return true, opts.Catalogue.Add(&za)
+}
+
+// returns true if the SOA serial of the zone equals the serial of the most recent archive in the catalogue
+func isUnchanged(z Zone, opts ProcessOpts) (bool, error) {
+	last, err := opts.Catalogue.Last(z.Tld())
+	if err != nil {
+		return false, err
+	}
+	// zone files that failed to be processed are processed again
+	if last == nil || last.Truncated || !last.Valid || last.SoaSerial == 0 {
+		return false, nil
+	}
+
+	serial, err := opts.SerialFn(z.Tld())
+	if err != nil {
+		return false, err
+	}
+	return serial == last.SoaSerial, nil
+}
+
+// computes the statistics of the (uncompressed) zone file in the given path
+func inspect(path string, inspector Inspector) (ZoneStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ZoneStats{}, err
+	}
+	defer f.Close()
+
+	lr := &lastByteReader{r: f}
+	stats, err := inspector(lr)
+	if err != nil {
+		return stats, err
+	}
+	// complete files end with a newline
+	if lr.last != '\n' {
+		return stats, TruncatedErr
+	}
+	return stats, nil
+}
+
+type lastByteReader struct {
+	r    io.Reader
+	last byte
+}
+
+func (lr *lastByteReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		lr.last = p[n-1]
+	}
+	return n, err
+}
+
+// writes a gzip compressed copy of the file in src to dst, and returns the checksum and size of the compressed file
+func archive(src, dst string) (string, int64, error) {
+	fin, err := os.Open(src)
+	if err != nil {
+		return "", 0, err
+	}
+	defer fin.Close()
+
+	fout, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return "", 0, err
+	}
+	defer fout.Close()
+
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(fout, h)}
+
+	wout := gzip.NewWriter(cw)
+	if _, err := io.Copy(wout, fin); err != nil {
+		return "", 0, err
+	}
+	if err := wout.Close(); err != nil {
+		return "", 0, err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), cw.n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
 func Process(z Zone, opts ProcessOpts) error {
 	if !opts.isValid() {
 		return OptsInvalidErr
 	}
 
+	if opts.Catalogue != nil && opts.SerialFn != nil {
+		unchanged, err := isUnchanged(z, opts)
+		if err != nil {
+			log.Warn().Msgf("failed to determine if '%s' has changed: %s", z.Tld(), err)
+		} else if unchanged {
+			log.Info().Msgf("SOA serial of '%s' is unchanged, skipping", z.Tld())
+			return nil
+		}
+	}
+
+	// the full zone is only transferred if its changes cannot be obtained otherwise
+	if dz, ok := z.(DiffZone); ok && opts.mode() == ModeDiff && opts.Catalogue != nil {
+		done, err := incrementalDiff(dz, opts)
+		if done {
+			if err != nil {
+				return &ZoneErr{z.Tld(), err}
+			}
+			return nil
+		}
+		if err != nil {
+			log.Warn().Msgf("failed to obtain changes of '%s', comparing to previous archive instead: %s", z.Tld(), err)
+		}
+	}
+
 	str, err := z.Stream()
 	if err != nil {
 		return &ZoneErr{z.Tld(), err}
@@ -116,9 +426,12 @@ func Process(z Zone, opts ProcessOpts) error {
 		}
 	}
 
-	// write zone file to temporary file on filesystem before processing
-	now := time.Now().Format("2006-01-02")
-	fileName := fmt.Sprintf("%s.%s", z.Tld(), now)
+	// write zone file to temporary file on filesystem, which is compressed into the permanent file afterwards
+	now := time.Now()
+	if dz, ok := z.(DatedZone); ok {
+		now = dz.Date()
+	}
+	fileName := fmt.Sprintf("%s.%s", z.Tld(), now.Format("2006-01-02"))
 	filePathTemp := filepath.Join(opts.TargetDir, fileName)
 	filePathPerm := fmt.Sprintf("%s.gz", filePathTemp)
 
@@ -128,41 +441,87 @@ func Process(z Zone, opts ProcessOpts) error {
 	}
 	defer os.Remove(fTemp.Name())
 
-	if _, err := io.Copy(fTemp, str); err != nil {
+	// process content of zone file while it is being written to disk. The zone file is archived even if it fails to be
+	// processed, in which case the error is returned once it is archived.
+	r := io.TeeReader(str, fTemp)
+	var handlerErr error
+	switch opts.mode() {
+	case ModeStream:
+		handlerErr = opts.StreamHandler(r, opts.DomainFn)
+	case ModeDiff:
+		handlerErr = streamDiff(z, r, opts, filePathPerm)
+	}
+	if handlerErr != nil {
+		log.Warn().Msgf("failed to process zone file of '%s', archiving it nonetheless: %s", z.Tld(), handlerErr)
+	}
+
+	// handlers do not necessarily consume the entire stream
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		fTemp.Close()
 		return &ZoneErr{z.Tld(), err}
 	}
 
 	log.Debug().Msgf("successfully written stream to file for '%s'", z.Tld())
 
-	// process content of zone file according to domain function
-	// TODO: re-enable
-	//if err := opts.StreamHandler(fTemp, opts.DomainFn); err != nil {
-	//	return &ZoneErr{z.Tld(), err}
-	//}
-
 	if err := fTemp.Close(); err != nil {
 		return &ZoneErr{z.Tld(), err}
 	}
 
 	// write to permanent file
-	fin, err := os.Open(filePathTemp)
+	checksum, size, err := archive(filePathTemp, filePathPerm)
 	if err != nil {
 		return &ZoneErr{z.Tld(), err}
 	}
-	defer fin.Close()
 
-	fout, err := os.OpenFile(filePathPerm, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	if err != nil {
-		return &ZoneErr{z.Tld(), err}
+	if opts.Catalogue == nil {
+		if handlerErr != nil {
+			return &ZoneErr{z.Tld(), handlerErr}
+		}
+		return nil
 	}
-	defer fout.Close()
 
-	wout := gzip.NewWriter(fout)
-	defer wout.Close()
+	za := models.ZoneArchive{
+		Tld:    z.Tld(),
+		Source: opts.Source,
+		Date:   now,
+		Path:   filePathPerm,
+		Sha256: checksum,
+		Size:   size,
+		Valid:  handlerErr == nil,
+	}
 
-	if _, err := io.Copy(wout, fin); err != nil {
+	if opts.Inspector != nil {
+		stats, err := inspect(filePathTemp, opts.Inspector)
+		if err != nil {
+			log.Warn().Msgf("zone file of '%s' is truncated: %s", z.Tld(), err)
+			za.Truncated = true
+		}
+		za.SoaSerial = stats.SoaSerial
+		za.RecordCount = stats.RecordCount
+	}
+
+	if opts.Compact {
+		last, err := opts.Catalogue.Last(z.Tld())
+		if err != nil {
+			return &ZoneErr{z.Tld(), err}
+		}
+		if last != nil && last.Sha256 == za.Sha256 && last.Path != za.Path {
+			if _, err := os.Stat(last.Path); err == nil {
+				log.Debug().Msgf("archive of '%s' is identical to '%s', removing it", z.Tld(), last.Path)
+				if err := os.Remove(za.Path); err != nil {
+					return &ZoneErr{z.Tld(), err}
+				}
+				za.Path = last.Path
+			}
+		}
+	}
+
+	if err := opts.Catalogue.Add(&za); err != nil {
 		return &ZoneErr{z.Tld(), err}
 	}
 
+	if handlerErr != nil {
+		return &ZoneErr{z.Tld(), handlerErr}
+	}
 	return nil
 }
@@ -2,10 +2,15 @@ package zone
 
 import (
 	"errors"
+	prt "github.com/aau-network-security/gollector/api/proto"
 	"github.com/aau-network-security/gollector/app"
 	"github.com/aau-network-security/gollector/store"
+	"github.com/aau-network-security/gollector/store/models"
 	testing2 "github.com/aau-network-security/gollector/testing"
 	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -19,8 +24,6 @@ type insertEntry struct {
 func TestGetStartTime(t *testing.T) {
 	testing2.SkipCI(t)
 
-	interval := 24 * time.Hour
-
 	tBase := time.Now()
 
 	tests := []struct {
@@ -97,7 +100,7 @@ func TestStoreLogentry(t *testing.T) {
 	ts := time.Now()
 	fqdn := "example.org"
 
-	if err := s.StoreZoneEntry(muid, ts, fqdn); err != nil {
+	if err := s.StoreZoneEntry(muid, ts, fqdn, prt.ZoneEntry_REGISTRATION); err != nil {
 		t.Fatalf("unexpected error while storing zone entry: %s", err)
 	}
 	if err := s.RunPostHooks(); err != nil {
@@ -161,6 +164,7 @@ func TestRetryProcess(t *testing.T) {
 		DomainFn:       df,
 		StreamWrappers: []StreamWrapper{},
 		StreamHandler:  ZoneFileHandler,
+		TargetDir:      t.TempDir(),
 	}
 
 	retryFn := func() error {
@@ -171,3 +175,464 @@ func TestRetryProcess(t *testing.T) {
 		t.Fatalf("unexpected error while processing zone: %s", err)
 	}
 }
+
+const testZoneFile = `test.	3600	IN	SOA	ns.test. admin.test. 2021020101 3600 900 604800 86400
+test.	3600	IN	NS	ns.test.
+example.test.	3600	IN	NS	ns.example.test.
+`
+
+type memCatalogue struct {
+	entries []*models.ZoneArchive
+}
+
+func (c *memCatalogue) Add(za *models.ZoneArchive) error {
+	za.ID = uint(len(c.entries) + 1)
+	c.entries = append(c.entries, za)
+	return nil
+}
+
+func (c *memCatalogue) Update(za *models.ZoneArchive) error {
+	c.entries[za.ID-1] = za
+	return nil
+}
+
+func (c *memCatalogue) Last(tld string) (*models.ZoneArchive, error) {
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		if c.entries[i].Tld == tld {
+			return c.entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *memCatalogue) List() ([]*models.ZoneArchive, error) {
+	return c.entries, nil
+}
+
+type stringZone struct {
+	content string
+	calls   int
+}
+
+func (z *stringZone) Stream() (io.ReadCloser, error) {
+	z.calls++
+	return ioutil.NopCloser(strings.NewReader(z.content)), nil
+}
+
+func (z *stringZone) Tld() string {
+	return "test"
+}
+
+func TestProcessCatalogue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zones")
+	if err != nil {
+		t.Fatalf("unexpected error while creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name              string
+		content           string
+		serial            uint32
+		expectedCalls     int
+		expectedEntries   int
+		expectedTruncated bool
+	}{
+		{
+			name:            "first download",
+			content:         testZoneFile,
+			serial:          2021020101,
+			expectedCalls:   1,
+			expectedEntries: 1,
+		},
+		{
+			name:            "unchanged serial",
+			content:         testZoneFile,
+			serial:          2021020101,
+			expectedCalls:   0,
+			expectedEntries: 1,
+		},
+		{
+			name:              "truncated download",
+			content:           testZoneFile[:40],
+			serial:            2021020102,
+			expectedCalls:     1,
+			expectedEntries:   2,
+			expectedTruncated: true,
+		},
+	}
+
+	c := memCatalogue{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			z := stringZone{content: test.content}
+			opts := ProcessOpts{
+				DomainFn: func([]byte) error {
+					return nil
+				},
+				StreamHandler: ZoneFileHandler,
+				TargetDir:     dir,
+				Source:        "test",
+				Catalogue:     &c,
+				Inspector:     ZoneFileInspector,
+				SerialFn: func(string) (uint32, error) {
+					return test.serial, nil
+				},
+			}
+			if err := Process(&z, opts); err != nil {
+				t.Fatalf("unexpected error while processing zone: %s", err)
+			}
+
+			if z.calls != test.expectedCalls {
+				t.Fatalf("expected %d download(s), but got %d", test.expectedCalls, z.calls)
+			}
+			if len(c.entries) != test.expectedEntries {
+				t.Fatalf("expected %d catalogue entries, but got %d", test.expectedEntries, len(c.entries))
+			}
+
+			za, _ := c.Last("test")
+			if za.Truncated != test.expectedTruncated {
+				t.Fatalf("expected truncated to be %t, but got %t", test.expectedTruncated, za.Truncated)
+			}
+			if !test.expectedTruncated {
+				if za.SoaSerial != test.serial {
+					t.Fatalf("expected SOA serial %d, but got %d", test.serial, za.SoaSerial)
+				}
+				if za.RecordCount != 3 {
+					t.Fatalf("expected %d records, but got %d", 3, za.RecordCount)
+				}
+			}
+
+			if err := Verify(za); err != nil {
+				t.Fatalf("unexpected error while verifying archive: %s", err)
+			}
+			if !za.Valid {
+				t.Fatalf("expected archive to be valid")
+			}
+		})
+	}
+
+	// tamper with the archive
+	za, _ := c.Last("test")
+	if err := ioutil.WriteFile(za.Path, []byte("invalid"), 0755); err != nil {
+		t.Fatalf("unexpected error while overwriting archive: %s", err)
+	}
+	if err := Verify(za); err == nil {
+		t.Fatalf("expected an error while verifying a modified archive, but got none")
+	}
+	if za.Valid {
+		t.Fatalf("expected archive to be invalid")
+	}
+}
+
+func TestProcessHandlerErr(t *testing.T) {
+	dir := t.TempDir()
+
+	handlerErr := errors.New("malformed record")
+	c := memCatalogue{}
+	z := stringZone{content: testZoneFile}
+	opts := ProcessOpts{
+		DomainFn: func([]byte) error {
+			return nil
+		},
+		// fails after reading the first record
+		StreamHandler: func(r io.Reader, domainFn DomainFunc) error {
+			if _, err := r.Read(make([]byte, 10)); err != nil {
+				return err
+			}
+			return handlerErr
+		},
+		TargetDir: dir,
+		Source:    "test",
+		Catalogue: &c,
+		Inspector: ZoneFileInspector,
+		SerialFn: func(string) (uint32, error) {
+			return 2021020101, nil
+		},
+	}
+	err := Process(&z, opts)
+	if ze, ok := err.(*ZoneErr); !ok || ze.err != handlerErr {
+		t.Fatalf("expected handler error, but got %v", err)
+	}
+
+	// the zone file is archived entirely nonetheless
+	if len(c.entries) != 1 {
+		t.Fatalf("expected %d catalogue entry, but got %d", 1, len(c.entries))
+	}
+	za := c.entries[0]
+	if za.Valid {
+		t.Fatalf("expected catalogue entry to be invalid")
+	}
+	if _, err := os.Stat(za.Path); err != nil {
+		t.Fatalf("expected archive to exist: %s", err)
+	}
+	if za.RecordCount != 3 {
+		t.Fatalf("expected %d records, but got %d", 3, za.RecordCount)
+	}
+
+	// the zone file is processed again, although its serial is unchanged
+	opts.StreamHandler = ZoneFileHandler
+	if err := Process(&z, opts); err != nil {
+		t.Fatalf("unexpected error while processing zone: %s", err)
+	}
+	if z.calls != 2 {
+		t.Fatalf("expected %d downloads, but got %d", 2, z.calls)
+	}
+}
+
+func TestProcessCompact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zones")
+	if err != nil {
+		t.Fatalf("unexpected error while creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := memCatalogue{}
+	previous := models.ZoneArchive{
+		Tld:  "test",
+		Path: dir + "/test.previous.gz",
+	}
+
+	z := stringZone{content: testZoneFile}
+	opts := ProcessOpts{
+		DomainFn: func([]byte) error {
+			return nil
+		},
+		StreamHandler: ZoneFileHandler,
+		TargetDir:     dir,
+		Catalogue:     &c,
+		Compact:       true,
+	}
+	if err := Process(&z, opts); err != nil {
+		t.Fatalf("unexpected error while processing zone: %s", err)
+	}
+
+	// pretend that the archive was created at an earlier date
+	current, _ := c.Last("test")
+	if err := os.Rename(current.Path, previous.Path); err != nil {
+		t.Fatalf("unexpected error while moving archive: %s", err)
+	}
+	current.Path = previous.Path
+
+	if err := Process(&z, opts); err != nil {
+		t.Fatalf("unexpected error while processing zone: %s", err)
+	}
+
+	za, _ := c.Last("test")
+	if za.Path != previous.Path {
+		t.Fatalf("expected archive to refer to %s, but got %s", previous.Path, za.Path)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error while reading directory: %s", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected %d archive, but got %d", 1, len(files))
+	}
+}
+
+func TestProcessModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zones")
+	if err != nil {
+		t.Fatalf("unexpected error while creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	prevContent := `test.	3600	IN	SOA	ns.test. admin.test. 2021020100 3600 900 604800 86400
+example.test.	3600	IN	NS	ns.example.test.
+expired.test.	3600	IN	NS	ns.expired.test.
+`
+	prevPath := dir + "/test.2000-01-01.gz"
+	if _, _, err := archive(writeTemp(t, dir, prevContent), prevPath); err != nil {
+		t.Fatalf("unexpected error while creating previous archive: %s", err)
+	}
+
+	content := testZoneFile + "registered.test.	3600	IN	NS	ns.registered.test.\n"
+
+	tests := []struct {
+		mode               Mode
+		expectedDomains    []string
+		expectedRegistered []string
+		expectedExpired    []string
+	}{
+		{
+			mode: ModeArchive,
+		},
+		{
+			mode:            ModeStream,
+			expectedDomains: []string{"example.test", "registered.test"},
+		},
+		{
+			mode:               ModeDiff,
+			expectedRegistered: []string{"registered.test"},
+			expectedExpired:    []string{"expired.test"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.mode), func(t *testing.T) {
+			var domains, registered, expired []string
+			opts := ProcessOpts{
+				Mode: test.mode,
+				DomainFn: func(domain []byte) error {
+					domains = append(domains, string(domain))
+					return nil
+				},
+				DiffFn: func(domain []byte, reg bool) error {
+					if reg {
+						registered = append(registered, string(domain))
+					} else {
+						expired = append(expired, string(domain))
+					}
+					return nil
+				},
+				StreamHandler: ZoneFileHandler,
+				TargetDir:     dir,
+			}
+			z := stringZone{content: content}
+			if err := Process(&z, opts); err != nil {
+				t.Fatalf("unexpected error while processing zone: %s", err)
+			}
+
+			if !reflect.DeepEqual(domains, test.expectedDomains) {
+				t.Fatalf("expected domains %v, but got %v", test.expectedDomains, domains)
+			}
+			if !reflect.DeepEqual(registered, test.expectedRegistered) {
+				t.Fatalf("expected registered domains %v, but got %v", test.expectedRegistered, registered)
+			}
+			if !reflect.DeepEqual(expired, test.expectedExpired) {
+				t.Fatalf("expected expired domains %v, but got %v", test.expectedExpired, expired)
+			}
+
+			// the archive must contain the entire zone file, regardless of the mode
+			archived, err := archivedDomains(dir+"/test."+time.Now().Format("2006-01-02")+".gz", ZoneFileHandler)
+			if err != nil {
+				t.Fatalf("unexpected error while reading archive: %s", err)
+			}
+			if len(archived) != 2 {
+				t.Fatalf("expected %d domains in archive, but got %d", 2, len(archived))
+			}
+		})
+	}
+}
+
+// writes content to a temporary file in dir and returns its path
+func writeTemp(t *testing.T, dir, content string) string {
+	f, err := ioutil.TempFile(dir, "tmp")
+	if err != nil {
+		t.Fatalf("unexpected error while creating temporary file: %s", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("unexpected error while writing temporary file: %s", err)
+	}
+	return f.Name()
+}
+
+type diffZone struct {
+	stringZone
+	registered, expired []string
+	err                 error
+	serial              uint32
+	streamed            bool
+}
+
+func (z *diffZone) Stream() (io.ReadCloser, error) {
+	z.streamed = true
+	return z.stringZone.Stream()
+}
+
+func (z *diffZone) Diff(serial uint32) ([]string, []string, uint32, error) {
+	z.serial = serial
+	return z.registered, z.expired, serial + 1, z.err
+}
+
+func TestProcessIncrementalDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zones")
+	if err != nil {
+		t.Fatalf("unexpected error while creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	prevPath := dir + "/test.2000-01-01.gz"
+	if _, _, err := archive(writeTemp(t, dir, testZoneFile), prevPath); err != nil {
+		t.Fatalf("unexpected error while creating previous archive: %s", err)
+	}
+	content := testZoneFile + "registered.test.	3600	IN	NS	ns.registered.test.\n"
+
+	tests := []struct {
+		name               string
+		last               *models.ZoneArchive
+		diffErr            error
+		expectedSerial     uint32
+		expectedRegistered []string
+		expectedStreamed   bool
+	}{
+		{
+			name:               "incremental",
+			last:               &models.ZoneArchive{Tld: "test", Path: prevPath, SoaSerial: 2021020100, Valid: true},
+			expectedSerial:     2021020100,
+			expectedRegistered: []string{"incremental.test"},
+		},
+		{
+			name:               "fallback on error",
+			last:               &models.ZoneArchive{Tld: "test", Path: prevPath, SoaSerial: 2021020100, Valid: true},
+			diffErr:            errors.New("unavailable"),
+			expectedSerial:     2021020100,
+			expectedRegistered: []string{"registered.test"},
+			expectedStreamed:   true,
+		},
+		{
+			name:               "fallback without serial",
+			expectedRegistered: []string{"registered.test"},
+			expectedStreamed:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := memCatalogue{}
+			if test.last != nil {
+				c.Add(test.last)
+			}
+
+			var registered []string
+			opts := ProcessOpts{
+				Mode: ModeDiff,
+				DomainFn: func(domain []byte) error {
+					return nil
+				},
+				DiffFn: func(domain []byte, reg bool) error {
+					if reg {
+						registered = append(registered, string(domain))
+					}
+					return nil
+				},
+				StreamHandler: ZoneFileHandler,
+				TargetDir:     dir,
+				Catalogue:     &c,
+			}
+			z := diffZone{
+				stringZone: stringZone{content: content},
+				registered: []string{"incremental.test"},
+				err:        test.diffErr,
+			}
+			if err := Process(&z, opts); err != nil {
+				t.Fatalf("unexpected error while processing zone: %s", err)
+			}
+			if z.serial != test.expectedSerial {
+				t.Fatalf("expected diff since serial %d, but got %d", test.expectedSerial, z.serial)
+			}
+			if !reflect.DeepEqual(registered, test.expectedRegistered) {
+				t.Fatalf("expected registered domains %v, but got %v", test.expectedRegistered, registered)
+			}
+			if z.streamed != test.expectedStreamed {
+				t.Fatalf("expected full transfer to be %t, but got %t", test.expectedStreamed, z.streamed)
+			}
+			if !test.expectedStreamed {
+				last, _ := c.Last("test")
+				if !last.Incremental || last.SoaSerial != test.expectedSerial+1 || last.Path != prevPath {
+					t.Fatalf("expected incremental catalogue entry with serial %d, but got %+v", test.expectedSerial+1, last)
+				}
+			}
+		})
+	}
+}
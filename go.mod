@@ -14,6 +14,7 @@ require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/emicklei/proto v1.6.13 // indirect
 	github.com/emirpasic/gods v1.12.0 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/getsentry/sentry-go v0.3.0
 	github.com/gliderlabs/ssh v0.1.4 // indirect
 	github.com/go-acme/lego v2.7.2+incompatible
@@ -30,6 +31,7 @@ require (
 	github.com/google/licenseclassifier v0.0.0-20190501212618-47b603fe1b8c // indirect
 	github.com/google/monologue v0.0.0-20190606152607-4b11a32b5934 // indirect
 	github.com/google/pprof v0.0.0-20200604032702-163a225fb653 // indirect
+	github.com/google/trillian v1.3.14-0.20210302110132-074ffbb0c117
 	github.com/google/trillian-examples v0.0.0-20190603134952-4e75ba15216c // indirect
 	github.com/google/uuid v1.1.2
 	github.com/gostaticanalysis/analysisutil v0.0.0-20190329151158-56bca42c7635 // indirect
@@ -38,6 +40,7 @@ require (
 	github.com/influxdata/influxdb-client-go/v2 v2.2.0
 	github.com/jinzhu/gorm v1.9.11
 	github.com/jlaffaye/ftp v0.0.0-20190828173736-6aaa91c7796e
+	github.com/klauspost/compress v1.11.7
 	github.com/letsencrypt/pkcs11key v2.0.1-0.20170608213348-396559074696+incompatible // indirect
 	github.com/lib/pq v1.9.0
 	github.com/lyft/protoc-gen-validate v0.0.14 // indirect
@@ -49,19 +52,23 @@ require (
 	github.com/pelletier/go-toml v1.4.0 // indirect
 	github.com/pingcap/errors v0.11.1
 	github.com/pkg/errors v0.9.1
+	github.com/pkg/sftp v1.12.0
 	github.com/rogpeppe/go-internal v1.3.2 // indirect
 	github.com/rs/zerolog v1.15.0
 	github.com/shurcooL/go v0.0.0-20190330031554-6713ea532688 // indirect
 	github.com/spf13/afero v1.2.2 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/uber/prototool v1.8.1 // indirect
+	github.com/ulikunitz/xz v0.5.7
 	github.com/vbauerster/mpb/v4 v4.10.1
 	github.com/weppos/publicsuffix-go v0.10.0
 	github.com/xanzy/ssh-agent v0.2.1 // indirect
 	go.etcd.io/etcd v3.3.13+incompatible // indirect
 	golang.org/x/crypto v0.0.0-20201012173705-84dcc777aaee
+	golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb
 	golang.org/x/sync v0.0.0-20200625203802-6e8e738ad208
 	golang.org/x/text v0.3.3
+	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e
 	google.golang.org/grpc v1.36.0
 	google.golang.org/grpc/cmd/protoc-gen-go-grpc v1.1.0 // indirect
 	google.golang.org/protobuf v1.25.0
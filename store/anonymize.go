@@ -0,0 +1,195 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/aau-network-security/gollector/store/models"
+	"github.com/go-pg/pg"
+)
+
+// the minimum size of the secret of an anonymization key in bytes
+const MinSecretSize = 16
+
+// the size of an anonymized label of a prefix-preserving anonymizer in bytes
+const prefixLabelSize = 16
+
+var (
+	ShortSecretErr             = errors.New("secret of anonymization key is too short")
+	NotReversibleErr           = errors.New("anonymizer is not reversible")
+	InvalidAnonymizedLabelErr  = errors.New("invalid anonymized label")
+	UnknownAnonymizedFqdnErr   = errors.New("unknown anonymized fqdn")
+	NotDeanonymizableErr       = errors.New("anonymized fqdn cannot be de-anonymized")
+	UnknownAnonymizationKeyErr = errors.New("unknown anonymization key")
+)
+
+// a label anonymizer of which the key is stored with the labels it anonymizes, such that keys can be rotated
+type KeyedLabelAnonymizer interface {
+	LabelAnonymizer
+	KeyId() string
+}
+
+// returns the ID of the key of a label anonymizer, which is empty for anonymizers without a key
+func keyId(la LabelAnonymizer) string {
+	if kla, ok := la.(KeyedLabelAnonymizer); ok {
+		return kla.KeyId()
+	}
+	return ""
+}
+
+type AnonymizationKey struct {
+	Id     string
+	Secret []byte
+}
+
+type HmacOpts struct {
+	// labels are anonymized separately, such that anonymized domain names share the anonymized labels of their parent
+	PrefixPreserving bool
+	// labels are encrypted deterministically, such that they can be de-anonymized with the key
+	Reversible bool
+}
+
+// anonymizes labels with HMAC-SHA256, or with AES-GCM of which the nonce is the HMAC of the label if reversible
+type HmacLabelAnonymizer struct {
+	id   string
+	mac  []byte
+	aead cipher.AEAD // nil, unless the anonymizer is reversible
+	opts HmacOpts
+}
+
+// derives a key for a single purpose from the secret of an anonymization key
+func deriveKey(secret []byte, purpose string) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(purpose))
+	return m.Sum(nil)
+}
+
+func NewHmacLabelAnonymizer(key AnonymizationKey, opts HmacOpts) (*HmacLabelAnonymizer, error) {
+	if len(key.Secret) < MinSecretSize {
+		return nil, ShortSecretErr
+	}
+	la := HmacLabelAnonymizer{
+		id:   key.Id,
+		mac:  deriveKey(key.Secret, "mac"),
+		opts: opts,
+	}
+	if opts.Reversible {
+		block, err := aes.NewCipher(deriveKey(key.Secret, "enc"))
+		if err != nil {
+			return nil, err
+		}
+		la.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &la, nil
+}
+
+func (la *HmacLabelAnonymizer) KeyId() string {
+	return la.id
+}
+
+// anonymizes a label, given its anonymized parent
+func (la *HmacLabelAnonymizer) anonymize(label, parent string) string {
+	m := hmac.New(sha256.New, la.mac)
+	m.Write([]byte(parent))
+	m.Write([]byte{0})
+	m.Write([]byte(label))
+	sum := m.Sum(nil)
+
+	if la.aead != nil {
+		nonce := sum[:la.aead.NonceSize()]
+		return hex.EncodeToString(la.aead.Seal(nonce, nonce, []byte(label), []byte(parent)))
+	}
+	if la.opts.PrefixPreserving {
+		return hex.EncodeToString(sum[:prefixLabelSize])
+	}
+	return hex.EncodeToString(sum)
+}
+
+func (la *HmacLabelAnonymizer) AnonymizeLabel(s string) string {
+	if !la.opts.PrefixPreserving {
+		return la.anonymize(s, "")
+	}
+	labels := strings.Split(s, ".")
+	res := la.anonymize(labels[len(labels)-1], "")
+	for i := len(labels) - 2; i >= 0; i-- {
+		res = la.anonymize(labels[i], res) + "." + res
+	}
+	return res
+}
+
+// decrypts an anonymized label, given its anonymized parent
+func (la *HmacLabelAnonymizer) deanonymize(s, parent string) (string, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) < la.aead.NonceSize() {
+		return "", InvalidAnonymizedLabelErr
+	}
+	n := la.aead.NonceSize()
+	res, err := la.aead.Open(nil, raw[:n], raw[n:], []byte(parent))
+	if err != nil {
+		return "", InvalidAnonymizedLabelErr
+	}
+	return string(res), nil
+}
+
+// returns the label that has been anonymized reversibly by this anonymizer
+func (la *HmacLabelAnonymizer) Deanonymize(s string) (string, error) {
+	if la.aead == nil {
+		return "", NotReversibleErr
+	}
+	if !la.opts.PrefixPreserving {
+		return la.deanonymize(s, "")
+	}
+	labels := strings.Split(s, ".")
+	res := make([]string, len(labels))
+	for i := range labels {
+		l, err := la.deanonymize(labels[i], strings.Join(labels[i+1:], "."))
+		if err != nil {
+			return "", err
+		}
+		res[i] = l
+	}
+	return strings.Join(res, "."), nil
+}
+
+// the anonymizers of all keys by which domain names have been anonymized, by key ID
+type Keyring map[string]*HmacLabelAnonymizer
+
+// returns the FQDN of an anonymized FQDN, which is either the unanonymized FQDN it is related to, or is decrypted with
+// the key by which it has been anonymized
+func (s *Store) DeanonymizeFqdn(anon string) (string, error) {
+	var fqdnAnon models.FqdnAnon
+	if err := s.db.Model(&fqdnAnon).Where("fqdn = ?", anon).First(); err == pg.ErrNoRows {
+		return "", UnknownAnonymizedFqdnErr
+	} else if err != nil {
+		return "", err
+	}
+
+	if fqdnAnon.FqdnID != 0 {
+		var fqdn models.Fqdn
+		if err := s.db.Model(&fqdn).Where("id = ?", fqdnAnon.FqdnID).First(); err != nil {
+			return "", err
+		}
+		return fqdn.Fqdn, nil
+	}
+
+	if fqdnAnon.KeyId == "" {
+		return "", NotDeanonymizableErr
+	}
+	la, ok := s.keyring[fqdnAnon.KeyId]
+	if !ok {
+		return "", UnknownAnonymizationKeyErr
+	}
+	res, err := la.Deanonymize(anon)
+	if err == NotReversibleErr {
+		return "", NotDeanonymizableErr
+	}
+	return res, err
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aau-network-security/gollector/store/models"
+)
+
+func newTestHmacAnonymizer(t *testing.T, id, secret string, opts HmacOpts) *HmacLabelAnonymizer {
+	key := AnonymizationKey{
+		Id:     id,
+		Secret: []byte(secret),
+	}
+	la, err := NewHmacLabelAnonymizer(key, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return la
+}
+
+func TestHmacLabelAnonymizer(t *testing.T) {
+	tests := []struct {
+		name string
+		opts HmacOpts
+	}{
+		{"hmac", HmacOpts{}},
+		{"prefix-preserving", HmacOpts{PrefixPreserving: true}},
+		{"reversible", HmacOpts{Reversible: true}},
+		{"prefix-preserving and reversible", HmacOpts{PrefixPreserving: true, Reversible: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			la := newTestHmacAnonymizer(t, "k1", "0123456789abcdef", test.opts)
+			other := newTestHmacAnonymizer(t, "k2", "fedcba9876543210", test.opts)
+
+			fqdn := "www.example.co.uk"
+			anon := la.AnonymizeLabel(fqdn)
+			if anon == fqdn || strings.Contains(anon, "example") {
+				t.Fatalf("expected fqdn to be anonymized, but got '%s'", anon)
+			}
+			if la.AnonymizeLabel(fqdn) != anon {
+				t.Fatalf("expected anonymization to be deterministic")
+			}
+			if other.AnonymizeLabel(fqdn) == anon {
+				t.Fatalf("expected anonymization to depend on the key")
+			}
+
+			parent := la.AnonymizeLabel("example.co.uk")
+			if test.opts.PrefixPreserving != strings.HasSuffix(anon, "."+parent) {
+				t.Fatalf("expected sharing of parent labels to be %t, but got '%s' and '%s'", test.opts.PrefixPreserving, anon, parent)
+			}
+			if test.opts.PrefixPreserving && strings.Count(anon, ".") != 3 {
+				t.Fatalf("expected anonymized fqdn to have 4 labels, but got '%s'", anon)
+			}
+
+			actual, err := la.Deanonymize(anon)
+			if !test.opts.Reversible {
+				if err != NotReversibleErr {
+					t.Fatalf("expected error '%s', but got %v", NotReversibleErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != fqdn {
+				t.Fatalf("expected '%s', but got '%s'", fqdn, actual)
+			}
+			if _, err := other.Deanonymize(anon); err != InvalidAnonymizedLabelErr {
+				t.Fatalf("expected error '%s' for other key, but got %v", InvalidAnonymizedLabelErr, err)
+			}
+		})
+	}
+}
+
+func TestHmacLabelAnonymizerShortSecret(t *testing.T) {
+	key := AnonymizationKey{
+		Id:     "k1",
+		Secret: []byte("short"),
+	}
+	if _, err := NewHmacLabelAnonymizer(key, HmacOpts{}); err != ShortSecretErr {
+		t.Fatalf("expected error '%s', but got %v", ShortSecretErr, err)
+	}
+}
+
+func TestAnonymizerKeyId(t *testing.T) {
+	la := newTestHmacAnonymizer(t, "k1", "0123456789abcdef", HmacOpts{})
+	a := NewAnonymizer(la, la, la, &DefaultLabelAnonymizer{})
+	d, err := NewDomain("www.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	a.Anonymize(d)
+	if d.apex.keyId != "k1" {
+		t.Fatalf("expected key id '%s', but got '%s'", "k1", d.apex.keyId)
+	}
+	if d.fqdn.keyId != "" {
+		t.Fatalf("expected no key id, but got '%s'", d.fqdn.keyId)
+	}
+}
+
+func TestDeanonymizeFqdn(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+	old := newTestHmacAnonymizer(t, "k1", "0123456789abcdef", HmacOpts{Reversible: true})
+	la := newTestHmacAnonymizer(t, "k2", "fedcba9876543210", HmacOpts{PrefixPreserving: true, Reversible: true})
+	s = s.WithAnonymizer(NewAnonymizer(old, old, old, old)).WithKeyring(Keyring{"k1": old, "k2": la})
+
+	ts := time.Now()
+	if err := s.StoreEntradaEntry(muid, "www.example.org", ts, ts); err != nil {
+		t.Fatalf("failed to store ENTRADA entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+
+	// rotate the key
+	s = s.WithAnonymizer(NewAnonymizer(la, la, la, la))
+	if err := s.StoreEntradaEntry(muid, "mail.example.org", ts, ts); err != nil {
+		t.Fatalf("failed to store ENTRADA entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+
+	var fqdns []models.FqdnAnon
+	if err := g.Find(&fqdns).Error; err != nil {
+		t.Fatalf("failed to retrieve anonymized fqdns: %s", err)
+	}
+	if len(fqdns) != 2 {
+		t.Fatalf("expected %d anonymized fqdns, but got %d", 2, len(fqdns))
+	}
+	expected := map[string]string{
+		"k1": "www.example.org",
+		"k2": "mail.example.org",
+	}
+	for _, fa := range fqdns {
+		fqdn, err := s.DeanonymizeFqdn(fa.Fqdn.Fqdn)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if fqdn != expected[fa.KeyId] {
+			t.Fatalf("expected '%s', but got '%s'", expected[fa.KeyId], fqdn)
+		}
+	}
+
+	if _, err := s.DeanonymizeFqdn("unknown"); err != UnknownAnonymizedFqdnErr {
+		t.Fatalf("expected error '%s', but got %v", UnknownAnonymizedFqdnErr, err)
+	}
+}
@@ -2,7 +2,9 @@ package store
 
 import (
 	"github.com/aau-network-security/gollector/store/models"
+	"github.com/aau-network-security/gollector/watchlist"
 	"github.com/pkg/errors"
+	"time"
 )
 
 type domainstruct struct {
@@ -48,17 +50,57 @@ type BatchEntities struct {
 	zoneEntries            []*zoneentrystruct
 	passiveEntries         []*passiveentrystruct
 	entradaEntries         []*entradaentrystruct
+	watchHits              []*models.WatchHit
+	watchlist              *watchlist.Watchlist
+	notifier               *watchlist.Notifier
 }
 
-func (be *BatchEntities) AddFqdn(domain *domain, anon bool) {
+// matches the domain against the watchlist, where hits of anonymized sources contain the anonymized FQDN
+func (be *BatchEntities) watch(domain *domain, anon bool, source string) {
+	if be.watchlist == nil {
+		return
+	}
+	for _, m := range be.watchlist.Match(domain.fqdn.normal, domain.apex.normal) {
+		h := watchlist.Hit{
+			Rule:       m.Rule,
+			Type:       m.Type,
+			Detail:     m.Detail,
+			Fqdn:       domain.fqdn.normal,
+			Source:     source,
+			Anonymized: anon,
+			Timestamp:  time.Now(),
+		}
+		if anon {
+			h.Fqdn = domain.fqdn.anon
+		}
+		be.watchHits = append(be.watchHits, &models.WatchHit{
+			Rule:       h.Rule,
+			RuleType:   string(h.Type),
+			Detail:     h.Detail,
+			Fqdn:       h.Fqdn,
+			Source:     h.Source,
+			Anonymized: h.Anonymized,
+			Timestamp:  h.Timestamp,
+		})
+		if be.notifier != nil {
+			be.notifier.Notify(h)
+		}
+	}
+}
+
+// adds the FQDN of a source to the batch according to the policy of the source, of which the first occurrence in the
+// batch is matched against the watchlist
+func (be *BatchEntities) AddFqdn(domain *domain, sp SourcePolicy, source string) {
+	plain, anon := sp.Fqdn.plain(), sp.Fqdn.anon()
 	existingFqdn, ok := be.fqdnByName[domain.fqdn.normal]
 	if ok {
-		existingFqdn.create = existingFqdn.create || !anon
+		existingFqdn.create = existingFqdn.create || plain
 		be.fqdnByName[domain.fqdn.normal] = existingFqdn
 	} else {
+		be.watch(domain, !plain, source)
 		be.fqdnByName[domain.fqdn.normal] = &domainstruct{
 			domain: domain,
-			create: !anon,
+			create: plain,
 		}
 	}
 
@@ -73,18 +115,25 @@ func (be *BatchEntities) AddFqdn(domain *domain, anon bool) {
 		}
 	}
 
-	be.AddApex(domain, anon)
+	// the FQDN has been matched against the watchlist already, which includes its apex
+	be.AddApex(domain, sp, "")
 }
 
-func (be *BatchEntities) AddApex(domain *domain, anon bool) {
+// adds the apex of a source to the batch according to the policy of the source. If a source is given, the first
+// occurrence of the apex in the batch is matched against the watchlist.
+func (be *BatchEntities) AddApex(domain *domain, sp SourcePolicy, source string) {
+	plain, anon := sp.Apex.plain(), sp.Apex.anon()
 	existingApex, ok := be.apexByName[domain.apex.normal]
 	if ok {
-		existingApex.create = existingApex.create || !anon
+		existingApex.create = existingApex.create || plain
 		be.apexByName[domain.apex.normal] = existingApex
 	} else {
+		if source != "" {
+			be.watch(domain, !plain, source)
+		}
 		be.apexByName[domain.apex.normal] = &domainstruct{
 			domain: domain,
-			create: !anon,
+			create: plain,
 		}
 	}
 
@@ -99,19 +148,20 @@ func (be *BatchEntities) AddApex(domain *domain, anon bool) {
 		}
 	}
 
-	be.AddPublicSuffix(domain, anon)
+	be.AddPublicSuffix(domain, sp)
 
 }
 
-func (be *BatchEntities) AddPublicSuffix(domain *domain, anon bool) {
+func (be *BatchEntities) AddPublicSuffix(domain *domain, sp SourcePolicy) {
+	plain, anon := sp.PublicSuffix.plain(), sp.PublicSuffix.anon()
 	existingPublicSuffix, ok := be.publicSuffixByName[domain.publicSuffix.normal]
 	if ok {
-		existingPublicSuffix.create = existingPublicSuffix.create || !anon
+		existingPublicSuffix.create = existingPublicSuffix.create || plain
 		be.publicSuffixByName[domain.publicSuffix.normal] = existingPublicSuffix
 	} else {
 		be.publicSuffixByName[domain.publicSuffix.normal] = &domainstruct{
 			domain: domain,
-			create: !anon,
+			create: plain,
 		}
 	}
 
@@ -126,19 +176,20 @@ func (be *BatchEntities) AddPublicSuffix(domain *domain, anon bool) {
 		}
 	}
 
-	be.AddTld(domain, anon)
+	be.AddTld(domain, sp)
 }
 
-func (be *BatchEntities) AddTld(domain *domain, anon bool) {
+func (be *BatchEntities) AddTld(domain *domain, sp SourcePolicy) {
+	plain, anon := sp.Tld.plain(), sp.Tld.anon()
 	existingTld, ok := be.tldByName[domain.tld.normal]
 	if ok {
 		// creation has precedence over not creating
-		existingTld.create = existingTld.create || !anon
+		existingTld.create = existingTld.create || plain
 		be.tldByName[domain.tld.normal] = existingTld
 	} else {
 		be.tldByName[domain.tld.normal] = &domainstruct{
 			domain: domain,
-			create: !anon,
+			create: plain,
 		}
 	}
 
@@ -176,6 +227,7 @@ func (be *BatchEntities) Reset() {
 	be.zoneEntries = []*zoneentrystruct{}
 	be.passiveEntries = []*passiveentrystruct{}
 	be.entradaEntries = []*entradaentrystruct{}
+	be.watchHits = []*models.WatchHit{}
 }
 
 func NewBatchEntities(size int) BatchEntities {
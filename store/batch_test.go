@@ -2,6 +2,8 @@ package store
 
 import (
 	"testing"
+
+	"github.com/aau-network-security/gollector/watchlist"
 )
 
 func TestBatchEntities(t *testing.T) {
@@ -31,3 +33,83 @@ func TestBatchEntities(t *testing.T) {
 		t.Fatalf("unexpected batch size: expected %d, but got %d", 0, be.Len())
 	}
 }
+
+func TestBatchEntitiesWatch(t *testing.T) {
+	w, err := watchlist.New([]watchlist.Rule{{Name: "r", Type: watchlist.TypeKeyword, Value: "example"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	be := NewBatchEntities(10)
+	be.watchlist = w
+
+	for _, fqdn := range []string{"www.example.com", "www.example.com", "other.org"} {
+		d, err := NewDomain(fqdn)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		be.AddFqdn(d, DefaultAnonymizationPolicy.Source("ct"), "ct")
+	}
+	if len(be.watchHits) != 1 {
+		t.Fatalf("expected %d hit, but got %d", 1, len(be.watchHits))
+	}
+	if be.watchHits[0].Fqdn != "www.example.com" || be.watchHits[0].Source != "ct" {
+		t.Fatalf("unexpected hit: %v", be.watchHits[0])
+	}
+
+	be.Reset()
+	if len(be.watchHits) != 0 {
+		t.Fatalf("expected hits to be reset, but got %d", len(be.watchHits))
+	}
+
+	// apexes of zone files are matched as well
+	for _, apex := range []string{"example.net", "example.net", "other.net"} {
+		d, err := NewDomain(apex)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		be.AddApex(d, DefaultAnonymizationPolicy.Source("zone"), "zone")
+	}
+	if len(be.watchHits) != 1 {
+		t.Fatalf("expected %d hit, but got %d", 1, len(be.watchHits))
+	}
+	if be.watchHits[0].Fqdn != "example.net" || be.watchHits[0].Source != "zone" {
+		t.Fatalf("unexpected hit: %v", be.watchHits[0])
+	}
+}
+
+func TestBatchEntitiesPolicy(t *testing.T) {
+	sp := SourcePolicy{Apex: ModeBoth, Fqdn: ModeAnonymize}.withDefaults()
+	be := NewBatchEntities(10)
+
+	d, err := NewDomain("www.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	DefaultAnonymizer.Anonymize(d)
+	be.AddFqdn(d, sp, "entrada")
+
+	tests := []struct {
+		name        string
+		m           map[string]*domainstruct
+		key         string
+		expectedNew bool
+	}{
+		{"fqdn", be.fqdnByName, "www.example.com", false},
+		{"fqdn anon", be.fqdnByNameAnon, d.fqdn.anon, true},
+		{"apex", be.apexByName, "example.com", true},
+		{"apex anon", be.apexByNameAnon, d.apex.anon, true},
+		{"tld", be.tldByName, "com", true},
+		{"tld anon", be.tldAnonByName, d.tld.anon, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, ok := test.m[test.key]
+			if !ok {
+				t.Fatalf("expected '%s' to be in the batch", test.key)
+			}
+			if str.create != test.expectedNew {
+				t.Fatalf("expected creation to be %t, but got %t", test.expectedNew, str.create)
+			}
+		})
+	}
+}
@@ -8,10 +8,17 @@ import (
 	"github.com/go-pg/pg"
 	"github.com/google/certificate-transparency-go/x509"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"net"
+	"sort"
+	"strings"
 	"time"
 )
 
-var cacheNotFull = errors.New("skip query to the DB cause cache not full")
+var (
+	cacheNotFull       = errors.New("skip query to the DB cause cache not full")
+	InvalidHostnameErr = errors.New("invalid hostname")
+)
 
 type LogEntry struct {
 	Cert      *x509.Certificate
@@ -21,6 +28,60 @@ type LogEntry struct {
 	Log       ct.Log
 }
 
+// a domain name for which a certificate is valid
+type certName struct {
+	name     string
+	wildcard bool
+}
+
+// parses a domain name of a certificate, of which a leading wildcard label is removed
+func parseCertName(s string) (certName, error) {
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+	cn := certName{}
+	if strings.HasPrefix(name, "*.") {
+		cn.wildcard = true
+		name = name[2:]
+	}
+	if name == "" || len(name) > 253 || net.ParseIP(name) != nil {
+		return cn, InvalidHostnameErr
+	}
+	for _, l := range strings.Split(name, ".") {
+		if l == "" || len(l) > 63 {
+			return cn, InvalidHostnameErr
+		}
+		for _, r := range l {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return cn, InvalidHostnameErr
+			}
+		}
+	}
+	cn.name = name
+	return cn, nil
+}
+
+// returns the domain names of the certificate, which are taken from the SANs and from the subject common name if it is
+// a hostname. Invalid names are skipped.
+func certNames(c *x509.Certificate) []certName {
+	var res []certName
+	seen := make(map[certName]bool)
+	add := func(s string) {
+		cn, err := parseCertName(s)
+		if err != nil || seen[cn] {
+			return
+		}
+		seen[cn] = true
+		res = append(res, cn)
+	}
+	for _, d := range c.DNSNames {
+		add(d)
+	}
+	// a common name is only a hostname when it consists of multiple labels
+	if strings.Contains(c.Subject.CommonName, ".") {
+		add(c.Subject.CommonName)
+	}
+	return res
+}
+
 func (s *Store) getLogFromCacheOrDB(log ct.Log) (*models.Log, error) {
 	//Check if it is in the cache
 	lI, ok := s.cache.logByUrl.Get(log.Url)
@@ -82,18 +143,37 @@ func (s *Store) StoreLogEntry(muid string, entry LogEntry) error {
 		sid:   sid,
 	}
 
-	for _, d := range entry.Cert.DNSNames {
-		domain, err := NewDomain(d)
+	for _, cn := range certNames(entry.Cert) {
+		domain, err := s.psl.NewDomain(cn.name)
 		if err != nil {
 			continue
 		}
 		s.anonymizer.Anonymize(domain)
 
-		s.batchEntities.AddFqdn(domain, false)
+		s.batchEntities.AddFqdn(domain, s.policy.Source("ct"), "ct")
 	}
 	return s.conditionalPostHooks()
 }
 
+// records the index of a log entry that could not be parsed as scanned, such that it is not retrieved again
+func (s *Store) StoreSkippedLogEntry(muid string, l ct.Log, index uint) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.ensureReady()
+
+	if _, ok := s.ms.SId(muid); !ok {
+		return NoActiveStageErr
+	}
+
+	lm, err := s.getOrCreateLog(l)
+	if err != nil {
+		return err
+	}
+	s.inserts.skippedLogIdx[lm.ID] = append(s.inserts.skippedLogIdx[lm.ID], index)
+	return nil
+}
+
 // collect ids for all observed certificates in the batch, either from the cache or the database
 func (s *Store) backpropCert() error {
 	if len(s.batchEntities.certByFingerprint) == 0 {
@@ -137,20 +217,116 @@ func (s *Store) backpropCert() error {
 	return nil
 }
 
+// returns the hash of the TBS certificate, from which the CT poison and SCT list extensions are removed, such that a
+// precertificate and its final certificate have the same hash
+func canonicalTbsHash(c *x509.Certificate) (string, error) {
+	tbs := c.RawTBSCertificate
+	for _, ext := range c.Extensions {
+		var err error
+		switch {
+		case ext.Id.Equal(x509.OIDExtensionCTPoison):
+			tbs, err = x509.RemoveCTPoison(tbs)
+		case ext.Id.Equal(x509.OIDExtensionCTSCT):
+			tbs, err = x509.RemoveSCTList(tbs)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(tbs)), nil
+}
+
+// creates a certificate model that contains the metadata of the parsed certificate
+func newCertificate(id uint, fingerprint string, c *x509.Certificate, isPrecert bool) *models.Certificate {
+	cert := &models.Certificate{
+		ID:                 id,
+		Sha256Fingerprint:  fingerprint,
+		Raw:                c.Raw,
+		IsPrecert:          isPrecert,
+		IssuerDN:           c.Issuer.String(),
+		IssuerOrganization: strings.Join(c.Issuer.Organization, ", "),
+		SubjectCN:          c.Subject.CommonName,
+		NotBefore:          c.NotBefore,
+		NotAfter:           c.NotAfter,
+		SignatureAlgorithm: c.SignatureAlgorithm.String(),
+		PublicKeyAlgorithm: c.PublicKeyAlgorithm.String(),
+		IpAddressCount:     len(c.IPAddresses),
+		EmailAddressCount:  len(c.EmailAddresses),
+	}
+	if c.SerialNumber != nil {
+		cert.SerialNumber = fmt.Sprintf("%x", c.SerialNumber)
+	}
+	tbsHash, err := canonicalTbsHash(c)
+	if err != nil {
+		log.Debug().Msgf("failed to compute canonical TBS hash of certificate %s: %s", fingerprint, err)
+	}
+	cert.TbsSha256 = tbsHash
+	return cert
+}
+
+// relates the new certificates to the precertificates and final certificates with the same canonical TBS hash, which
+// are either part of the batch or stored in the database
+func (s *Store) linkPrecerts(certs []*models.Certificate) error {
+	newByTbs := make(map[string][]*models.Certificate)
+	var hashes []string
+	for _, c := range certs {
+		if c.TbsSha256 == "" {
+			continue
+		}
+		if _, ok := newByTbs[c.TbsSha256]; !ok {
+			hashes = append(hashes, c.TbsSha256)
+		}
+		newByTbs[c.TbsSha256] = append(newByTbs[c.TbsSha256], c)
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	var existing []*models.Certificate
+	if err := s.db.Model(&existing).Column("id", "tbs_sha256", "is_precert").Where("tbs_sha256 in (?)", pg.In(hashes)).Select(); err != nil {
+		return err
+	}
+	existingByTbs := make(map[string][]*models.Certificate)
+	for _, c := range existing {
+		existingByTbs[c.TbsSha256] = append(existingByTbs[c.TbsSha256], c)
+	}
+
+	for tbs, newCerts := range newByTbs {
+		all := append(existingByTbs[tbs], newCerts...)
+		isNew := make(map[uint]bool)
+		for _, c := range newCerts {
+			isNew[c.ID] = true
+		}
+		for _, pre := range all {
+			if !pre.IsPrecert {
+				continue
+			}
+			for _, final := range all {
+				// pairs of existing certificates have been related before
+				if final.IsPrecert || (!isNew[pre.ID] && !isNew[final.ID]) {
+					continue
+				}
+				s.inserts.precertToCerts = append(s.inserts.precertToCerts, &models.PrecertToCert{
+					PrecertificateID: pre.ID,
+					CertificateID:    final.ID,
+				})
+			}
+		}
+	}
+	return nil
+}
+
 func (s *Store) forpropCerts() error {
+	var newCerts []*models.Certificate
 	for k, certstr := range s.batchEntities.certByFingerprint {
 
 		if certstr.cert == nil {
 			// get TLD name from domain object
-			cert := &models.Certificate{
-				ID:                s.ids.certs,
-				Sha256Fingerprint: k,
-				Raw:               certstr.entry.Cert.Raw,
-			}
+			cert := newCertificate(s.ids.certs, k, certstr.entry.Cert, certstr.entry.IsPrecert)
 
 			// create an association between FQDNs in database and the newly created certificate
-			for _, d := range certstr.entry.Cert.DNSNames {
-				domain, err := NewDomain(d)
+			for _, cn := range certNames(certstr.entry.Cert) {
+				domain, err := s.psl.NewDomain(cn.name)
 				if err != nil {
 					continue
 				}
@@ -160,6 +336,7 @@ func (s *Store) forpropCerts() error {
 				ctof := models.CertificateToFqdn{
 					CertificateID: cert.ID,
 					FqdnID:        fqdn.ID,
+					IsWildcard:    cn.wildcard,
 				}
 				s.inserts.certToFqdns = append(s.inserts.certToFqdns, &ctof)
 				s.ids.certsToFqdn++
@@ -167,6 +344,7 @@ func (s *Store) forpropCerts() error {
 
 			certstr.cert = cert
 			s.inserts.certs = append(s.inserts.certs, cert)
+			newCerts = append(newCerts, cert)
 			s.ids.certs++
 			s.cache.certByFingerprint.Add(k, cert)
 		}
@@ -187,5 +365,196 @@ func (s *Store) forpropCerts() error {
 
 		s.inserts.logEntries = append(s.inserts.logEntries, &le)
 	}
+	return s.linkPrecerts(newCerts)
+}
+
+type TreeHead struct {
+	Log          ct.Log
+	TreeSize     int64
+	Timestamp    time.Time
+	RootHash     []byte
+	Signature    []byte
+	Consistent   bool
+	Misbehaviour string
+}
+
+// stores a signed tree head of a log, and marks the log as misbehaving if the tree head shows misbehaviour
+func (s *Store) StoreTreeHead(th TreeHead) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.ensureReady()
+
+	l, err := s.getOrCreateLog(th.Log)
+	if err != nil {
+		return err
+	}
+
+	model := models.TreeHead{
+		LogID:        l.ID,
+		TreeSize:     th.TreeSize,
+		Timestamp:    th.Timestamp,
+		RootHash:     th.RootHash,
+		Signature:    th.Signature,
+		Consistent:   th.Consistent,
+		Misbehaviour: th.Misbehaviour,
+	}
+	if err := s.db.Insert(&model); err != nil {
+		return errors.Wrap(err, "insert tree head")
+	}
+
+	if th.Misbehaviour != "" && !l.Misbehaving {
+		l.Misbehaving = true
+		if _, err := s.db.Model(l).Column("misbehaving").WherePK().Update(); err != nil {
+			return errors.Wrap(err, "update log")
+		}
+	}
+	return nil
+}
+
+// returns the most recent tree head of a log that did not show misbehaviour, or nil if there is none
+func (s *Store) GetLastTreeHead(logUrl string) (*models.TreeHead, error) {
+	var l models.Log
+	if err := s.db.Model(&l).Where("url = ?", logUrl).First(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var th models.TreeHead
+	if err := s.db.Model(&th).Where("log_id = ? AND (misbehaviour = '' OR misbehaviour IS NULL)", l.ID).Order("tree_size DESC", "id DESC").Limit(1).Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &th, nil
+}
+
+// a range of log entries [Start, End)
+type IndexRange struct {
+	Start uint
+	End   uint
+}
+
+// returns the ranges of consecutive indices, where the indices are sorted in place
+func indexRanges(indices []uint) []IndexRange {
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i] < indices[j]
+	})
+	var res []IndexRange
+	for _, idx := range indices {
+		if n := len(res); n > 0 && idx <= res[n-1].End {
+			if idx == res[n-1].End {
+				res[n-1].End++
+			}
+			continue
+		}
+		res = append(res, IndexRange{Start: idx, End: idx + 1})
+	}
+	return res
+}
+
+// combines overlapping and adjacent ranges, and returns them in order
+func mergeRanges(ranges []IndexRange) []IndexRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	var res []IndexRange
+	for _, r := range ranges {
+		if n := len(res); n > 0 && r.Start <= res[n-1].End {
+			if r.End > res[n-1].End {
+				res[n-1].End = r.End
+			}
+			continue
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+// returns the gaps between the given ranges
+func missingRanges(ranges []IndexRange) []IndexRange {
+	var res []IndexRange
+	merged := mergeRanges(ranges)
+	for i := 1; i < len(merged); i++ {
+		res = append(res, IndexRange{
+			Start: merged[i-1].End,
+			End:   merged[i].Start,
+		})
+	}
+	return res
+}
+
+// records the indices of the log entries in the batch as scanned, including those that could not be parsed, by merging
+// them into the existing ranges of each log
+func (s *Store) updateScanRanges(tx *pg.Tx) error {
+	indicesByLog := make(map[uint][]uint)
+	for _, le := range s.inserts.logEntries {
+		indicesByLog[le.LogID] = append(indicesByLog[le.LogID], le.Index)
+	}
+	for logId, indices := range s.inserts.skippedLogIdx {
+		indicesByLog[logId] = append(indicesByLog[logId], indices...)
+	}
+
+	for logId, indices := range indicesByLog {
+		var existing []*models.LogScanRange
+		if err := tx.Model(&existing).Where("log_id = ?", logId).Select(); err != nil {
+			return errors.Wrap(err, "select scan ranges")
+		}
+		ranges := indexRanges(indices)
+		for _, r := range existing {
+			ranges = append(ranges, IndexRange{Start: r.StartIndex, End: r.EndIndex})
+		}
+		ranges = mergeRanges(ranges)
+
+		if len(existing) > 0 {
+			if _, err := tx.Model(&existing).WherePK().Delete(); err != nil {
+				return errors.Wrap(err, "delete scan ranges")
+			}
+		}
+		var updated []*models.LogScanRange
+		for _, r := range ranges {
+			updated = append(updated, &models.LogScanRange{
+				LogID:      logId,
+				StartIndex: r.Start,
+				EndIndex:   r.End,
+			})
+		}
+		if err := tx.Insert(&updated); err != nil {
+			return errors.Wrap(err, "insert scan ranges")
+		}
+	}
 	return nil
 }
+
+// returns the stored ranges of log entries of a log
+func (s *Store) getScanRanges(logUrl string) ([]IndexRange, error) {
+	var l models.Log
+	if err := s.db.Model(&l).Where("url = ?", logUrl).First(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ranges []*models.LogScanRange
+	if err := s.db.Model(&ranges).Where("log_id = ?", l.ID).Order("start_index ASC").Select(); err != nil {
+		return nil, err
+	}
+	var res []IndexRange
+	for _, r := range ranges {
+		res = append(res, IndexRange{Start: r.StartIndex, End: r.EndIndex})
+	}
+	return res, nil
+}
+
+// returns the ranges of log entries that lie between the stored ranges of a log, such as entries of batches that failed
+func (s *Store) GetMissingRanges(logUrl string) ([]IndexRange, error) {
+	ranges, err := s.getScanRanges(logUrl)
+	if err != nil {
+		return nil, err
+	}
+	return missingRanges(ranges), nil
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/go-pg/pg"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
-	"github.com/weppos/publicsuffix-go/net/publicsuffix"
+	"golang.org/x/net/idna"
 	"net"
 	"strings"
 )
@@ -59,6 +59,10 @@ func (a *Anonymizer) Anonymize(d *domain) {
 	d.publicSuffix.anon = a.psuffixAnonymizer.AnonymizeLabel(d.publicSuffix.normal)
 	d.apex.anon = a.apexAnonymizer.AnonymizeLabel(d.apex.normal)
 	d.fqdn.anon = a.fqdnAnonymizer.AnonymizeLabel(d.fqdn.normal)
+	d.tld.keyId = keyId(a.tldAnonymizer)
+	d.publicSuffix.keyId = keyId(a.psuffixAnonymizer)
+	d.apex.keyId = keyId(a.apexAnonymizer)
+	d.fqdn.keyId = keyId(a.fqdnAnonymizer)
 	d.anonymized = true
 }
 
@@ -68,12 +72,78 @@ func NewAnonymizer(tldAnonymizer, psuffixAnonymizer, apexAnonymizer, fqdnAnonymi
 	}
 }
 
+// validates labels according to IDNA2008, without the mapping of UTS #46, which would map distinct labels to the same
+// A-label (e.g. 'ß' to 'ss')
+var idnaProfile = idna.Registration
+
+// a label of a domain name that is not a valid IDNA2008 label
+type InvalidLabelErr struct {
+	Label string
+	Err   error
+}
+
+func (err *InvalidLabelErr) Error() string {
+	return fmt.Sprintf("invalid label '%s': %s", err.Label, err.Err)
+}
+
+func (err *InvalidLabelErr) Unwrap() error {
+	return err.Err
+}
+
+func isAscii(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// converts the labels of a domain name to A-labels. ASCII labels that are not A-labels are left as is, as these are
+// not restricted to letters, digits and hyphens in practice (e.g. wildcards and underscores).
+func toALabels(s string) (string, error) {
+	labels := strings.Split(s, ".")
+	for i, l := range labels {
+		if isAscii(l) && !strings.HasPrefix(l, "xn--") {
+			continue
+		}
+		a, err := idnaProfile.ToASCII(l)
+		if err != nil {
+			return "", &InvalidLabelErr{
+				Label: l,
+				Err:   err,
+			}
+		}
+		labels[i] = a
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// converts the A-labels of a domain name to U-labels
+func toULabels(s string) string {
+	labels := strings.Split(s, ".")
+	for i, l := range labels {
+		if !strings.HasPrefix(l, "xn--") {
+			continue
+		}
+		if u, err := idnaProfile.ToUnicode(l); err == nil {
+			labels[i] = u
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
 type label struct {
 	normal, anon string
+	unicode      string // the normal label with U-labels instead of A-labels
+	keyId        string // the ID of the key by which the label has been anonymized, if any
 }
 
 func newLabel(l string) label {
-	return label{normal: l}
+	return label{
+		normal:  l,
+		unicode: toULabels(l),
+	}
 }
 
 type domain struct {
@@ -81,7 +151,13 @@ type domain struct {
 	anonymized                    bool
 }
 
+// splits a domain name by the compiled-in public suffix list
 func NewDomain(fqdn string) (*domain, error) {
+	return DefaultPublicSuffixList.NewDomain(fqdn)
+}
+
+// splits a domain name into its TLD, public suffix and apex by the public suffix list
+func (l *PublicSuffixList) NewDomain(fqdn string) (*domain, error) {
 	fqdn = strings.TrimSuffix(fqdn, ".")
 	fqdn = strings.TrimPrefix(fqdn, ".")
 	fqdn = strings.ToLower(fqdn)
@@ -92,6 +168,11 @@ func NewDomain(fqdn string) (*domain, error) {
 		return nil, FqdnIsIpErr
 	}
 
+	fqdn, err := toALabels(fqdn)
+	if err != nil {
+		return nil, err
+	}
+
 	d := &domain{
 		fqdn: newLabel(fqdn),
 	}
@@ -107,7 +188,7 @@ func NewDomain(fqdn string) (*domain, error) {
 		return d, nil
 	}
 
-	apex, err := publicsuffix.EffectiveTLDPlusOne(fqdn)
+	apex, err := l.EffectiveTLDPlusOne(fqdn)
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "is a suffix") {
 			// domain is a public suffix
@@ -566,6 +647,7 @@ func (s *Store) forpropApex() {
 				TldID:          tld.ID,
 				PublicSuffixID: suffix.ID,
 				Apex:           k,
+				Unicode:        str.domain.apex.unicode,
 			}
 			str.obj = res
 			s.inserts.apexes[res.ID] = res
@@ -604,6 +686,7 @@ func (s *Store) forpropFqdn() {
 				PublicSuffixID: suffix.ID,
 				ApexID:         apex.ID,
 				Fqdn:           k,
+				Unicode:        str.domain.fqdn.unicode,
 			}
 			str.obj = res
 			s.inserts.fqdns = append(s.inserts.fqdns, res)
@@ -631,6 +714,7 @@ func (s *Store) forpropTldAnon() {
 					ID:  s.ids.tldsAnon,
 					Tld: k,
 				},
+				KeyId: str.domain.tld.keyId,
 			}
 
 			tldstr := s.batchEntities.tldByName[str.domain.tld.normal]
@@ -663,6 +747,7 @@ func (s *Store) forpropPublicSuffixAnon() {
 					TldID:        tldAnon.ID,
 					PublicSuffix: k,
 				},
+				KeyId: str.domain.publicSuffix.keyId,
 			}
 
 			// add foreign key to unanonymized public suffix
@@ -695,6 +780,7 @@ func (s *Store) forpropApexAnon() {
 					TldID:          suffixAnon.TldID,
 					PublicSuffixID: suffixAnon.ID,
 				},
+				KeyId: str.domain.apex.keyId,
 			}
 
 			// add foreign key to unanonymized apex
@@ -729,6 +815,7 @@ func (s *Store) forpropFqdnAnon() {
 					PublicSuffixID: apexAnon.PublicSuffixID,
 					ApexID:         apexAnon.ID,
 				},
+				KeyId: str.domain.fqdn.keyId,
 			}
 
 			// add foreign key to unanonymized fqdn
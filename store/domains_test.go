@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -111,6 +112,119 @@ func TestNewDomain(t *testing.T) {
 	}
 }
 
+func TestNewDomainIdn(t *testing.T) {
+	tests := []struct {
+		name        string
+		fqdn        string
+		apex        string
+		normal      string
+		unicode     string
+		apexUnicode string
+	}{
+		{
+			name:        "u-label from zone file",
+			fqdn:        "blåbær.dk",
+			apex:        "xn--blbr-roah.dk",
+			normal:      "xn--blbr-roah.dk",
+			unicode:     "blåbær.dk",
+			apexUnicode: "blåbær.dk",
+		},
+		{
+			name:        "a-label from certificate",
+			fqdn:        "www.xn--blbr-roah.dk",
+			apex:        "xn--blbr-roah.dk",
+			normal:      "www.xn--blbr-roah.dk",
+			unicode:     "www.blåbær.dk",
+			apexUnicode: "blåbær.dk",
+		},
+		{
+			name:        "upper case",
+			fqdn:        "WWW.BLÅBÆR.DK.",
+			apex:        "xn--blbr-roah.dk",
+			normal:      "www.xn--blbr-roah.dk",
+			unicode:     "www.blåbær.dk",
+			apexUnicode: "blåbær.dk",
+		},
+		{
+			name:        "upper case a-label",
+			fqdn:        "XN--BLBR-ROAH.DK",
+			apex:        "xn--blbr-roah.dk",
+			normal:      "xn--blbr-roah.dk",
+			unicode:     "blåbær.dk",
+			apexUnicode: "blåbær.dk",
+		},
+		{
+			name:        "no transitional mapping",
+			fqdn:        "straße.de",
+			apex:        "xn--strae-oqa.de",
+			normal:      "xn--strae-oqa.de",
+			unicode:     "straße.de",
+			apexUnicode: "straße.de",
+		},
+		{
+			name:        "idn public suffix",
+			fqdn:        "www.example.公司.cn",
+			apex:        "example.xn--55qx5d.cn",
+			normal:      "www.example.xn--55qx5d.cn",
+			unicode:     "www.example.公司.cn",
+			apexUnicode: "example.公司.cn",
+		},
+		{
+			name:        "non-ldh ascii labels",
+			fqdn:        "*._dmarc.blåbær.dk",
+			apex:        "xn--blbr-roah.dk",
+			normal:      "*._dmarc.xn--blbr-roah.dk",
+			unicode:     "*._dmarc.blåbær.dk",
+			apexUnicode: "blåbær.dk",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := NewDomain(test.fqdn)
+			if err != nil {
+				t.Fatalf("unexpected error while parsing fqdn: %s", err)
+			}
+			if actual.apex.normal != test.apex {
+				t.Fatalf("expected apex '%s', but got '%s'", test.apex, actual.apex.normal)
+			}
+			if actual.fqdn.normal != test.normal {
+				t.Fatalf("expected fqdn '%s', but got '%s'", test.normal, actual.fqdn.normal)
+			}
+			if actual.fqdn.unicode != test.unicode {
+				t.Fatalf("expected unicode fqdn '%s', but got '%s'", test.unicode, actual.fqdn.unicode)
+			}
+			if actual.apex.unicode != test.apexUnicode {
+				t.Fatalf("expected unicode apex '%s', but got '%s'", test.apexUnicode, actual.apex.unicode)
+			}
+		})
+	}
+}
+
+func TestNewDomainInvalidLabel(t *testing.T) {
+	tests := []struct {
+		name  string
+		fqdn  string
+		label string
+	}{
+		{"invalid punycode", "www.xn--a.dk", "xn--a"},
+		{"leading hyphen", "-blå.dk", "-blå"},
+		{"mixed direction", "aא.com", "aא"},
+		{"fullwidth", "ｅxample.com", "ｅxample"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewDomain(test.fqdn)
+			var labelErr *InvalidLabelErr
+			if !errors.As(err, &labelErr) {
+				t.Fatalf("expected invalid label error, but got %v", err)
+			}
+			if labelErr.Label != test.label {
+				t.Fatalf("expected invalid label '%s', but got '%s'", test.label, labelErr.Label)
+			}
+		})
+	}
+}
+
 func TestAnonymizer(t *testing.T) {
 	a := Anonymizer{
 		&TestLabelAnonymizer{},
@@ -21,13 +21,13 @@ func (s *Store) StoreEntradaEntry(muid string, fqdn string, tsMin time.Time, tsM
 
 	fqdn = strings.ToLower(fqdn)
 
-	domain, err := NewDomain(fqdn)
+	domain, err := s.psl.NewDomain(fqdn)
 	if err != nil {
 		return errors.Wrap(err, "failed to create domain")
 	}
 	s.anonymizer.Anonymize(domain)
 
-	s.batchEntities.AddFqdn(domain, true)
+	s.batchEntities.AddFqdn(domain, s.policy.Source("entrada"), "entrada")
 
 	ee := &entradaentrystruct{
 		ee: &models.EntradaEntry{
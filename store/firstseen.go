@@ -0,0 +1,49 @@
+package store
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+)
+
+// the time at which a source observed an apex first
+type FirstSeen struct {
+	Apex      string
+	Source    string
+	Timestamp time.Time
+}
+
+// returns the time at which each of the sources observed the apexes first. Anonymized sources are not included, as
+// their apexes cannot be compared. Zone entries without a timestamp are dated by the start of their stage.
+func (s *Store) GetFirstSeen(apexes []string) ([]FirstSeen, error) {
+	var res []FirstSeen
+	if len(apexes) == 0 {
+		return res, nil
+	}
+	qry := `
+SELECT a.apex, 'zone' AS source, min(coalesce(z.registered, st.start_time)) AS timestamp
+FROM apexes a
+JOIN zonefile_entries z ON z.apex_id = a.id
+JOIN stages st ON st.id = z.stage_id
+WHERE a.apex IN (?0)
+GROUP BY a.apex
+UNION ALL
+SELECT a.apex, 'ct' AS source, min(le.timestamp) AS timestamp
+FROM apexes a
+JOIN fqdns f ON f.apex_id = a.id
+JOIN certificate_to_fqdns cf ON cf.fqdn_id = f.id
+JOIN log_entries le ON le.certificate_id = cf.certificate_id
+WHERE a.apex IN (?0)
+GROUP BY a.apex
+UNION ALL
+SELECT a.apex, 'passive' AS source, min(p.timestamp) AS timestamp
+FROM apexes a
+JOIN fqdns f ON f.apex_id = a.id
+JOIN passive_entries p ON p.fqdn_id = f.id
+WHERE a.apex IN (?0)
+GROUP BY a.apex`
+	if _, err := s.db.Query(&res, qry, pg.In(apexes)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
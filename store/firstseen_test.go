@@ -0,0 +1,52 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/aau-network-security/gollector/api/proto"
+)
+
+func TestGetFirstSeen(t *testing.T) {
+	s, _, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+
+	first := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	if err := s.StoreZoneEntry(muid, second, "example.org", api.ZoneEntry_REGISTRATION); err != nil {
+		t.Fatalf("error while storing zone entry: %s", err)
+	}
+	if err := s.StoreZoneEntry(muid, first, "example.org", api.ZoneEntry_REGISTRATION); err != nil {
+		t.Fatalf("error while storing zone entry: %s", err)
+	}
+	if err := s.StorePassiveEntry(muid, "www.example.org", second); err != nil {
+		t.Fatalf("error while storing passive entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("error while running post hooks: %s", err)
+	}
+
+	res, err := s.GetFirstSeen([]string{"example.org", "other.org"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	bySource := make(map[string]FirstSeen)
+	for _, fs := range res {
+		if fs.Apex != "example.org" {
+			t.Fatalf("expected apex '%s', but got '%s'", "example.org", fs.Apex)
+		}
+		bySource[fs.Source] = fs
+	}
+	if len(bySource) != 2 {
+		t.Fatalf("expected %d sources, but got %d", 2, len(bySource))
+	}
+	if !bySource["zone"].Timestamp.Equal(first) {
+		t.Fatalf("expected zone timestamp %s, but got %s", first, bySource["zone"].Timestamp)
+	}
+	if !bySource["passive"].Timestamp.Equal(second) {
+		t.Fatalf("expected passive timestamp %s, but got %s", second, bySource["passive"].Timestamp)
+	}
+}
@@ -60,11 +60,14 @@ func (s *Store) StartMeasurement(description, host string) (string, error) {
 	tm := time.Now()
 	muid := newMuId()
 	measure := &models.Measurement{
-		Muid:        muid,
-		Description: description,
-		Host:        host,
-		StartTime:   tm,
-		Stage:       1,
+		Muid:         muid,
+		Description:  description,
+		Host:         host,
+		StartTime:    tm,
+		PslVersion:   s.psl.Version,
+		PslSha256:    s.psl.Sha256,
+		PslIcannOnly: s.psl.IcannOnly,
+		Stage:        1,
 	}
 
 	if err := tx.Insert(measure); err != nil {
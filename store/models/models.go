@@ -19,14 +19,16 @@ type PublicSuffix struct {
 
 type Apex struct {
 	ID             uint   `gorm:"primary_key" pg:",pk"`
-	Apex           string `gorm:"index"`
+	Apex           string `gorm:"index"` // with A-labels
+	Unicode        string // with U-labels, empty for anonymized apexes
 	TldID          uint
 	PublicSuffixID uint
 }
 
 type Fqdn struct {
 	ID             uint   `gorm:"primary_key" pg:",pk"`
-	Fqdn           string `gorm:"index"`
+	Fqdn           string `gorm:"index"` // with A-labels
+	Unicode        string // with U-labels, empty for anonymized FQDNs
 	TldID          uint
 	PublicSuffixID uint
 	ApexID         uint
@@ -36,6 +38,7 @@ type TldAnon struct {
 	Tld
 	tableName struct{} `sql:"tlds_anon"`
 	TldID     uint
+	KeyId     string // the ID of the key by which the TLD has been anonymized
 }
 
 func (TldAnon) TableName() string {
@@ -46,6 +49,7 @@ type PublicSuffixAnon struct {
 	PublicSuffix
 	tableName      struct{} `sql:"public_suffixes_anon"`
 	PublicSuffixID uint
+	KeyId          string // the ID of the key by which the public suffix has been anonymized
 }
 
 func (t PublicSuffixAnon) TableName() string {
@@ -56,6 +60,7 @@ type ApexAnon struct {
 	Apex
 	tableName struct{} `sql:"apexes_anon"`
 	ApexID    uint
+	KeyId     string // the ID of the key by which the apex has been anonymized
 }
 
 func (t ApexAnon) TableName() string {
@@ -66,6 +71,7 @@ type FqdnAnon struct {
 	Fqdn
 	tableName struct{} `sql:"fqdns_anon"`
 	FqdnID    uint
+	KeyId     string // the ID of the key by which the FQDN has been anonymized
 }
 
 func (t FqdnAnon) TableName() string {
@@ -76,13 +82,31 @@ func (t FqdnAnon) TableName() string {
 
 // ----- BEGIN ZONEFILE -----
 type ZonefileEntry struct {
-	ID         uint `gorm:"primary_key" pg:",pk"`
+	ID         uint      `gorm:"primary_key" pg:",pk"`
+	Timestamp  time.Time // the time of the observation, by which the table is partitioned
 	Registered time.Time
 	Expired    time.Time
 	ApexID     uint `gorm:"index"`
 	StageID    uint
 }
 
+// Catalogue entry of a zone file that has been archived to disk
+type ZoneArchive struct {
+	ID          uint   `gorm:"primary_key" pg:",pk"`
+	Tld         string `gorm:"index"`
+	Source      string
+	Date        time.Time
+	Path        string
+	Sha256      string
+	Size        int64
+	SoaSerial   uint32
+	RecordCount int64
+	Truncated   bool
+	Incremental bool // the changes were obtained by IXFR, so the entry refers to the previous archive
+	VerifiedAt  time.Time
+	Valid       bool
+}
+
 // ----- END ZONEFILE -----
 
 // ----- BEGIN CT -----
@@ -90,12 +114,32 @@ type CertificateToFqdn struct {
 	ID            uint `gorm:"primary_key" pg:",pk"`
 	FqdnID        uint
 	CertificateID uint
+	IsWildcard    bool // the certificate is valid for the subdomains of the FQDN
+}
+
+// relates a precertificate to the final certificate that was issued for it
+type PrecertToCert struct {
+	ID               uint `gorm:"primary_key" pg:",pk"`
+	PrecertificateID uint
+	CertificateID    uint
 }
 
 type Certificate struct {
-	ID                uint   `gorm:"primary_key" pg:",pk"`
-	Sha256Fingerprint string `gorm:"index"`
-	Raw               []byte `gorm:"type:bytea"`
+	ID                 uint   `gorm:"primary_key" pg:",pk"`
+	Sha256Fingerprint  string `gorm:"index"`
+	Raw                []byte `gorm:"type:bytea"`
+	TbsSha256          string `gorm:"index"` // hash of the TBS certificate without poison and SCT list extensions
+	IsPrecert          bool
+	IssuerDN           string
+	IssuerOrganization string `gorm:"index"`
+	SubjectCN          string
+	SerialNumber       string
+	NotBefore          time.Time
+	NotAfter           time.Time
+	SignatureAlgorithm string
+	PublicKeyAlgorithm string
+	IpAddressCount     int
+	EmailAddressCount  int
 }
 
 type LogEntry struct {
@@ -112,6 +156,26 @@ type Log struct {
 	ID          uint `gorm:"primary_key" pg:",pk"`
 	Url         string
 	Description string
+	Misbehaving bool
+}
+
+type TreeHead struct {
+	ID           uint `gorm:"primary_key" pg:",pk"`
+	LogID        uint `gorm:"index"`
+	TreeSize     int64
+	Timestamp    time.Time
+	RootHash     []byte `gorm:"type:bytea"`
+	Signature    []byte `gorm:"type:bytea"`
+	Consistent   bool
+	Misbehaviour string // empty, unless the tree head shows that the log misbehaved
+}
+
+// a range of log entries [StartIndex, EndIndex) that have been stored for a log
+type LogScanRange struct {
+	ID         uint `gorm:"primary_key" pg:",pk"`
+	LogID      uint `gorm:"index"`
+	StartIndex uint
+	EndIndex   uint
 }
 
 // ----- END CT -----
@@ -143,13 +207,16 @@ type RecordType struct {
 
 // Meta information for invidual measurements
 type Measurement struct {
-	ID          uint `gorm:"primary_key" pg:",pk"`
-	Muid        string
-	Description string
-	Host        string
-	StartTime   time.Time
-	EndTime     time.Time
-	Stage       uint `sql:"-"`
+	ID           uint `gorm:"primary_key" pg:",pk"`
+	Muid         string
+	Description  string
+	Host         string
+	StartTime    time.Time
+	EndTime      time.Time
+	PslVersion   string // version of the public suffix list by which domain names are split
+	PslSha256    string // empty for the compiled-in public suffix list
+	PslIcannOnly bool   // the private section of the public suffix list is ignored
+	Stage        uint   `sql:"-"`
 }
 
 // An individual measurement can repeat a single stage multiple times
@@ -162,3 +229,19 @@ type Stage struct {
 }
 
 // ----- END MEASUREMENT -----
+
+// ----- BEGIN WATCHLIST -----
+
+// an observation of a domain name that matches a rule of the watchlist
+type WatchHit struct {
+	ID         uint   `gorm:"primary_key" pg:",pk"`
+	Rule       string `gorm:"index"`
+	RuleType   string
+	Detail     string
+	Fqdn       string // anonymized if the source is anonymized
+	Source     string
+	Anonymized bool
+	Timestamp  time.Time
+}
+
+// ----- END WATCHLIST -----
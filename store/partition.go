@@ -0,0 +1,345 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	errs "github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// the number of months after the current month for which partitions are created in advance
+const DefaultPartitionsAhead = 3
+
+// a table of entries that is partitioned by month on a timestamp column
+type partitionedTable struct {
+	name   string
+	column string
+}
+
+var partitionedTables = []partitionedTable{
+	{"log_entries", "timestamp"},
+	{"passive_entries", "timestamp"},
+	{"entrada_entries", "last_seen"},
+	{"zonefile_entries", "timestamp"},
+}
+
+// returns the start of the month of a time in UTC
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func partitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_y%04dm%02d", table, month.Year(), month.Month())
+}
+
+// returns the month of a partition of a table by its name, unless it is not a monthly partition
+func partitionMonth(table, name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, table+"_y") {
+		return time.Time{}, false
+	}
+	var year, month int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(name, table+"_"), "y%4dm%2d", &year, &month); err != nil {
+		return time.Time{}, false
+	}
+	if month < 1 || month > 12 || partitionName(table, time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)) != name {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), true
+}
+
+// returns the kind of a relation in the current schema, which is 'p' for partitioned tables
+func relkind(db orm.DB, name string) (string, error) {
+	var kind string
+	qry := `
+SELECT c.relkind FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relname = ? AND n.nspname = current_schema()`
+	if _, err := db.QueryOne(pg.Scan(&kind), qry, name); err != nil {
+		return "", err
+	}
+	return kind, nil
+}
+
+// creates the partition of a table for a month, which is unlogged like the other tables of the store
+func createPartition(db orm.DB, pt partitionedTable, month time.Time) error {
+	qry := "CREATE UNLOGGED TABLE IF NOT EXISTS ?0 PARTITION OF ?1 FOR VALUES FROM (?2) TO (?3)"
+	_, err := db.Exec(qry, pg.F(partitionName(pt.name, month)), pg.F(pt.name), month, month.AddDate(0, 1, 0))
+	return err
+}
+
+// converts an unpartitioned table into a table that is partitioned by month, where the entries of the unpartitioned
+// table are moved to the partitions of their months
+func convertToPartitioned(tx *pg.Tx, pt partitionedTable) error {
+	// the indices are created again for the partitioned table, except for the primary key and other unique indices,
+	// which would have to include the partition column
+	var defs []string
+	qry := `
+SELECT indexdef FROM pg_indexes
+WHERE tablename = ? AND schemaname = current_schema() AND indexdef NOT LIKE 'CREATE UNIQUE INDEX%'`
+	if _, err := tx.Query(&defs, qry, pt.name); err != nil {
+		return errs.Wrap(err, "list index definitions")
+	}
+
+	old := pt.name + "_unpartitioned"
+	if _, err := tx.Exec("ALTER TABLE ?0 RENAME TO ?1", pg.F(pt.name), pg.F(old)); err != nil {
+		return errs.Wrap(err, "rename table")
+	}
+
+	// the indices of the unpartitioned table are renamed, such that they can be created again for the partitioned table
+	var indices []string
+	if _, err := tx.Query(&indices, "SELECT indexname FROM pg_indexes WHERE tablename = ? AND schemaname = current_schema()", old); err != nil {
+		return errs.Wrap(err, "list indices")
+	}
+	for _, idx := range indices {
+		if _, err := tx.Exec("ALTER INDEX ?0 RENAME TO ?1", pg.F(idx), pg.F(idx+"_unpartitioned")); err != nil {
+			return errs.Wrap(err, "rename index")
+		}
+	}
+
+	// the primary key of a partitioned table must include the partition column, which would forbid entries without a
+	// timestamp, so the partitioned table has no primary key
+	if _, err := tx.Exec("CREATE TABLE ?0 (LIKE ?1 INCLUDING DEFAULTS) PARTITION BY RANGE (?2)", pg.F(pt.name), pg.F(old), pg.F(pt.column)); err != nil {
+		return errs.Wrap(err, "create partitioned table")
+	}
+	for _, def := range defs {
+		if _, err := tx.Exec(def); err != nil {
+			return errs.Wrap(err, "create index")
+		}
+	}
+	var seq string
+	if _, err := tx.QueryOne(pg.Scan(&seq), "SELECT coalesce(pg_get_serial_sequence(?, 'id'), '')", old); err != nil {
+		return errs.Wrap(err, "get id sequence")
+	}
+	if seq != "" {
+		if _, err := tx.Exec("ALTER SEQUENCE ?0 OWNED BY ?1.id", pg.Q(seq), pg.F(pt.name)); err != nil {
+			return errs.Wrap(err, "move id sequence")
+		}
+	}
+
+	// entries without timestamp, or of months without partition, are stored in the default partition
+	if _, err := tx.Exec("CREATE UNLOGGED TABLE ?0 PARTITION OF ?1 DEFAULT", pg.F(pt.name+"_default"), pg.F(pt.name)); err != nil {
+		return errs.Wrap(err, "create default partition")
+	}
+	var months []time.Time
+	qry = "SELECT DISTINCT date_trunc('month', ?0 AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' FROM ?1 WHERE ?0 IS NOT NULL"
+	if _, err := tx.Query(&months, qry, pg.F(pt.column), pg.F(old)); err != nil {
+		return errs.Wrap(err, "list months")
+	}
+	for _, month := range months {
+		if err := createPartition(tx, pt, monthOf(month)); err != nil {
+			return errs.Wrap(err, "create partition")
+		}
+	}
+
+	if _, err := tx.Exec("INSERT INTO ?0 SELECT * FROM ?1", pg.F(pt.name), pg.F(old)); err != nil {
+		return errs.Wrap(err, "move entries")
+	}
+	if _, err := tx.Exec("DROP TABLE ?0", pg.F(old)); err != nil {
+		return errs.Wrap(err, "drop unpartitioned table")
+	}
+	return nil
+}
+
+// partitions a table in a single transaction
+func (s *Store) convertTable(pt partitionedTable) error {
+	log.Info().Msgf("partitioning %s, which may take a while..", pt.name)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := convertToPartitioned(tx, pt); err != nil {
+		tx.Rollback()
+		return errs.Wrapf(err, "partition %s", pt.name)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Info().Msgf("partitioning %s: done!", pt.name)
+	return nil
+}
+
+// loads the existing partitions of a partitioned table
+func (s *Store) loadPartitions(pt partitionedTable) error {
+	var names []string
+	qry := `
+SELECT c.relname FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+JOIN pg_class p ON p.oid = i.inhparent
+WHERE p.relname = ?`
+	if _, err := s.db.Query(&names, qry, pt.name); err != nil {
+		return errs.Wrapf(err, "list partitions of %s", pt.name)
+	}
+	for _, name := range names {
+		s.partitions[name] = true
+	}
+	s.partitioned[pt.name] = true
+	return nil
+}
+
+// loads the partitions of the tables of entries. Empty tables that are not partitioned yet, such as the tables of a
+// new database, are partitioned. Tables with entries of earlier versions are left unpartitioned, as partitioning
+// copies their entries, which is done by PartitionTables instead.
+func (s *Store) initPartitions() error {
+	for _, pt := range partitionedTables {
+		kind, err := relkind(s.db, pt.name)
+		if err != nil {
+			return errs.Wrapf(err, "get kind of %s", pt.name)
+		}
+		if kind != "p" {
+			var exists bool
+			if _, err := s.db.QueryOne(pg.Scan(&exists), "SELECT EXISTS (SELECT 1 FROM ?0)", pg.F(pt.name)); err != nil {
+				return errs.Wrapf(err, "check entries of %s", pt.name)
+			}
+			if exists {
+				log.Warn().Msgf("%s is not partitioned, which can be done by running the cache with --partition", pt.name)
+				continue
+			}
+			if err := s.convertTable(pt); err != nil {
+				return err
+			}
+		}
+		if err := s.loadPartitions(pt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partitions the tables of entries that are not partitioned yet by month, which copies their entries and may take a
+// long time. The partitioned tables have no primary key, as it would have to include the time of the entries.
+func (s *Store) PartitionTables() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for _, pt := range partitionedTables {
+		if s.partitioned[pt.name] {
+			continue
+		}
+		if err := s.convertTable(pt); err != nil {
+			return err
+		}
+		if err := s.loadPartitions(pt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// creates the partitions of the given months that do not exist yet, unless the table is not partitioned
+func (s *Store) ensurePartitions(pt partitionedTable, months map[time.Time]bool) error {
+	if !s.partitioned[pt.name] {
+		return nil
+	}
+	for month := range months {
+		name := partitionName(pt.name, month)
+		if s.partitions[name] {
+			continue
+		}
+		if err := createPartition(s.db, pt, month); err != nil {
+			return errs.Wrapf(err, "create partition %s", name)
+		}
+		s.partitions[name] = true
+		log.Info().Msgf("created partition %s", name)
+	}
+	return nil
+}
+
+// creates the partitions of the months of the entries in the batch, as entries may be observed long after they have
+// been created (e.g. when scanning a CT log from its start)
+func (s *Store) ensureBatchPartitions() {
+	months := make(map[string]map[time.Time]bool)
+	add := func(table string, t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		if months[table] == nil {
+			months[table] = make(map[time.Time]bool)
+		}
+		months[table][monthOf(t)] = true
+	}
+	for _, e := range s.inserts.logEntries {
+		add("log_entries", e.Timestamp)
+	}
+	for _, e := range s.inserts.passiveEntries {
+		add("passive_entries", e.Timestamp)
+	}
+	for _, e := range s.inserts.entradaEntries {
+		add("entrada_entries", e.LastSeen)
+	}
+	for _, e := range s.inserts.zoneEntries {
+		add("zonefile_entries", e.Timestamp)
+	}
+	for _, pt := range partitionedTables {
+		// entries are stored in the default partition if their partition cannot be created, which is the case if the
+		// default partition contains entries of its month already
+		if err := s.ensurePartitions(pt, months[pt.name]); err != nil {
+			log.Debug().Msgf("failed to create partition: %s", err)
+		}
+	}
+}
+
+// creates the partitions of the current month and the given number of months after it
+func (s *Store) CreateUpcomingPartitions(now time.Time, ahead int) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	months := make(map[time.Time]bool)
+	for i := 0; i <= ahead; i++ {
+		months[monthOf(now).AddDate(0, i, 0)] = true
+	}
+	for _, pt := range partitionedTables {
+		if err := s.ensurePartitions(pt, months); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// creates upcoming partitions daily until the returned function is called
+func (s *Store) SchedulePartitions(ahead int) func() {
+	return schedule(24*time.Hour, func() {
+		if err := s.CreateUpcomingPartitions(time.Now(), ahead); err != nil {
+			log.Error().Msgf("failed to create upcoming partitions: %s", err)
+		}
+	})
+}
+
+// returns the names of the partitions of a table of which the months end before the given time
+func (s *Store) expiredPartitions(table string, before time.Time) []string {
+	var res []string
+	for name := range s.partitions {
+		month, ok := partitionMonth(table, name)
+		if !ok || month.AddDate(0, 1, 0).After(before) {
+			continue
+		}
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// detaches the partitions of months that end before the given time, and returns their names. Detached partitions
+// remain as tables of their own, which can be archived (e.g. by pg_dump) and dropped.
+func (s *Store) DetachPartitions(before time.Time) ([]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var res []string
+	for _, pt := range partitionedTables {
+		for _, name := range s.expiredPartitions(pt.name, before) {
+			if _, err := s.db.Exec("ALTER TABLE ?0 DETACH PARTITION ?1", pg.F(pt.name), pg.F(name)); err != nil {
+				return res, errs.Wrapf(err, "detach partition %s", name)
+			}
+			delete(s.partitions, name)
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aau-network-security/gollector/store/models"
+)
+
+func TestPartitionMonth(t *testing.T) {
+	month := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	name := partitionName("log_entries", month)
+	if name != "log_entries_y2021m03" {
+		t.Fatalf("expected '%s', but got '%s'", "log_entries_y2021m03", name)
+	}
+
+	tests := []struct {
+		name     string
+		table    string
+		ok       bool
+		expected time.Time
+	}{
+		{"log_entries_y2021m03", "log_entries", true, month},
+		{"log_entries_default", "log_entries", false, time.Time{}},
+		{"log_entries_y2021m13", "log_entries", false, time.Time{}},
+		{"log_entries_y2021m03", "passive_entries", false, time.Time{}},
+		{"log_entries_y2021m03_unpartitioned", "log_entries", false, time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok := partitionMonth(test.table, test.name)
+			if ok != test.ok {
+				t.Fatalf("expected %t, but got %t", test.ok, ok)
+			}
+			if !actual.Equal(test.expected) {
+				t.Fatalf("expected %s, but got %s", test.expected, actual)
+			}
+		})
+	}
+
+	ts := time.Date(2021, 3, 31, 23, 0, 0, 0, time.FixedZone("", -2*60*60))
+	if actual := monthOf(ts); !actual.Equal(time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected month in UTC, but got %s", actual)
+	}
+}
+
+func TestPartitions(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+
+	old := time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	for _, ts := range []time.Time{old, now} {
+		if err := s.StorePassiveEntry(muid, "www.example.org", ts); err != nil {
+			t.Fatalf("failed to store passive entry: %s", err)
+		}
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+	if err := s.CreateUpcomingPartitions(now, 1); err != nil {
+		t.Fatalf("failed to create upcoming partitions: %s", err)
+	}
+
+	for _, table := range partitionedTables {
+		kind, err := relkind(s.db, table.name)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if kind != "p" {
+			t.Fatalf("expected %s to be partitioned, but got kind '%s'", table.name, kind)
+		}
+	}
+
+	expected := []string{
+		"passive_entries_y2020m01",
+		partitionName("passive_entries", monthOf(now)),
+		partitionName("passive_entries", monthOf(now).AddDate(0, 1, 0)),
+	}
+	for _, name := range expected {
+		if !s.partitions[name] {
+			t.Fatalf("expected partition '%s' to exist", name)
+		}
+	}
+
+	detached, err := s.DetachPartitions(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed to detach partitions: %s", err)
+	}
+	defer g.Exec("DROP TABLE IF EXISTS passive_entries_y2020m01")
+	if len(detached) != 1 || detached[0] != "passive_entries_y2020m01" {
+		t.Fatalf("unexpected detached partitions: %v", detached)
+	}
+
+	var count uint
+	if err := g.Model(&models.PassiveEntry{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to retrieve model count: %s", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected %d passive entry, but got %d", 1, count)
+	}
+}
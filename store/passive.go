@@ -21,13 +21,13 @@ func (s *Store) StorePassiveEntry(muid string, query string, t time.Time) error
 	}
 
 	query = strings.ToLower(query)
-	domain, err := NewDomain(query)
+	domain, err := s.psl.NewDomain(query)
 	if err != nil {
 		return errors.Wrap(err, "failed to create domain")
 	}
 	s.anonymizer.Anonymize(domain)
 
-	s.batchEntities.AddFqdn(domain, false)
+	s.batchEntities.AddFqdn(domain, s.policy.Source("passive"), "passive")
 
 	pe := &passiveentrystruct{
 		pe: &models.PassiveEntry{
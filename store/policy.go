@@ -0,0 +1,135 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	UnknownSourceErr            = errors.New("unknown source")
+	InvalidAnonymizationModeErr = errors.New("invalid anonymization mode")
+)
+
+// determines whether the names of a level of domain names are stored unanonymized, anonymized or both
+type AnonymizationMode string
+
+const (
+	ModePlain     AnonymizationMode = "plain"
+	ModeAnonymize AnonymizationMode = "anonymize"
+	ModeBoth      AnonymizationMode = "both"
+)
+
+func (m AnonymizationMode) plain() bool {
+	return m == ModePlain || m == ModeBoth
+}
+
+func (m AnonymizationMode) anon() bool {
+	return m == ModeAnonymize || m == ModeBoth
+}
+
+func (m AnonymizationMode) valid() bool {
+	return m == ModePlain || m == ModeAnonymize || m == ModeBoth
+}
+
+// the anonymization modes of the levels of the domain names of a source. Levels without a mode have the mode of the
+// level below, such that the mode of the FQDN applies to the entire domain name if no other mode is given.
+type SourcePolicy struct {
+	Tld          AnonymizationMode `yaml:"tld"`
+	PublicSuffix AnonymizationMode `yaml:"public-suffix"`
+	Apex         AnonymizationMode `yaml:"apex"`
+	Fqdn         AnonymizationMode `yaml:"fqdn"`
+}
+
+// returns the policy in which levels without a mode have the mode of the level below
+func (sp SourcePolicy) withDefaults() SourcePolicy {
+	if sp.Fqdn == "" {
+		sp.Fqdn = ModePlain
+	}
+	if sp.Apex == "" {
+		sp.Apex = sp.Fqdn
+	}
+	if sp.PublicSuffix == "" {
+		sp.PublicSuffix = sp.Apex
+	}
+	if sp.Tld == "" {
+		sp.Tld = sp.PublicSuffix
+	}
+	return sp
+}
+
+// the level of the domain names that the entries of a source refer to, and whether they refer to anonymized names
+type entryRef struct {
+	level string
+	anon  bool
+}
+
+// the sources of domain names, and the names their entries refer to. Passive DNS entries (e.g. from Splunk) are
+// stored as the 'passive' source.
+var sourceEntryRefs = map[string]entryRef{
+	"zone":    {level: "apex"},
+	"ct":      {level: "fqdn"},
+	"passive": {level: "fqdn"},
+	"entrada": {level: "fqdn", anon: true},
+}
+
+// the anonymization policies of sources by name. Sources without a policy store their domain names unanonymized.
+type AnonymizationPolicy map[string]SourcePolicy
+
+// anonymizes the domain names of ENTRADA only
+var DefaultAnonymizationPolicy = AnonymizationPolicy{
+	"entrada": {Fqdn: ModeAnonymize},
+}
+
+// returns the policy of a source
+func (p AnonymizationPolicy) Source(source string) SourcePolicy {
+	return p[source].withDefaults()
+}
+
+// returns an error if the policy cannot be honoured. The parent levels of a level must be stored in the same way
+// (e.g. an anonymized FQDN requires its apex to be anonymized), and the names that the entries of a source refer to
+// must be stored.
+func (p AnonymizationPolicy) Validate() error {
+	for source, sp := range p {
+		ref, ok := sourceEntryRefs[source]
+		if !ok {
+			return errors.Wrap(UnknownSourceErr, source)
+		}
+		sp = sp.withDefaults()
+
+		// ordered from the bottom to the top level
+		levels := []struct {
+			name string
+			mode AnonymizationMode
+		}{
+			{"fqdn", sp.Fqdn},
+			{"apex", sp.Apex},
+			{"public-suffix", sp.PublicSuffix},
+			{"tld", sp.Tld},
+		}
+		for i, l := range levels {
+			if !l.mode.valid() {
+				return fmt.Errorf("source '%s': %s: %s", source, InvalidAnonymizationModeErr, l.mode)
+			}
+			if l.name == ref.level {
+				if ref.anon && !l.mode.anon() {
+					return fmt.Errorf("source '%s': entries refer to anonymized names, so the %s must be anonymized", source, l.name)
+				}
+				if !ref.anon && !l.mode.plain() {
+					return fmt.Errorf("source '%s': entries refer to unanonymized names, so the %s must be stored unanonymized", source, l.name)
+				}
+			}
+			if i == 0 {
+				continue
+			}
+			child := levels[i-1]
+			if child.mode.plain() && !l.mode.plain() {
+				return fmt.Errorf("source '%s': the %s must be stored unanonymized, as the %s is", source, l.name, child.name)
+			}
+			if child.mode.anon() && !l.mode.anon() {
+				return fmt.Errorf("source '%s': the %s must be anonymized, as the %s is", source, l.name, child.name)
+			}
+		}
+	}
+	return nil
+}
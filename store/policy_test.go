@@ -0,0 +1,115 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestSourcePolicyDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		sp       SourcePolicy
+		expected SourcePolicy
+	}{
+		{
+			name:     "empty",
+			expected: SourcePolicy{ModePlain, ModePlain, ModePlain, ModePlain},
+		},
+		{
+			name:     "fqdn only",
+			sp:       SourcePolicy{Fqdn: ModeAnonymize},
+			expected: SourcePolicy{ModeAnonymize, ModeAnonymize, ModeAnonymize, ModeAnonymize},
+		},
+		{
+			name:     "apex and fqdn",
+			sp:       SourcePolicy{Apex: ModeBoth, Fqdn: ModeAnonymize},
+			expected: SourcePolicy{ModeBoth, ModeBoth, ModeBoth, ModeAnonymize},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.sp.withDefaults()
+			if actual != test.expected {
+				t.Fatalf("expected %+v, but got %+v", test.expected, actual)
+			}
+		})
+	}
+
+	if sp := DefaultAnonymizationPolicy.Source("ct"); sp.Fqdn != ModePlain {
+		t.Fatalf("expected source without policy to be stored unanonymized, but got %+v", sp)
+	}
+}
+
+func TestAnonymizationPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     AnonymizationPolicy
+		valid bool
+	}{
+		{
+			name:  "default",
+			p:     DefaultAnonymizationPolicy,
+			valid: true,
+		},
+		{
+			name: "anonymized subdomains",
+			p: AnonymizationPolicy{
+				"ct": {Apex: ModePlain, Fqdn: ModeBoth},
+			},
+			valid: false,
+		},
+		{
+			name: "both",
+			p: AnonymizationPolicy{
+				"ct":      {Fqdn: ModeBoth},
+				"entrada": {Tld: ModeBoth, PublicSuffix: ModeBoth, Apex: ModeBoth, Fqdn: ModeAnonymize},
+			},
+			valid: true,
+		},
+		{
+			name: "unanonymized entrada",
+			p: AnonymizationPolicy{
+				"entrada": {Fqdn: ModePlain},
+			},
+			valid: false,
+		},
+		{
+			name: "anonymized zone",
+			p: AnonymizationPolicy{
+				"zone": {Apex: ModeAnonymize},
+			},
+			valid: false,
+		},
+		{
+			name: "anonymized parent",
+			p: AnonymizationPolicy{
+				"zone": {Tld: ModeAnonymize, Apex: ModePlain},
+			},
+			valid: false,
+		},
+		{
+			name: "unknown source",
+			p: AnonymizationPolicy{
+				"unknown": {},
+			},
+			valid: false,
+		},
+		{
+			name: "unknown mode",
+			p: AnonymizationPolicy{
+				"ct": {Fqdn: "hashed"},
+			},
+			valid: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.p.Validate()
+			if test.valid && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !test.valid && err == nil {
+				t.Fatalf("expected error, but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/weppos/publicsuffix-go/publicsuffix"
+)
+
+var (
+	DefaultPublicSuffixList = CompiledPublicSuffixList(false)
+)
+
+// the public suffix list by which domain names are split into their apex and public suffix
+type PublicSuffixList struct {
+	list      *publicsuffix.List
+	opts      *publicsuffix.FindOptions
+	Version   string // the version in the header of the list, if any
+	Sha256    string // the hash of the file of the list, empty for the compiled-in list
+	IcannOnly bool   // the rules in the private section of the list are ignored
+}
+
+func findOptions(icannOnly bool) *publicsuffix.FindOptions {
+	return &publicsuffix.FindOptions{
+		IgnorePrivate: icannOnly,
+		DefaultRule:   publicsuffix.DefaultRule,
+	}
+}
+
+// returns the public suffix list that is compiled into the publicsuffix-go library, of which the rules in the private
+// section are ignored if icannOnly is set
+func CompiledPublicSuffixList(icannOnly bool) *PublicSuffixList {
+	return &PublicSuffixList{
+		list: publicsuffix.DefaultList,
+		opts: findOptions(icannOnly),
+		// the compiled-in list does not expose its version by other means
+		Version:   publicsuffix.CookieJarList.String(),
+		IcannOnly: icannOnly,
+	}
+}
+
+// loads a public suffix list from a file in the format of https://publicsuffix.org/list/public_suffix_list.dat, of
+// which the rules in the private section are ignored if icannOnly is set
+func LoadPublicSuffixList(path string, icannOnly bool) (*PublicSuffixList, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	list := publicsuffix.NewList()
+	opts := &publicsuffix.ParserOption{
+		PrivateDomains: !icannOnly,
+	}
+	if _, err := list.Load(bytes.NewReader(raw), opts); err != nil {
+		return nil, err
+	}
+	return &PublicSuffixList{
+		list:      list,
+		opts:      findOptions(icannOnly),
+		Version:   pslVersion(raw),
+		Sha256:    fmt.Sprintf("%x", sha256.Sum256(raw)),
+		IcannOnly: icannOnly,
+	}, nil
+}
+
+// returns the version and commit in the header of a public suffix list
+func pslVersion(raw []byte) string {
+	var version, commit string
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "//") {
+			if line != "" {
+				break
+			}
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if strings.HasPrefix(line, "VERSION:") {
+			version = strings.TrimSpace(strings.TrimPrefix(line, "VERSION:"))
+		} else if strings.HasPrefix(line, "COMMIT:") {
+			commit = strings.TrimSpace(strings.TrimPrefix(line, "COMMIT:"))
+		}
+	}
+	if commit != "" {
+		return fmt.Sprintf("%s (%s)", version, commit)
+	}
+	return version
+}
+
+// returns the apex of a domain name, or an error if the domain name is a public suffix
+func (l *PublicSuffixList) EffectiveTLDPlusOne(fqdn string) (string, error) {
+	return publicsuffix.DomainFromListWithOptions(l.list, fqdn, l.opts)
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aau-network-security/gollector/store/models"
+)
+
+const testPsl = `// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0.
+
+// VERSION: 2021-01-01_00-00-00_UTC
+// COMMIT: 0123abcd
+
+// ===BEGIN ICANN DOMAINS===
+com
+dk
+uk
+co.uk
+// ===END ICANN DOMAINS===
+// ===BEGIN PRIVATE DOMAINS===
+blogspot.com
+// ===END PRIVATE DOMAINS===
+`
+
+// writes a public suffix list to a temporary directory, which must be removed afterwards
+func writeTestPsl(t *testing.T, content string) (string, string) {
+	dir, err := ioutil.TempDir("", "psl")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	path := filepath.Join(dir, "public_suffix_list.dat")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return dir, path
+}
+
+func TestLoadPublicSuffixList(t *testing.T) {
+	dir, path := writeTestPsl(t, testPsl)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name      string
+		icannOnly bool
+		fqdn      string
+		apex      string
+		suffix    string
+	}{
+		{"icann", false, "www.example.co.uk", "example.co.uk", "co.uk"},
+		{"private", false, "www.example.blogspot.com", "example.blogspot.com", "blogspot.com"},
+		{"icann only", true, "www.example.blogspot.com", "blogspot.com", "com"},
+		{"unlisted", false, "www.example.test", "example.test", "test"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, err := LoadPublicSuffixList(path, test.icannOnly)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if l.Version != "2021-01-01_00-00-00_UTC (0123abcd)" {
+				t.Fatalf("unexpected version: %s", l.Version)
+			}
+			if len(l.Sha256) != 64 {
+				t.Fatalf("expected a SHA-256 hash, but got '%s'", l.Sha256)
+			}
+			d, err := l.NewDomain(test.fqdn)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d.apex.normal != test.apex {
+				t.Fatalf("expected apex '%s', but got '%s'", test.apex, d.apex.normal)
+			}
+			if d.publicSuffix.normal != test.suffix {
+				t.Fatalf("expected public suffix '%s', but got '%s'", test.suffix, d.publicSuffix.normal)
+			}
+		})
+	}
+}
+
+func TestLoadPublicSuffixListWithoutVersion(t *testing.T) {
+	dir, path := writeTestPsl(t, "com\n")
+	defer os.RemoveAll(dir)
+	l, err := LoadPublicSuffixList(path, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if l.Version != "" {
+		t.Fatalf("expected no version, but got '%s'", l.Version)
+	}
+}
+
+func TestResplitDomains(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+
+	// the compiled-in list contains blogspot.com
+	if err := s.StorePassiveEntry(muid, "www.example.blogspot.com", time.Now()); err != nil {
+		t.Fatalf("error while storing passive entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("error while running post hooks: %s", err)
+	}
+
+	dir, path := writeTestPsl(t, testPsl)
+	defer os.RemoveAll(dir)
+	l, err := LoadPublicSuffixList(path, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	stats, err := s.WithPublicSuffixList(l).ResplitDomains(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats.Fqdns != 1 || stats.Apexes != 1 {
+		t.Fatalf("expected 1 fqdn and 1 apex to be re-split, but got %v", stats)
+	}
+
+	var fqdn models.Fqdn
+	if err := g.First(&fqdn).Error; err != nil {
+		t.Fatalf("failed to retrieve fqdn: %s", err)
+	}
+	var apex models.Apex
+	if err := g.Where("id = ?", fqdn.ApexID).First(&apex).Error; err != nil {
+		t.Fatalf("failed to retrieve apex: %s", err)
+	}
+	if apex.Apex != "blogspot.com" {
+		t.Fatalf("expected apex '%s', but got '%s'", "blogspot.com", apex.Apex)
+	}
+	var ps models.PublicSuffix
+	if err := g.Where("id = ?", apex.PublicSuffixID).First(&ps).Error; err != nil {
+		t.Fatalf("failed to retrieve public suffix: %s", err)
+	}
+	if ps.PublicSuffix != "com" {
+		t.Fatalf("expected public suffix '%s', but got '%s'", "com", ps.PublicSuffix)
+	}
+}
+
+func TestResplitDomainsConvertsUnicode(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+
+	if err := s.StorePassiveEntry(muid, "www.bücher.de", time.Now()); err != nil {
+		t.Fatalf("error while storing passive entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("error while running post hooks: %s", err)
+	}
+	var stored models.Fqdn
+	if err := g.Where("fqdn = ?", "www.xn--bcher-kva.de").First(&stored).Error; err != nil {
+		t.Fatalf("failed to retrieve fqdn: %s", err)
+	}
+
+	// names stored before they were converted to A-labels, of which one is stored with A-labels already
+	apex := models.Apex{ID: 1000, Apex: "bücher.de", TldID: stored.TldID, PublicSuffixID: stored.PublicSuffixID}
+	fqdns := []models.Fqdn{
+		{ID: 1000, Fqdn: "www.bücher.de", TldID: stored.TldID, PublicSuffixID: stored.PublicSuffixID, ApexID: apex.ID},
+		{ID: 1001, Fqdn: "mail.bücher.de", TldID: stored.TldID, PublicSuffixID: stored.PublicSuffixID, ApexID: apex.ID},
+	}
+	if err := g.Create(&apex).Error; err != nil {
+		t.Fatalf("failed to create apex: %s", err)
+	}
+	for _, f := range fqdns {
+		if err := g.Create(&f).Error; err != nil {
+			t.Fatalf("failed to create fqdn: %s", err)
+		}
+	}
+	if err := g.Create(&models.PassiveEntry{FqdnID: 1000, Timestamp: time.Now()}).Error; err != nil {
+		t.Fatalf("failed to create passive entry: %s", err)
+	}
+
+	stats, err := s.ResplitDomains(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats.Converted != 3 {
+		t.Fatalf("expected %d converted names, but got %d", 3, stats.Converted)
+	}
+
+	var count int
+	if err := g.Model(&models.PassiveEntry{}).Where("fqdn_id = ?", stored.ID).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count passive entries: %s", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected %d passive entries of the merged fqdn, but got %d", 2, count)
+	}
+
+	var mail models.Fqdn
+	if err := g.Where("id = ?", 1001).First(&mail).Error; err != nil {
+		t.Fatalf("failed to retrieve fqdn: %s", err)
+	}
+	if mail.Fqdn != "mail.xn--bcher-kva.de" || mail.Unicode != "mail.bücher.de" {
+		t.Fatalf("expected fqdn to be converted, but got %s (%s)", mail.Fqdn, mail.Unicode)
+	}
+	if mail.ApexID != stored.ApexID {
+		t.Fatalf("expected apex %d, but got %d", stored.ApexID, mail.ApexID)
+	}
+	if err := g.Model(&models.Apex{}).Where("apex = ?", "bücher.de").Count(&count).Error; err != nil {
+		t.Fatalf("failed to count apexes: %s", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected unicode apex to be merged, but got %d", count)
+	}
+}
@@ -0,0 +1,361 @@
+package store
+
+import (
+	"github.com/aau-network-security/gollector/store/models"
+	"github.com/go-pg/pg"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/rs/zerolog/log"
+)
+
+// the number of domain names of which the split changed, and the number of domain names that were stored with U-labels
+// and have been converted to A-labels
+type ResplitStats struct {
+	Fqdns     int
+	Apexes    int
+	Converted int
+}
+
+// returns the TLD by name, which is created if it does not exist yet
+func (s *Store) ensureTld(tx *pg.Tx, d *domain) (*models.Tld, error) {
+	if tldI, ok := s.cache.tldByName.Get(d.tld.normal); ok {
+		return tldI.(*models.Tld), nil
+	}
+	var tld models.Tld
+	err := tx.Model(&tld).Where("tld = ?", d.tld.normal).First()
+	if err == pg.ErrNoRows {
+		tld = models.Tld{
+			ID:  s.ids.tlds,
+			Tld: d.tld.normal,
+		}
+		if err := tx.Insert(&tld); err != nil {
+			return nil, err
+		}
+		s.ids.tlds++
+	} else if err != nil {
+		return nil, err
+	}
+	s.cache.tldByName.Add(tld.Tld, &tld)
+	return &tld, nil
+}
+
+// returns the public suffix by name, which is created if it does not exist yet
+func (s *Store) ensurePublicSuffix(tx *pg.Tx, d *domain) (*models.PublicSuffix, error) {
+	if psI, ok := s.cache.publicSuffixByName.Get(d.publicSuffix.normal); ok {
+		return psI.(*models.PublicSuffix), nil
+	}
+	var ps models.PublicSuffix
+	err := tx.Model(&ps).Where("public_suffix = ?", d.publicSuffix.normal).First()
+	if err == pg.ErrNoRows {
+		tld, err := s.ensureTld(tx, d)
+		if err != nil {
+			return nil, err
+		}
+		ps = models.PublicSuffix{
+			ID:           s.ids.suffixes,
+			TldID:        tld.ID,
+			PublicSuffix: d.publicSuffix.normal,
+		}
+		if err := tx.Insert(&ps); err != nil {
+			return nil, err
+		}
+		s.ids.suffixes++
+	} else if err != nil {
+		return nil, err
+	}
+	s.cache.publicSuffixByName.Add(ps.PublicSuffix, &ps)
+	return &ps, nil
+}
+
+// returns the apex by name, which is created with the given public suffix if it does not exist yet
+func (s *Store) ensureApex(tx *pg.Tx, d *domain, ps *models.PublicSuffix) (*models.Apex, error) {
+	if apexI, ok := s.cache.apexByName.Get(d.apex.normal); ok {
+		return apexI.(*models.Apex), nil
+	}
+	var apex models.Apex
+	err := tx.Model(&apex).Where("apex = ?", d.apex.normal).First()
+	if err == pg.ErrNoRows {
+		apex = models.Apex{
+			ID:             s.ids.apexes,
+			Apex:           d.apex.normal,
+			Unicode:        d.apex.unicode,
+			TldID:          ps.TldID,
+			PublicSuffixID: ps.ID,
+		}
+		if err := tx.Insert(&apex); err != nil {
+			return nil, err
+		}
+		s.ids.apexes++
+	} else if err != nil {
+		return nil, err
+	}
+	s.cache.apexByName.Add(apex.Apex, &apex)
+	return &apex, nil
+}
+
+// a table of domain names that may contain names that were stored before names were converted to A-labels, along
+// with the columns of other tables that refer to its rows
+type namedTable struct {
+	name   string
+	column string
+	refs   [][2]string
+	cache  *lru.Cache
+}
+
+func (s *Store) namedTables() []namedTable {
+	return []namedTable{
+		{
+			name:   "fqdns",
+			column: "fqdn",
+			refs:   [][2]string{{"certificate_to_fqdns", "fqdn_id"}, {"passive_entries", "fqdn_id"}, {"fqdns_anon", "fqdn_id"}},
+			cache:  s.cache.fqdnByName,
+		},
+		{
+			name:   "apexes",
+			column: "apex",
+			refs:   [][2]string{{"fqdns", "apex_id"}, {"zonefile_entries", "apex_id"}, {"apexes_anon", "apex_id"}},
+			cache:  s.cache.apexByName,
+		},
+	}
+}
+
+// fills the Unicode form of the ASCII names without A-labels that have no Unicode form yet, which equals the name
+func (s *Store) fillAsciiNames(nt namedTable) (int, error) {
+	qry := `
+UPDATE ?0 SET unicode = ?1
+WHERE coalesce(unicode, '') = '' AND octet_length(?1) = char_length(?1) AND ?1 NOT LIKE '%xn--%'`
+	res, err := s.db.Exec(qry, pg.F(nt.name), pg.F(nt.column))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+type nameRow struct {
+	ID   uint
+	Name string
+}
+
+// converts a chunk of names after the given ID that have no Unicode form yet, which are the names that were stored
+// before names were converted to A-labels. Names of which the A-labels are stored already are merged into the row of
+// the A-labels, such that the references to them refer to that row instead. Returns the ID of the last name in the
+// chunk.
+func (s *Store) convertNames(nt namedTable, after uint, chunkSize int, stats *ResplitStats) (uint, int, error) {
+	var rows []nameRow
+	qry := "SELECT id, ?0 AS name FROM ?1 WHERE id > ?2 AND coalesce(unicode, '') = '' ORDER BY id LIMIT ?3"
+	if _, err := s.db.Query(&rows, qry, pg.F(nt.column), pg.F(nt.name), after, chunkSize); err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		after = row.ID
+		name, err := toALabels(row.Name)
+		if err != nil {
+			log.Warn().Str(nt.column, row.Name).Msgf("failed to convert to A-labels: %s", err)
+			continue
+		}
+		unicode := toULabels(name)
+		if name == row.Name {
+			if _, err := tx.Exec("UPDATE ?0 SET unicode = ?1 WHERE id = ?2", pg.F(nt.name), unicode, row.ID); err != nil {
+				return 0, 0, err
+			}
+			continue
+		}
+
+		var existing []uint
+		if _, err := tx.Query(&existing, "SELECT id FROM ?0 WHERE ?1 = ?2 AND id != ?3 LIMIT 1", pg.F(nt.name), pg.F(nt.column), name, row.ID); err != nil {
+			return 0, 0, err
+		}
+		if len(existing) == 0 {
+			qry := "UPDATE ?0 SET ?1 = ?2, unicode = ?3 WHERE id = ?4"
+			if _, err := tx.Exec(qry, pg.F(nt.name), pg.F(nt.column), name, unicode, row.ID); err != nil {
+				return 0, 0, err
+			}
+		} else {
+			for _, ref := range nt.refs {
+				qry := "UPDATE ?0 SET ?1 = ?2 WHERE ?1 = ?3"
+				if _, err := tx.Exec(qry, pg.F(ref[0]), pg.F(ref[1]), existing[0], row.ID); err != nil {
+					return 0, 0, err
+				}
+			}
+			if _, err := tx.Exec("DELETE FROM ?0 WHERE id = ?1", pg.F(nt.name), row.ID); err != nil {
+				return 0, 0, err
+			}
+		}
+		// the cached row of the name no longer exists under this name
+		nt.cache.Remove(row.Name)
+		stats.Converted++
+	}
+	return after, len(rows), tx.Commit()
+}
+
+type splitRow struct {
+	ID           uint
+	Name         string
+	Apex         string
+	PublicSuffix string
+}
+
+// re-splits a chunk of FQDNs after the given ID, and returns the ID of the last FQDN in the chunk
+func (s *Store) resplitFqdns(after uint, chunkSize int, stats *ResplitStats) (uint, int, error) {
+	var rows []splitRow
+	qry := `
+SELECT f.id, f.fqdn AS name, a.apex, ps.public_suffix
+FROM fqdns f
+JOIN apexes a ON a.id = f.apex_id
+JOIN public_suffixes ps ON ps.id = f.public_suffix_id
+WHERE f.id > ?
+ORDER BY f.id
+LIMIT ?`
+	if _, err := s.db.Query(&rows, qry, after, chunkSize); err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		after = row.ID
+		d, err := s.psl.NewDomain(row.Name)
+		if err != nil {
+			log.Warn().Str("fqdn", row.Name).Msgf("failed to re-split fqdn: %s", err)
+			continue
+		}
+		if d.apex.normal == row.Apex && d.publicSuffix.normal == row.PublicSuffix {
+			continue
+		}
+		ps, err := s.ensurePublicSuffix(tx, d)
+		if err != nil {
+			return 0, 0, err
+		}
+		apex, err := s.ensureApex(tx, d, ps)
+		if err != nil {
+			return 0, 0, err
+		}
+		fqdn := models.Fqdn{
+			ID:             row.ID,
+			TldID:          ps.TldID,
+			PublicSuffixID: ps.ID,
+			ApexID:         apex.ID,
+		}
+		if _, err := tx.Model(&fqdn).Column("tld_id", "public_suffix_id", "apex_id").WherePK().Update(); err != nil {
+			return 0, 0, err
+		}
+		stats.Fqdns++
+	}
+	return after, len(rows), tx.Commit()
+}
+
+// re-splits a chunk of apexes after the given ID, and returns the ID of the last apex in the chunk
+func (s *Store) resplitApexes(after uint, chunkSize int, stats *ResplitStats) (uint, int, error) {
+	var rows []splitRow
+	qry := `
+SELECT a.id, a.apex AS name, a.apex, ps.public_suffix
+FROM apexes a
+JOIN public_suffixes ps ON ps.id = a.public_suffix_id
+WHERE a.id > ?
+ORDER BY a.id
+LIMIT ?`
+	if _, err := s.db.Query(&rows, qry, after, chunkSize); err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		after = row.ID
+		d, err := s.psl.NewDomain(row.Name)
+		if err != nil {
+			log.Warn().Str("apex", row.Name).Msgf("failed to re-split apex: %s", err)
+			continue
+		}
+		if d.publicSuffix.normal == row.PublicSuffix {
+			continue
+		}
+		ps, err := s.ensurePublicSuffix(tx, d)
+		if err != nil {
+			return 0, 0, err
+		}
+		apex := models.Apex{
+			ID:             row.ID,
+			TldID:          ps.TldID,
+			PublicSuffixID: ps.ID,
+		}
+		if _, err := tx.Model(&apex).Column("tld_id", "public_suffix_id").WherePK().Update(); err != nil {
+			return 0, 0, err
+		}
+		if apexI, ok := s.cache.apexByName.Get(row.Name); ok {
+			cached := apexI.(*models.Apex)
+			cached.TldID, cached.PublicSuffixID = ps.TldID, ps.ID
+		}
+		stats.Apexes++
+	}
+	return after, len(rows), tx.Commit()
+}
+
+// processes a chunk of rows after the given ID, and returns the ID of the last row and the number of rows in the chunk
+type chunkFunc func(after uint, chunkSize int, stats *ResplitStats) (uint, int, error)
+
+type chunkStep struct {
+	name string
+	f    chunkFunc
+}
+
+// recomputes the apexes and public suffixes of the stored FQDNs, and the public suffixes of the stored apexes, by the
+// public suffix list of the store, e.g. after the list has been updated. Names that were stored with U-labels are
+// converted to A-labels first. Apexes that no longer are the apex of any
+// FQDN are kept, as they may be referred to by zone file entries. Anonymized domain names cannot be re-split, as
+// their names are unknown. No other store may write to the database meanwhile, as this store assigns the IDs of the
+// apexes, public suffixes and TLDs that it creates.
+func (s *Store) ResplitDomains(chunkSize int) (ResplitStats, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.ensureReady()
+
+	stats := ResplitStats{}
+	var resplits []chunkStep
+	// names are converted to A-labels first, as they are split by their A-labels
+	for _, nt := range s.namedTables() {
+		nt := nt
+		n, err := s.fillAsciiNames(nt)
+		if err != nil {
+			return stats, err
+		}
+		log.Debug().Msgf("filled the unicode form of %d %s", n, nt.name)
+		resplits = append(resplits, chunkStep{"converted " + nt.name, func(after uint, chunkSize int, stats *ResplitStats) (uint, int, error) {
+			return s.convertNames(nt, after, chunkSize, stats)
+		}})
+	}
+	resplits = append(resplits,
+		chunkStep{"re-split fqdns", s.resplitFqdns},
+		chunkStep{"re-split apexes", s.resplitApexes},
+	)
+	for _, resplit := range resplits {
+		after := uint(0)
+		for {
+			last, n, err := resplit.f(after, chunkSize, &stats)
+			if err != nil {
+				return stats, err
+			}
+			if n == 0 {
+				break
+			}
+			after = last
+			log.Debug().Msgf("%s up to id %d", resplit.name, after)
+		}
+	}
+	return stats, nil
+}
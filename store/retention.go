@@ -0,0 +1,232 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	errs "github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// the periods for which entries are retained, where entries are retained indefinitely if the period is zero
+type RetentionOpts struct {
+	Passive        time.Duration `yaml:"passive"`
+	Entrada        time.Duration `yaml:"entrada"`
+	CollectGarbage bool          `yaml:"collect-garbage"` // deletes FQDNs that are no longer referenced after purging
+	Interval       time.Duration `yaml:"interval"`        // time between purges, defaults to a day
+}
+
+// returns whether anything is purged
+func (o RetentionOpts) Enabled() bool {
+	return o.Passive > 0 || o.Entrada > 0 || o.CollectGarbage
+}
+
+func (o RetentionOpts) interval() time.Duration {
+	if o.Interval == 0 {
+		return 24 * time.Hour
+	}
+	return o.Interval
+}
+
+// the number of deleted entries per stage, the names of the dropped partitions, of which the entries are not counted,
+// and the number of garbage collected FQDNs
+type PurgeStats struct {
+	PassiveEntries map[uint]int
+	EntradaEntries map[uint]int
+	Partitions     []string
+	Fqdns          int
+	FqdnsAnon      int
+}
+
+type stageCount struct {
+	StageID uint
+	Count   int
+}
+
+// deletes the entries of a table of which the timestamp column is before the cutoff, and adds the number of deleted
+// entries per stage to the counts
+func purgeEntries(db orm.DB, table, column string, cutoff time.Time, counts map[uint]int) error {
+	var res []stageCount
+	qry := `
+WITH deleted AS (
+	DELETE FROM ?0 WHERE ?1 < ?2 RETURNING stage_id
+)
+SELECT stage_id, count(*) AS count FROM deleted GROUP BY stage_id`
+	if _, err := db.Query(&res, qry, pg.F(table), pg.F(column), cutoff); err != nil {
+		return err
+	}
+	for _, c := range res {
+		counts[c.StageID] += c.Count
+	}
+	return nil
+}
+
+// drops the partitions of a table of which the months end before the cutoff, and returns their names
+func (s *Store) dropExpiredPartitions(table string, cutoff time.Time) ([]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var res []string
+	for _, name := range s.expiredPartitions(table, cutoff) {
+		if _, err := s.db.Exec("DROP TABLE ?0", pg.F(name)); err != nil {
+			return res, errs.Wrapf(err, "drop partition %s", name)
+		}
+		delete(s.partitions, name)
+		res = append(res, name)
+	}
+	return res, nil
+}
+
+// the conditions under which an anonymized FQDN (fa) is referenced by the entries of a source. ENTRADA entries refer
+// to anonymized FQDNs, whereas the entries of other sources refer to unanonymized FQDNs, which the anonymized FQDNs of
+// these sources refer to.
+var fqdnAnonRefs = map[string]string{
+	"entrada": "EXISTS (SELECT 1 FROM entrada_entries e WHERE e.fqdn_id = fa.id)",
+	"ct":      "EXISTS (SELECT 1 FROM certificate_to_fqdns cf WHERE cf.fqdn_id = fa.fqdn_id)",
+	"passive": "EXISTS (SELECT 1 FROM passive_entries p WHERE p.fqdn_id = fa.fqdn_id)",
+}
+
+// deletes the anonymized FQDNs that are not referenced by the entries of any source that stores anonymized FQDNs
+// according to the policy, and returns their names
+func collectFqdnsAnon(tx *pg.Tx, policy AnonymizationPolicy) ([]string, error) {
+	var conds []string
+	for source, ref := range fqdnAnonRefs {
+		if source == "entrada" || policy.Source(source).Fqdn.anon() {
+			conds = append(conds, ref)
+		}
+	}
+	sort.Strings(conds)
+
+	var names []string
+	qry := fmt.Sprintf(`
+DELETE FROM fqdns_anon fa
+WHERE NOT (%s)
+RETURNING fa.fqdn`, strings.Join(conds, " OR "))
+	if _, err := tx.Query(&names, qry); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// deletes the FQDNs that are not referenced by passive DNS entries, certificates or anonymized FQDNs, and returns
+// their names
+func collectFqdns(tx *pg.Tx) ([]string, error) {
+	var names []string
+	qry := `
+DELETE FROM fqdns f
+WHERE NOT EXISTS (SELECT 1 FROM passive_entries p WHERE p.fqdn_id = f.id)
+AND NOT EXISTS (SELECT 1 FROM certificate_to_fqdns cf WHERE cf.fqdn_id = f.id)
+AND NOT EXISTS (SELECT 1 FROM fqdns_anon fa WHERE fa.fqdn_id = f.id)
+RETURNING f.fqdn`
+	if _, err := tx.Query(&names, qry); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// deletes the FQDNs and anonymized FQDNs that are no longer referenced, and removes them from the cache
+func (s *Store) collectGarbage() (int, int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, errs.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	// anonymized FQDNs refer to unanonymized FQDNs, so they are collected first
+	fqdnsAnon, err := collectFqdnsAnon(tx, s.policy)
+	if err != nil {
+		return 0, 0, errs.Wrap(err, "collect anonymized fqdns")
+	}
+	fqdns, err := collectFqdns(tx)
+	if err != nil {
+		return 0, 0, errs.Wrap(err, "collect fqdns")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, errs.Wrap(err, "committing transaction")
+	}
+
+	// the cache must not return deleted FQDNs, as they would be referred to by new entries
+	for _, name := range fqdnsAnon {
+		s.cache.fqdnByNameAnon.Remove(name)
+	}
+	for _, name := range fqdns {
+		s.cache.fqdnByName.Remove(name)
+	}
+	return len(fqdns), len(fqdnsAnon), nil
+}
+
+// deletes the entries that are older than their retention period, and optionally the FQDNs that are no longer
+// referenced. The partitions of months that have expired as a whole are dropped, and the remaining expired entries are
+// deleted while new entries continue to be stored.
+func (s *Store) Purge(now time.Time, opts RetentionOpts) (PurgeStats, error) {
+	s.ensureReady()
+
+	stats := PurgeStats{
+		PassiveEntries: make(map[uint]int),
+		EntradaEntries: make(map[uint]int),
+	}
+	expirations := []struct {
+		table  string
+		column string
+		period time.Duration
+		counts map[uint]int
+	}{
+		{"passive_entries", "timestamp", opts.Passive, stats.PassiveEntries},
+		{"entrada_entries", "last_seen", opts.Entrada, stats.EntradaEntries},
+	}
+	for _, e := range expirations {
+		if e.period == 0 {
+			continue
+		}
+		cutoff := now.Add(-e.period)
+		dropped, err := s.dropExpiredPartitions(e.table, cutoff)
+		stats.Partitions = append(stats.Partitions, dropped...)
+		if err != nil {
+			return stats, errs.Wrapf(err, "purge %s", e.table)
+		}
+		// only the partitions that may contain entries before the cutoff are scanned, i.e. the partition of the month
+		// of the cutoff and the default partition (or the table as a whole if it is not partitioned)
+		if err := purgeEntries(s.db, e.table, e.column, cutoff, e.counts); err != nil {
+			return stats, errs.Wrapf(err, "purge %s", e.table)
+		}
+	}
+
+	if opts.CollectGarbage {
+		var err error
+		stats.Fqdns, stats.FqdnsAnon, err = s.collectGarbage()
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	for _, name := range stats.Partitions {
+		log.Info().Msgf("dropped partition %s", name)
+	}
+	for sid, count := range stats.PassiveEntries {
+		log.Info().Uint("stage", sid).Msgf("purged %d passive entries", count)
+	}
+	for sid, count := range stats.EntradaEntries {
+		log.Info().Uint("stage", sid).Msgf("purged %d entrada entries", count)
+	}
+	if opts.CollectGarbage {
+		log.Info().Msgf("garbage collected %d fqdns and %d anonymized fqdns", stats.Fqdns, stats.FqdnsAnon)
+	}
+	return stats, nil
+}
+
+// purges at the interval of the retention options until the returned function is called
+func (s *Store) SchedulePurge(opts RetentionOpts) func() {
+	return schedule(opts.interval(), func() {
+		if _, err := s.Purge(time.Now(), opts); err != nil {
+			log.Error().Msgf("failed to purge expired entries: %s", err)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aau-network-security/gollector/collectors/ct"
+	"github.com/aau-network-security/gollector/store/models"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+func TestPurge(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+
+	// the entries are within a single month, such that they are deleted rather than dropped with their partition
+	now := time.Date(2021, 3, 15, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-48 * time.Hour)
+
+	entries := []struct {
+		fqdn string
+		ts   time.Time
+	}{
+		{"old.example.org", old},
+		{"recent.example.org", now},
+	}
+	for _, e := range entries {
+		if err := s.StorePassiveEntry(muid, e.fqdn, e.ts); err != nil {
+			t.Fatalf("failed to store passive entry: %s", err)
+		}
+		if err := s.StoreEntradaEntry(muid, e.fqdn, e.ts, e.ts); err != nil {
+			t.Fatalf("failed to store ENTRADA entry: %s", err)
+		}
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+
+	opts := RetentionOpts{
+		Passive:        24 * time.Hour,
+		Entrada:        24 * time.Hour,
+		CollectGarbage: true,
+	}
+	stats, err := s.Purge(now, opts)
+	if err != nil {
+		t.Fatalf("failed to purge: %s", err)
+	}
+
+	sum := func(counts map[uint]int) int {
+		res := 0
+		for _, c := range counts {
+			res += c
+		}
+		return res
+	}
+	if sum(stats.PassiveEntries) != 1 {
+		t.Fatalf("expected %d purged passive entry, but got %d", 1, sum(stats.PassiveEntries))
+	}
+	if sum(stats.EntradaEntries) != 1 {
+		t.Fatalf("expected %d purged ENTRADA entry, but got %d", 1, sum(stats.EntradaEntries))
+	}
+	if len(stats.Partitions) != 0 {
+		t.Fatalf("expected no dropped partitions, but got %v", stats.Partitions)
+	}
+	if stats.Fqdns != 1 || stats.FqdnsAnon != 1 {
+		t.Fatalf("expected %d garbage collected fqdn of each kind, but got %d and %d", 1, stats.Fqdns, stats.FqdnsAnon)
+	}
+
+	counts := []struct {
+		count uint
+		model interface{}
+	}{
+		{1, &models.PassiveEntry{}},
+		{1, &models.EntradaEntry{}},
+		{1, &models.Fqdn{}},
+		{1, &models.FqdnAnon{}},
+		{1, &models.Apex{}},
+	}
+	for _, tc := range counts {
+		var count uint
+		if err := g.Model(tc.model).Count(&count).Error; err != nil {
+			t.Fatalf("failed to retrieve model count: %s", err)
+		}
+		if count != tc.count {
+			t.Fatalf("expected %d elements, but got %d", tc.count, count)
+		}
+	}
+
+	if _, ok := s.cache.fqdnByName.Get("old.example.org"); ok {
+		t.Fatalf("expected purged fqdn to be removed from the cache")
+	}
+
+	// purged FQDNs are created again when observed again
+	if err := s.StorePassiveEntry(muid, "old.example.org", now); err != nil {
+		t.Fatalf("failed to store passive entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+	var count uint
+	if err := g.Model(&models.Fqdn{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to retrieve model count: %s", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected %d fqdns, but got %d", 2, count)
+	}
+}
+
+func TestPurgePartitions(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+
+	now := time.Date(2021, 3, 15, 12, 0, 0, 0, time.UTC)
+	entries := []struct {
+		fqdn string
+		ts   time.Time
+	}{
+		{"old.example.org", time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{"recent.example.org", time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, e := range entries {
+		if err := s.StorePassiveEntry(muid, e.fqdn, e.ts); err != nil {
+			t.Fatalf("failed to store passive entry: %s", err)
+		}
+		if err := s.StoreEntradaEntry(muid, e.fqdn, e.ts, e.ts); err != nil {
+			t.Fatalf("failed to store ENTRADA entry: %s", err)
+		}
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+
+	opts := RetentionOpts{
+		Passive: 30 * 24 * time.Hour,
+		Entrada: 30 * 24 * time.Hour,
+	}
+	stats, err := s.Purge(now, opts)
+	if err != nil {
+		t.Fatalf("failed to purge: %s", err)
+	}
+
+	expected := []string{"passive_entries_y2021m01", "entrada_entries_y2021m01"}
+	if !reflect.DeepEqual(stats.Partitions, expected) {
+		t.Fatalf("expected dropped partitions %v, but got %v", expected, stats.Partitions)
+	}
+	if len(stats.PassiveEntries) != 0 || len(stats.EntradaEntries) != 0 {
+		t.Fatalf("expected no deleted entries, but got %v and %v", stats.PassiveEntries, stats.EntradaEntries)
+	}
+	for _, name := range expected {
+		if s.partitions[name] {
+			t.Fatalf("expected partition %s to be removed", name)
+		}
+	}
+
+	for _, model := range []interface{}{&models.PassiveEntry{}, &models.EntradaEntry{}} {
+		var count uint
+		if err := g.Model(model).Count(&count).Error; err != nil {
+			t.Fatalf("failed to retrieve model count: %s", err)
+		}
+		if count != 1 {
+			t.Fatalf("expected %d entry, but got %d", 1, count)
+		}
+	}
+}
+
+func TestPurgeAnonymizedCtFqdns(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+	s = s.WithAnonymizationPolicy(AnonymizationPolicy{
+		"ct":      {Fqdn: ModeBoth},
+		"entrada": {Fqdn: ModeAnonymize},
+	})
+
+	now := time.Now()
+	raw, err := selfSignedCert(now, now, []string{"www.example.org"}, "")
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+	le := LogEntry{
+		Cert: cert,
+		Log: ct.Log{
+			Url: "www://localhost:443/ct",
+		},
+		Ts: now,
+	}
+	if err := s.StoreLogEntry(muid, le); err != nil {
+		t.Fatalf("failed to store log entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("failed to run post hooks: %s", err)
+	}
+
+	stats, err := s.Purge(now, RetentionOpts{CollectGarbage: true})
+	if err != nil {
+		t.Fatalf("failed to purge: %s", err)
+	}
+	if stats.Fqdns != 0 || stats.FqdnsAnon != 0 {
+		t.Fatalf("expected no garbage collected fqdns, but got %d and %d", stats.Fqdns, stats.FqdnsAnon)
+	}
+	var count uint
+	if err := g.Model(&models.FqdnAnon{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to retrieve model count: %s", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected %d anonymized fqdn, but got %d", 1, count)
+	}
+}
+
+func TestRetentionOptsEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     RetentionOpts
+		expected bool
+	}{
+		{"empty", RetentionOpts{}, false},
+		{"interval only", RetentionOpts{Interval: time.Hour}, false},
+		{"passive", RetentionOpts{Passive: time.Hour}, true},
+		{"garbage collection", RetentionOpts{CollectGarbage: true}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.opts.Enabled(); actual != test.expected {
+				t.Fatalf("expected %t, but got %t", test.expected, actual)
+			}
+		})
+	}
+}
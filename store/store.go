@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/aau-network-security/gollector/store/models"
+	"github.com/aau-network-security/gollector/watchlist"
 	"github.com/go-pg/pg"
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/jinzhu/gorm"
@@ -85,10 +86,13 @@ type ModelSet struct {
 	tldAnon          []*models.TldAnon
 	certs            []*models.Certificate
 	certToFqdns      []*models.CertificateToFqdn
+	precertToCerts   []*models.PrecertToCert
 	zoneEntries      []*models.ZonefileEntry
 	logEntries       []*models.LogEntry
+	skippedLogIdx    map[uint][]uint // indices of log entries that could not be parsed, by log
 	passiveEntries   []*models.PassiveEntry
 	entradaEntries   []*models.EntradaEntry
+	watchHits        []*models.WatchHit
 }
 
 func (ms *ModelSet) Description() string {
@@ -123,6 +127,9 @@ func (ms *ModelSet) Description() string {
 	if len(ms.certToFqdns) > 0 {
 		res += fmt.Sprintf("certToFqdns: %d\n", len(ms.certToFqdns))
 	}
+	if len(ms.precertToCerts) > 0 {
+		res += fmt.Sprintf("precertToCerts: %d\n", len(ms.precertToCerts))
+	}
 	if len(ms.zoneEntries) > 0 {
 		res += fmt.Sprintf("zoneEntries: %d\n", len(ms.zoneEntries))
 	}
@@ -135,6 +142,9 @@ func (ms *ModelSet) Description() string {
 	if len(ms.entradaEntries) > 0 {
 		res += fmt.Sprintf("entradaEntries: %d\n", len(ms.entradaEntries))
 	}
+	if len(ms.watchHits) > 0 {
+		res += fmt.Sprintf("watchHits: %d\n", len(ms.watchHits))
+	}
 	res += "]"
 	return res
 }
@@ -171,10 +181,13 @@ func NewModelSet() ModelSet {
 		fqdns:            []*models.Fqdn{},
 		fqdnsAnon:        []*models.FqdnAnon{},
 		certToFqdns:      []*models.CertificateToFqdn{},
+		precertToCerts:   []*models.PrecertToCert{},
 		certs:            []*models.Certificate{},
 		logEntries:       []*models.LogEntry{},
+		skippedLogIdx:    make(map[uint][]uint),
 		passiveEntries:   []*models.PassiveEntry{},
 		entradaEntries:   []*models.EntradaEntry{},
+		watchHits:        []*models.WatchHit{},
 		tld:              []*models.Tld{},
 		tldAnon:          []*models.TldAnon{},
 		publicSuffix:     []*models.PublicSuffix{},
@@ -297,6 +310,11 @@ type Store struct {
 	updates         ModelSet
 	ms              measurementState
 	anonymizer      *Anonymizer
+	policy          AnonymizationPolicy
+	keyring         Keyring
+	psl             *PublicSuffixList
+	partitions      map[string]bool // the names of the partitions of the tables of entries
+	partitioned     map[string]bool // the tables of entries that are partitioned
 	Ready           *Ready
 	batchEntities   BatchEntities // datastructure with all entities in batch
 	influxService   InfluxService
@@ -307,12 +325,61 @@ func (s *Store) WithAnonymizer(a *Anonymizer) *Store {
 	return s
 }
 
+// determines per source which levels of domain names are stored unanonymized, anonymized or both
+func (s *Store) WithAnonymizationPolicy(p AnonymizationPolicy) *Store {
+	s.policy = p
+	return s
+}
+
+// de-anonymizes FQDNs with the keys of the keyring, which includes rotated keys
+func (s *Store) WithKeyring(k Keyring) *Store {
+	s.keyring = k
+	return s
+}
+
+// splits domain names by the public suffix list, which is recorded for each measurement
+func (s *Store) WithPublicSuffixList(l *PublicSuffixList) *Store {
+	s.psl = l
+	return s
+}
+
+// matches the FQDNs of all sources against the watchlist, and delivers hits to the notifier
+func (s *Store) WithWatchlist(w *watchlist.Watchlist, n *watchlist.Notifier) *Store {
+	s.batchEntities.watchlist = w
+	s.batchEntities.notifier = n
+	return s
+}
+
+func (s *Store) Watchlist() *watchlist.Watchlist {
+	return s.batchEntities.watchlist
+}
+
 func (s *Store) ensureReady() {
 	if !s.Ready.isReady {
 		s.Ready.Wait()
 	}
 }
 
+// calls the function, and again at the given interval, until the returned function is called
+func schedule(interval time.Duration, fn func()) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan bool)
+	go func() {
+		for {
+			fn()
+			select {
+			case <-done:
+				ticker.Stop()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		done <- true
+	}
+}
+
 func (s *Store) RunPostHooks() error {
 	s.m.Lock()
 	defer s.m.Unlock()
@@ -347,6 +414,14 @@ func (s *Store) GetLastIndexLog(knowLogURL string) (int64, error) {
 		return 0, nil //know log in not present in DB (it's new)
 	}
 
+	// the end of the last scanned range is used, unless the log was scanned before ranges were stored
+	var lastRange models.LogScanRange
+	if err := s.db.Model(&lastRange).Where("log_id = ?", knowLog.ID).Order("end_index DESC").Limit(1).Select(); err == nil {
+		return int64(lastRange.EndIndex), nil
+	} else if err != pg.ErrNoRows {
+		return 0, err
+	}
+
 	var lastLogEntry models.LogEntry
 	if err := s.db.Model(&lastLogEntry).Where("log_id = ?", knowLog.ID).Last(); err != nil {
 		if !strings.Contains(err.Error(), "no rows in result set") {
@@ -376,9 +451,13 @@ func (s *Store) migrate() error {
 		&models.Fqdn{},
 		&models.FqdnAnon{},
 		&models.CertificateToFqdn{},
+		&models.PrecertToCert{},
 		&models.Certificate{},
 		&models.LogEntry{},
 		&models.Log{},
+		&models.TreeHead{},
+		&models.LogScanRange{},
+		&models.WatchHit{},
 		&models.RecordType{},
 		&models.PassiveEntry{},
 		&models.EntradaEntry{},
@@ -390,7 +469,8 @@ func (s *Store) migrate() error {
 			return err
 		}
 	}
-	return nil
+
+	return s.initPartitions()
 }
 
 func (s *Store) maxValForColumn(table string, column string) (uint, error) {
@@ -654,6 +734,10 @@ func NewStore(conf Config, opts Opts) (*Store, error) {
 		updates:         NewModelSet(),
 		ids:             Ids{},
 		anonymizer:      &DefaultAnonymizer,
+		policy:          DefaultAnonymizationPolicy,
+		psl:             DefaultPublicSuffixList,
+		partitions:      make(map[string]bool),
+		partitioned:     make(map[string]bool),
 		ms:              NewMeasurementState(),
 		Ready:           NewReady(),
 		batchEntities:   NewBatchEntities(opts.BatchSize),
@@ -668,25 +752,23 @@ func NewStore(conf Config, opts Opts) (*Store, error) {
 
 	log.Debug().Msgf("unlogging db tables..")
 
-	//make the table unlogged to improve performance
+	//make the table unlogged to improve performance, where the partitions of the tables of entries are created unlogged
 	tableList := []string{
 		"apexes",
 		"apexes_anon",
 		"certificate_to_fqdns",
 		"certificates",
-		"entrada_entries",
+		"precert_to_certs",
 		"fqdns",
 		"fqdns_anon",
-		"log_entries",
+		"log_scan_ranges",
 		"logs",
-		"passive_entries",
 		"public_suffixes",
 		"public_suffixes_anon",
 		"record_types",
 		"stages",
 		"tlds",
 		"tlds_anon",
-		"zonefile_entries",
 	}
 
 	// check which columns are already unlogged
@@ -791,6 +873,7 @@ func propagationPosthook() postHook {
 		log.Debug().Msgf("(11/12)")
 		s.forpropEntradaEntries()
 		log.Debug().Msgf("(12/12)")
+		s.inserts.watchHits = append(s.inserts.watchHits, s.batchEntities.watchHits...)
 
 		s.influxService.StoreHit("db-insert", "tld", len(s.inserts.tld))
 		s.influxService.StoreHit("db-insert", "tld-anon", len(s.inserts.tldAnon))
@@ -811,6 +894,8 @@ func propagationPosthook() postHook {
 
 func storeCachedValuePosthook() postHook {
 	return func(s *Store) error {
+		s.ensureBatchPartitions()
+
 		tx, err := s.db.Begin()
 		if err != nil {
 			return err
@@ -885,6 +970,11 @@ func storeCachedValuePosthook() postHook {
 				models: &s.inserts.certToFqdns,
 				length: len(s.inserts.certToFqdns),
 			},
+			{
+				name:   "precert-to-certs",
+				models: &s.inserts.precertToCerts,
+				length: len(s.inserts.precertToCerts),
+			},
 			{
 				name:   "passive entries",
 				models: &s.inserts.passiveEntries,
@@ -895,6 +985,11 @@ func storeCachedValuePosthook() postHook {
 				models: &s.inserts.entradaEntries,
 				length: len(s.inserts.entradaEntries),
 			},
+			{
+				name:   "watch hits",
+				models: &s.inserts.watchHits,
+				length: len(s.inserts.watchHits),
+			},
 		}
 
 		log.Debug().Msgf("storing cached values")
@@ -907,6 +1002,10 @@ func storeCachedValuePosthook() postHook {
 			log.Debug().Msgf("(%d/%d)", i+1, len(inserts))
 		}
 
+		if err := s.updateScanRanges(tx); err != nil {
+			return err
+		}
+
 		// updates
 		apexAnonUpdateList := s.updates.apexAnonList()
 
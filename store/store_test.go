@@ -25,11 +25,12 @@ import (
 	"github.com/google/certificate-transparency-go/x509/pkix"
 )
 
-func selfSignedCert(notBefore, notAfter time.Time, sans []string) ([]byte, error) {
+func selfSignedCert(notBefore, notAfter time.Time, sans []string, cn string) ([]byte, error) {
 	template := x509.Certificate{
 		SerialNumber: big.NewInt(1),
 		Subject: pkix.Name{
 			Organization: []string{"Org"},
+			CommonName:   cn,
 		},
 		NotBefore:             notBefore,
 		NotAfter:              notAfter,
@@ -319,7 +320,7 @@ func TestDebug(t *testing.T) {
 	}
 	for _, sanList := range sanLists {
 		now := time.Now()
-		raw, err := selfSignedCert(now, now, sanList)
+		raw, err := selfSignedCert(now, now, sanList, "")
 		if err != nil {
 			t.Fatalf("unexpected error while creating self-signed certificate: %s", err)
 		}
@@ -361,7 +362,7 @@ func TestDebug(t *testing.T) {
 	}
 	for _, sanList := range sanLists {
 		now := time.Now()
-		raw, err := selfSignedCert(now, now, sanList)
+		raw, err := selfSignedCert(now, now, sanList, "")
 		if err != nil {
 			t.Fatalf("unexpected error while creating self-signed certificate: %s", err)
 		}
@@ -444,7 +445,7 @@ func TestInfluxDb(t *testing.T) {
 
 	for _, domain := range []string{"www.domain1.com", "test.domain1.com"} {
 		now := time.Now()
-		raw, err := selfSignedCert(now, now, []string{domain})
+		raw, err := selfSignedCert(now, now, []string{domain}, "")
 		if err != nil {
 			t.Fatalf("unexpected error while creating self-signed certificate: %s", err)
 		}
@@ -602,7 +603,7 @@ func TestConditionalPostHooks(t *testing.T) {
 		t.Fatalf("unexpected batch size: expected %d, but got %d", 2, s.batchEntities.Len())
 	}
 
-	raw, err := selfSignedCert(ts, ts, []string{"example.org"})
+	raw, err := selfSignedCert(ts, ts, []string{"example.org"}, "")
 	if err != nil {
 		t.Fatalf("unexpected error while creating self-signed cert: %s", err)
 	}
@@ -811,7 +812,7 @@ func TestStoreDifferentEntries(t *testing.T) {
 		t.Fatalf("failed to create passive store entry: %s", err)
 	}
 	// log entry
-	raw, err := selfSignedCert(ts, ts.Add(10*time.Minute), []string{domain})
+	raw, err := selfSignedCert(ts, ts.Add(10*time.Minute), []string{domain}, "")
 	if err != nil {
 		t.Fatalf("failed to create self-signe cert: %s", err)
 	}
@@ -1036,3 +1037,292 @@ func TestAnonAndUnanonInSameBatch(t *testing.T) {
 		t.Fatalf("unexpected error while storing post hooks: %s", err)
 	}
 }
+
+func TestNewCertificate(t *testing.T) {
+	notBefore := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	raw, err := selfSignedCert(notBefore, notAfter, []string{"www.example.org", "example.org"}, "")
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	c, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+
+	cert := newCertificate(1, "fp", c, false)
+	if cert.IssuerDN != "O=Org" {
+		t.Fatalf("expected issuer DN 'O=Org', but got '%s'", cert.IssuerDN)
+	}
+	if cert.IssuerOrganization != "Org" {
+		t.Fatalf("expected issuer organization 'Org', but got '%s'", cert.IssuerOrganization)
+	}
+	if !cert.NotBefore.Equal(notBefore) || !cert.NotAfter.Equal(notAfter) {
+		t.Fatalf("expected validity period %s - %s, but got %s - %s", notBefore, notAfter, cert.NotBefore, cert.NotAfter)
+	}
+	if cert.SerialNumber != "1" {
+		t.Fatalf("expected serial number '1', but got '%s'", cert.SerialNumber)
+	}
+	if cert.SignatureAlgorithm != "SHA256-RSA" {
+		t.Fatalf("expected signature algorithm 'SHA256-RSA', but got '%s'", cert.SignatureAlgorithm)
+	}
+	if cert.PublicKeyAlgorithm != "RSA" {
+		t.Fatalf("expected public key algorithm 'RSA', but got '%s'", cert.PublicKeyAlgorithm)
+	}
+	if cert.IpAddressCount != 0 || cert.EmailAddressCount != 0 {
+		t.Fatalf("expected no IP and email addresses, but got %d and %d", cert.IpAddressCount, cert.EmailAddressCount)
+	}
+}
+
+func TestCanonicalTbsHash(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	// creates a certificate with the given extensions, that is otherwise identical to the other certificates
+	create := func(exts []pkix.Extension) *x509.Certificate {
+		template := x509.Certificate{
+			SerialNumber:    big.NewInt(1),
+			Subject:         pkix.Name{Organization: []string{"Org"}},
+			NotBefore:       time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:        time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+			DNSNames:        []string{"example.org"},
+			ExtraExtensions: exts,
+		}
+		raw, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %s", err)
+		}
+		// the SCT list is not valid, which results in a non-fatal error
+		c, err := x509.ParseCertificate(raw)
+		if x509.IsFatal(err) {
+			t.Fatalf("failed to parse certificate: %s", err)
+		}
+		return c
+	}
+	poison := pkix.Extension{Id: x509.OIDExtensionCTPoison, Critical: true, Value: []byte{0x05, 0x00}}
+	sctList := pkix.Extension{Id: x509.OIDExtensionCTSCT, Value: []byte{0x04, 0x02, 0x00, 0x00}}
+
+	precert := create([]pkix.Extension{poison})
+	final := create([]pkix.Extension{sctList})
+	other := create([]pkix.Extension{sctList, {Id: []int{1, 2, 3}, Value: []byte{0x05, 0x00}}})
+
+	hashes := make(map[string]string)
+	for name, c := range map[string]*x509.Certificate{"precert": precert, "final": final, "other": other} {
+		h, err := canonicalTbsHash(c)
+		if err != nil {
+			t.Fatalf("unexpected error while computing hash of %s: %s", name, err)
+		}
+		hashes[name] = h
+	}
+	if hashes["precert"] != hashes["final"] {
+		t.Fatalf("expected precertificate and final certificate to have the same hash, but got %s and %s", hashes["precert"], hashes["final"])
+	}
+	if hashes["other"] == hashes["final"] {
+		t.Fatalf("expected certificates with different extensions to have different hashes")
+	}
+}
+
+func TestCertNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		sans     []string
+		cn       string
+		expected []certName
+	}{
+		{
+			name: "sans",
+			sans: []string{"www.example.org", "Example.ORG."},
+			expected: []certName{
+				{name: "www.example.org"},
+				{name: "example.org"},
+			},
+		},
+		{
+			name: "common name",
+			cn:   "www.example.org",
+			expected: []certName{
+				{name: "www.example.org"},
+			},
+		},
+		{
+			name: "common name in sans",
+			sans: []string{"www.example.org"},
+			cn:   "www.example.org",
+			expected: []certName{
+				{name: "www.example.org"},
+			},
+		},
+		{
+			name: "common name is not a hostname",
+			sans: []string{"www.example.org"},
+			cn:   "Example Organization",
+			expected: []certName{
+				{name: "www.example.org"},
+			},
+		},
+		{
+			name: "wildcard",
+			sans: []string{"*.example.org", "example.org"},
+			expected: []certName{
+				{name: "example.org", wildcard: true},
+				{name: "example.org"},
+			},
+		},
+		{
+			name: "invalid",
+			sans: []string{"www.*.example.org", "exa mple.org", "a..org", "127.0.0.1", "*.", "www.example.org"},
+			expected: []certName{
+				{name: "www.example.org"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Now()
+			raw, err := selfSignedCert(now, now, test.sans, test.cn)
+			if err != nil {
+				t.Fatalf("unexpected error while creating self-signed certificate: %s", err)
+			}
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				t.Fatalf("unexpected error while parsing certificate: %s", err)
+			}
+
+			actual := certNames(cert)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expected names %v, but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestScanRanges(t *testing.T) {
+	tests := []struct {
+		name            string
+		indices         []uint
+		existing        []IndexRange
+		expectedRanges  []IndexRange
+		expectedMissing []IndexRange
+	}{
+		{
+			name:           "consecutive",
+			indices:        []uint{2, 0, 1},
+			expectedRanges: []IndexRange{{0, 3}},
+		},
+		{
+			name:            "gap in batch",
+			indices:         []uint{0, 1, 5, 6, 6},
+			expectedRanges:  []IndexRange{{0, 2}, {5, 7}},
+			expectedMissing: []IndexRange{{2, 5}},
+		},
+		{
+			name:           "adjacent to existing",
+			indices:        []uint{10, 11},
+			existing:       []IndexRange{{0, 10}, {12, 20}},
+			expectedRanges: []IndexRange{{0, 20}},
+		},
+		{
+			name:            "overlapping existing",
+			indices:         []uint{5, 6, 30},
+			existing:        []IndexRange{{0, 10}, {15, 20}},
+			expectedRanges:  []IndexRange{{0, 10}, {15, 20}, {30, 31}},
+			expectedMissing: []IndexRange{{10, 15}, {20, 30}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranges := mergeRanges(append(indexRanges(test.indices), test.existing...))
+			if !reflect.DeepEqual(ranges, test.expectedRanges) {
+				t.Fatalf("expected ranges %v, but got %v", test.expectedRanges, ranges)
+			}
+			missing := missingRanges(ranges)
+			if !reflect.DeepEqual(missing, test.expectedMissing) {
+				t.Fatalf("expected missing ranges %v, but got %v", test.expectedMissing, missing)
+			}
+		})
+	}
+}
+
+func TestStoreSkippedLogEntry(t *testing.T) {
+	s, _, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+
+	l := ct.Log{
+		Description: "test description",
+		Url:         "www://localhost:443/ct",
+	}
+	for i, san := range []string{"a.com", "b.com"} {
+		now := time.Now()
+		raw, err := selfSignedCert(now, now, []string{san}, "")
+		if err != nil {
+			t.Fatalf("unexpected error while creating self-signed certificate: %s", err)
+		}
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			t.Fatalf("unexpected error while parsing certificate: %s", err)
+		}
+		le := LogEntry{
+			Cert:  cert,
+			Index: uint(i * 2),
+			Log:   l,
+			Ts:    now,
+		}
+		if err := s.StoreLogEntry(muid, le); err != nil {
+			t.Fatalf("unexpected error while storing log entry: %s", err)
+		}
+	}
+	if err := s.StoreSkippedLogEntry(muid, l, 1); err != nil {
+		t.Fatalf("unexpected error while storing skipped log entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("unexpected error while running post hooks: %s", err)
+	}
+
+	missing, err := s.GetMissingRanges(l.Url)
+	if err != nil {
+		t.Fatalf("unexpected error while getting missing ranges: %s", err)
+	}
+	if len(missing) != 0 {
+		t.Fatalf("expected no missing ranges, but got %v", missing)
+	}
+}
+
+func TestStoreIdnFromDifferentSources(t *testing.T) {
+	s, g, muid, err := OpenStore(TestConfig, TestOpts)
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+
+	// the .dk zone file contains U-labels, whereas passive DNS contains A-labels
+	if err := s.StoreZoneEntry(muid, time.Now(), "blåbær.dk", api.ZoneEntry_FIRST_SEEN); err != nil {
+		t.Fatalf("error while storing zone entry: %s", err)
+	}
+	if err := s.StorePassiveEntry(muid, "xn--blbr-roah.dk", time.Now()); err != nil {
+		t.Fatalf("error while storing passive entry: %s", err)
+	}
+	if err := s.RunPostHooks(); err != nil {
+		t.Fatalf("error while running post hooks: %s", err)
+	}
+
+	var apexes []models.Apex
+	if err := g.Find(&apexes).Error; err != nil {
+		t.Fatalf("failed to retrieve apexes: %s", err)
+	}
+	if len(apexes) != 1 {
+		t.Fatalf("expected %d apex, but got %d", 1, len(apexes))
+	}
+	if apexes[0].Apex != "xn--blbr-roah.dk" || apexes[0].Unicode != "blåbær.dk" {
+		t.Fatalf("unexpected apex: %v", apexes[0])
+	}
+
+	var fqdns []models.Fqdn
+	if err := g.Find(&fqdns).Error; err != nil {
+		t.Fatalf("failed to retrieve fqdns: %s", err)
+	}
+	if len(fqdns) != 1 {
+		t.Fatalf("expected %d fqdn, but got %d", 1, len(fqdns))
+	}
+}
@@ -18,7 +18,7 @@ func (s *Store) StoreZoneEntry(muid string, t time.Time, fqdn string, zoneEntryT
 		return NoActiveStageErr
 	}
 
-	domain, err := NewDomain(fqdn)
+	domain, err := s.psl.NewDomain(fqdn)
 	if err != nil {
 		return err
 	}
@@ -26,10 +26,11 @@ func (s *Store) StoreZoneEntry(muid string, t time.Time, fqdn string, zoneEntryT
 
 	s.influxService.ZoneCount(domain.tld.normal)
 
-	s.batchEntities.AddApex(domain, false)
+	s.batchEntities.AddApex(domain, s.policy.Source("zone"), "zone")
 
 	ze := &models.ZonefileEntry{
-		StageID: sid,
+		Timestamp: t,
+		StageID:   sid,
 	}
 	if zoneEntryType == prt.ZoneEntry_EXPIRATION {
 		ze.Expired = t
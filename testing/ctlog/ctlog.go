@@ -0,0 +1,403 @@
+package ctlog
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509/pkix"
+)
+
+var (
+	InvalidRangeErr = errors.New("invalid range of entries")
+)
+
+// an in-process certificate transparency log, that serves either the RFC 6962 API or the static CT API for generated
+// certificates and precertificates
+type Log struct {
+	m            sync.Mutex
+	key          *ecdsa.PrivateKey
+	rootKey      *ecdsa.PrivateKey
+	root         *x509.Certificate
+	entries      []ct.LeafEntry
+	leafHashes   [][]byte
+	tileLeaves   [][]byte // entries as encoded in the data tiles of the static CT API
+	serial       int64
+	maxBatchSize int
+	server       *httptest.Server
+}
+
+func generateRoot(key *ecdsa.PrivateKey) (*x509.Certificate, error) {
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Organization: []string{"Test CA"},
+			CommonName:   "Test Root",
+		},
+		NotBefore:             time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(raw)
+}
+
+// starts a new log without entries that serves the RFC 6962 API, which must be closed afterwards
+func New() (*Log, error) {
+	l, err := newLog()
+	if err != nil {
+		return nil, err
+	}
+	l.server = httptest.NewServer(l.Handler())
+	return l, nil
+}
+
+// starts a new log without entries that serves the static CT API, which must be closed afterwards
+func NewStatic() (*Log, error) {
+	l, err := newLog()
+	if err != nil {
+		return nil, err
+	}
+	l.server = httptest.NewServer(l.StaticHandler())
+	return l, nil
+}
+
+func newLog() (*Log, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	root, err := generateRoot(rootKey)
+	if err != nil {
+		return nil, err
+	}
+	l := Log{
+		key:          key,
+		rootKey:      rootKey,
+		root:         root,
+		serial:       1,
+		maxBatchSize: 1000,
+	}
+	return &l, nil
+}
+
+// returns the URL of the log, including the scheme
+func (l *Log) URL() string {
+	return l.server.URL
+}
+
+// returns the base64-encoded public key of the log, as found in log lists
+func (l *Log) Key() string {
+	der, err := x509.MarshalPKIXPublicKey(&l.key.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+// returns the root certificate by which all certificates in the log are issued
+func (l *Log) Root() *x509.Certificate {
+	return l.root
+}
+
+func (l *Log) Close() {
+	l.server.Close()
+}
+
+// sets the maximum number of entries that are returned by a single get-entries request
+func (l *Log) SetMaxBatchSize(n int) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.maxBatchSize = n
+}
+
+// returns the number of entries in the log
+func (l *Log) Size() int {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return len(l.entries)
+}
+
+func (l *Log) issue(ts time.Time, sans []string, precert bool) (*x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	l.serial++
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(l.serial),
+		Subject: pkix.Name{
+			Organization: []string{"Org"},
+		},
+		NotBefore:   ts,
+		NotAfter:    ts.Add(90 * 24 * time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:    sans,
+	}
+	if len(sans) > 0 {
+		template.Subject.CommonName = sans[0]
+	}
+	if precert {
+		template.ExtraExtensions = []pkix.Extension{
+			{Id: x509.OIDExtensionCTPoison, Critical: true, Value: []byte{0x05, 0x00}},
+		}
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, &template, l.root, &key.PublicKey, l.rootKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(raw)
+}
+
+func (l *Log) add(ts time.Time, sans []string, precert bool) (*x509.Certificate, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	cert, err := l.issue(ts, sans, precert)
+	if err != nil {
+		return nil, err
+	}
+
+	etype := ct.X509LogEntryType
+	if precert {
+		etype = ct.PrecertLogEntryType
+	}
+	chain := []*x509.Certificate{cert, l.root}
+	leaf, err := ct.MerkleTreeLeafFromChain(chain, etype, uint64(ts.UnixNano()/1e6))
+	if err != nil {
+		return nil, err
+	}
+	leafInput, err := tls.Marshal(*leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	var extraData []byte
+	if precert {
+		extraData, err = tls.Marshal(ct.PrecertChainEntry{
+			PreCertificate:   ct.ASN1Cert{Data: cert.Raw},
+			CertificateChain: []ct.ASN1Cert{{Data: l.root.Raw}},
+		})
+	} else {
+		extraData, err = tls.Marshal(ct.CertificateChain{
+			Entries: []ct.ASN1Cert{{Data: l.root.Raw}},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l.entries = append(l.entries, ct.LeafEntry{
+		LeafInput: leafInput,
+		ExtraData: extraData,
+	})
+	h := sha256.Sum256(append([]byte{0}, leafInput...))
+	l.leafHashes = append(l.leafHashes, h[:])
+
+	tileLeaf, err := l.tileLeaf(leaf.TimestampedEntry, cert, precert)
+	if err != nil {
+		return nil, err
+	}
+	l.tileLeaves = append(l.tileLeaves, tileLeaf)
+	return cert, nil
+}
+
+// appends a certificate entry of which the certificate cannot be parsed, logged at the given time
+func (l *Log) AddMalformedCert(ts time.Time) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	leaf := ct.MerkleTreeLeaf{
+		Version:  ct.V1,
+		LeafType: ct.TimestampedEntryLeafType,
+		TimestampedEntry: &ct.TimestampedEntry{
+			Timestamp: uint64(ts.UnixNano() / 1e6),
+			EntryType: ct.X509LogEntryType,
+			X509Entry: &ct.ASN1Cert{Data: []byte("malformed")},
+		},
+	}
+	leafInput, err := tls.Marshal(leaf)
+	if err != nil {
+		return err
+	}
+	extraData, err := tls.Marshal(ct.CertificateChain{
+		Entries: []ct.ASN1Cert{{Data: l.root.Raw}},
+	})
+	if err != nil {
+		return err
+	}
+
+	l.entries = append(l.entries, ct.LeafEntry{
+		LeafInput: leafInput,
+		ExtraData: extraData,
+	})
+	h := sha256.Sum256(append([]byte{0}, leafInput...))
+	l.leafHashes = append(l.leafHashes, h[:])
+
+	tileLeaf, err := l.tileLeaf(leaf.TimestampedEntry, nil, false)
+	if err != nil {
+		return err
+	}
+	l.tileLeaves = append(l.tileLeaves, tileLeaf)
+	return nil
+}
+
+// appends a certificate for the given domain names, logged at the given time
+func (l *Log) AddCert(ts time.Time, sans ...string) (*x509.Certificate, error) {
+	return l.add(ts, sans, false)
+}
+
+// appends a precertificate for the given domain names, logged at the given time
+func (l *Log) AddPrecert(ts time.Time, sans ...string) (*x509.Certificate, error) {
+	return l.add(ts, sans, true)
+}
+
+// returns the tree head of the current tree, signed by the log
+func (l *Log) STH() (*ct.SignedTreeHead, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.sth(len(l.entries))
+}
+
+func (l *Log) sth(size int) (*ct.SignedTreeHead, error) {
+	sth := ct.SignedTreeHead{
+		Version:   ct.V1,
+		TreeSize:  uint64(size),
+		Timestamp: uint64(time.Now().UnixNano() / 1e6),
+	}
+	copy(sth.SHA256RootHash[:], TreeHash(l.leafHashes[:size]))
+	input, err := ct.SerializeSTHSignatureInput(sth)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := tls.CreateSignature(*l.key, tls.SHA256, input)
+	if err != nil {
+		return nil, err
+	}
+	sth.TreeHeadSignature = ct.DigitallySigned(sig)
+	return &sth, nil
+}
+
+// returns the handler that serves the get-sth, get-sth-consistency, get-entries and get-roots endpoints
+func (l *Log) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ct.GetSTHPath, l.handleGetSTH)
+	mux.HandleFunc(ct.GetSTHConsistencyPath, l.handleGetSTHConsistency)
+	mux.HandleFunc(ct.GetEntriesPath, l.handleGetEntries)
+	mux.HandleFunc(ct.GetRootsPath, l.handleGetRoots)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (l *Log) handleGetSTH(w http.ResponseWriter, r *http.Request) {
+	sth, err := l.STH()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sig, err := tls.Marshal(sth.TreeHeadSignature)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, ct.GetSTHResponse{
+		TreeSize:          sth.TreeSize,
+		Timestamp:         sth.Timestamp,
+		SHA256RootHash:    sth.SHA256RootHash[:],
+		TreeHeadSignature: sig,
+	})
+}
+
+// parses two integer query parameters, of which the first may not be larger than the second
+func parseRange(r *http.Request, a, b string) (int, int, error) {
+	first, err := strconv.Atoi(r.URL.Query().Get(a))
+	if err != nil {
+		return 0, 0, err
+	}
+	second, err := strconv.Atoi(r.URL.Query().Get(b))
+	if err != nil {
+		return 0, 0, err
+	}
+	if first < 0 || second < first {
+		return 0, 0, InvalidRangeErr
+	}
+	return first, second, nil
+}
+
+func (l *Log) handleGetSTHConsistency(w http.ResponseWriter, r *http.Request) {
+	first, second, err := parseRange(r, "first", "second")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	if second > len(l.leafHashes) {
+		http.Error(w, InvalidRangeErr.Error(), http.StatusBadRequest)
+		return
+	}
+	var proof [][]byte
+	if first > 0 {
+		proof = subproof(first, l.leafHashes[:second], true)
+	}
+	writeJSON(w, ct.GetSTHConsistencyResponse{
+		Consistency: proof,
+	})
+}
+
+func (l *Log) handleGetEntries(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseRange(r, "start", "end")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	if start >= len(l.entries) {
+		http.Error(w, InvalidRangeErr.Error(), http.StatusBadRequest)
+		return
+	}
+	// like real logs, fewer entries than requested may be returned
+	if end >= len(l.entries) {
+		end = len(l.entries) - 1
+	}
+	if end-start+1 > l.maxBatchSize {
+		end = start + l.maxBatchSize - 1
+	}
+	writeJSON(w, ct.GetEntriesResponse{
+		Entries: l.entries[start : end+1],
+	})
+}
+
+func (l *Log) handleGetRoots(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, ct.GetRootsResponse{
+		Certificates: []string{base64.StdEncoding.EncodeToString(l.root.Raw)},
+	})
+}
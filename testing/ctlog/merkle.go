@@ -0,0 +1,51 @@
+package ctlog
+
+import (
+	"crypto/sha256"
+)
+
+// computes the Merkle tree hash of the leaf hashes, as defined in RFC 6962
+func TreeHash(leafHashes [][]byte) []byte {
+	switch len(leafHashes) {
+	case 0:
+		h := sha256.Sum256(nil)
+		return h[:]
+	case 1:
+		return leafHashes[0]
+	}
+	k := split(len(leafHashes))
+	data := append([]byte{1}, TreeHash(leafHashes[:k])...)
+	h := sha256.Sum256(append(data, TreeHash(leafHashes[k:])...))
+	return h[:]
+}
+
+// returns the largest power of two smaller than n
+func split(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// computes the consistency proof between the tree of the first m leaves and the tree of all leaves, as defined in
+// RFC 6962
+func subproof(m int, leafHashes [][]byte, complete bool) [][]byte {
+	n := len(leafHashes)
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{TreeHash(leafHashes)}
+	}
+	k := split(n)
+	if m <= k {
+		return append(subproof(m, leafHashes[:k], complete), TreeHash(leafHashes[k:]))
+	}
+	return append(subproof(m-k, leafHashes[k:], false), TreeHash(leafHashes[:k]))
+}
+
+// computes the consistency proof between the tree of the first m leaves and the tree of all leaves
+func ConsistencyProof(m int, leafHashes [][]byte) [][]byte {
+	return subproof(m, leafHashes, true)
+}
@@ -0,0 +1,178 @@
+package ctlog
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+const tileWidth = 256
+
+// encodes an entry as in the data tiles of the static CT API, where the chain consists of the fingerprint of the root
+func (l *Log) tileLeaf(te *ct.TimestampedEntry, cert *x509.Certificate, precert bool) ([]byte, error) {
+	res, err := tls.Marshal(*te)
+	if err != nil {
+		return nil, err
+	}
+	if precert {
+		raw, err := tls.Marshal(ct.ASN1Cert{Data: cert.Raw})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, raw...)
+	}
+	fp := sha256.Sum256(l.root.Raw)
+	res = append(res, 0, sha256.Size)
+	return append(res, fp[:]...), nil
+}
+
+// returns the origin of the checkpoints of the log, which is its URL without scheme
+func (l *Log) Origin() string {
+	return strings.TrimPrefix(l.URL(), "http://")
+}
+
+// returns the checkpoint of the current tree, signed by the log
+func (l *Log) checkpoint() ([]byte, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	sth, err := l.sth(len(l.entries))
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&l.key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	logId := sha256.Sum256(der)
+	origin := l.Origin()
+	keyId := sha256.Sum256(append(append([]byte(origin), '\n', 0x05), logId[:]...))
+
+	sig, err := tls.Marshal(sth.TreeHeadSignature)
+	if err != nil {
+		return nil, err
+	}
+	noteSig := make([]byte, 12)
+	copy(noteSig, keyId[:4])
+	binary.BigEndian.PutUint64(noteSig[4:], sth.Timestamp)
+	noteSig = append(noteSig, sig...)
+
+	body := fmt.Sprintf("%s\n%d\n%s\n\n", origin, sth.TreeSize, base64.StdEncoding.EncodeToString(sth.SHA256RootHash[:]))
+	// a cosignature by a witness precedes the signature of the log
+	body += fmt.Sprintf("— witness.example.org %s\n", base64.StdEncoding.EncodeToString(make([]byte, 72)))
+	body += fmt.Sprintf("— %s %s\n", origin, base64.StdEncoding.EncodeToString(noteSig))
+	return []byte(body), nil
+}
+
+// returns the handler that serves the checkpoint, tiles and issuers of the static CT API
+func (l *Log) StaticHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/checkpoint", l.handleCheckpoint)
+	mux.HandleFunc("/tile/", l.handleTile)
+	mux.HandleFunc("/issuer/", l.handleIssuer)
+	return mux
+}
+
+func (l *Log) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	cp, err := l.checkpoint()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(cp)
+}
+
+// parses the level, index and width of a tile from paths of the form <level>/x001/234[.p/<width>]
+func parseTilePath(path string) (string, int64, int, error) {
+	width := tileWidth
+	if i := strings.Index(path, ".p/"); i >= 0 {
+		w, err := strconv.Atoi(path[i+3:])
+		if err != nil || w < 1 || w >= tileWidth {
+			return "", 0, 0, InvalidRangeErr
+		}
+		width = w
+		path = path[:i]
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return "", 0, 0, InvalidRangeErr
+	}
+	digits := ""
+	for i, p := range parts[1:] {
+		if i < len(parts)-2 {
+			if !strings.HasPrefix(p, "x") {
+				return "", 0, 0, InvalidRangeErr
+			}
+			p = p[1:]
+		}
+		if len(p) != 3 {
+			return "", 0, 0, InvalidRangeErr
+		}
+		digits += p
+	}
+	index, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return "", 0, 0, InvalidRangeErr
+	}
+	return parts[0], index, width, nil
+}
+
+// serves full tiles, and partial tiles of which the width matches the current tree size
+func (l *Log) handleTile(w http.ResponseWriter, r *http.Request) {
+	level, index, width, err := parseTilePath(strings.TrimPrefix(r.URL.Path, "/tile/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	// the number of leaves covered by a single hash or entry in the tile
+	span := int64(1)
+	if level != "data" {
+		n, err := strconv.Atoi(level)
+		if err != nil || n < 0 {
+			http.Error(w, InvalidRangeErr.Error(), http.StatusNotFound)
+			return
+		}
+		for i := 0; i < n; i++ {
+			span *= tileWidth
+		}
+	}
+	count := int64(len(l.leafHashes)) / span
+	available := count - index*tileWidth
+	if available > tileWidth {
+		available = tileWidth
+	}
+	if int64(width) != available {
+		http.Error(w, InvalidRangeErr.Error(), http.StatusNotFound)
+		return
+	}
+
+	var body []byte
+	for i := index * tileWidth; i < index*tileWidth+int64(width); i++ {
+		if level == "data" {
+			body = append(body, l.tileLeaves[i]...)
+			continue
+		}
+		body = append(body, TreeHash(l.leafHashes[i*span:(i+1)*span])...)
+	}
+	w.Write(body)
+}
+
+func (l *Log) handleIssuer(w http.ResponseWriter, r *http.Request) {
+	fp := sha256.Sum256(l.root.Raw)
+	if strings.TrimPrefix(r.URL.Path, "/issuer/") != hex.EncodeToString(fp[:]) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(l.root.Raw)
+}
@@ -17,6 +17,7 @@ func SkipCI(t *testing.T) {
 func ResetDb(g *gorm.DB) error {
 	tables := []string{
 		"zonefile_entries",
+		"zone_archives",
 		"tlds",
 		"tlds_anon",
 		"public_suffixes",
@@ -26,9 +27,13 @@ func ResetDb(g *gorm.DB) error {
 		"fqdns",
 		"fqdns_anon",
 		"certificate_to_fqdns",
+		"precert_to_certs",
 		"certificates",
 		"log_entries",
 		"logs",
+		"tree_heads",
+		"log_scan_ranges",
+		"watch_hits",
 		"record_types",
 		"passive_entries",
 		"measurements",
@@ -45,6 +50,7 @@ func ResetDb(g *gorm.DB) error {
 
 	migrateExamples := []interface{}{
 		&models.ZonefileEntry{},
+		&models.ZoneArchive{},
 		&models.EntradaEntry{},
 		&models.Tld{},
 		&models.TldAnon{},
@@ -55,9 +61,13 @@ func ResetDb(g *gorm.DB) error {
 		&models.Fqdn{},
 		&models.FqdnAnon{},
 		&models.CertificateToFqdn{},
+		&models.PrecertToCert{},
 		&models.Certificate{},
 		&models.LogEntry{},
 		&models.Log{},
+		&models.TreeHead{},
+		&models.LogScanRange{},
+		&models.WatchHit{},
 		&models.RecordType{},
 		&models.PassiveEntry{},
 		&models.Measurement{},
@@ -0,0 +1,136 @@
+package watchlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// the number of hits that are buffered before hits are dropped
+const DefaultBufferSize = 1000
+
+// an observation of a domain name that matches a rule of the watchlist
+type Hit struct {
+	Rule       string    `json:"rule"`
+	Type       RuleType  `json:"type"`
+	Detail     string    `json:"detail,omitempty"`
+	Fqdn       string    `json:"fqdn"`
+	Source     string    `json:"source"`
+	Anonymized bool      `json:"anonymized"` // the FQDN is anonymized, as the source is anonymized
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// delivers hits
+type Sink interface {
+	Send(Hit) error
+}
+
+// writes hits as lines of JSON
+type WriterSink struct {
+	w io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Send(h Hit) error {
+	return json.NewEncoder(s.w).Encode(h)
+}
+
+// posts hits as JSON to a URL
+type WebhookSink struct {
+	url string
+	c   *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url: url,
+		c: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s *WebhookSink) Send(h Hit) error {
+	body, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	resp, err := s.c.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// delivers hits to sinks in the background, such that slow sinks do not delay the processing of domain names
+type Notifier struct {
+	sinks []Sink
+	hits  chan Hit
+	wg    sync.WaitGroup
+}
+
+func NewNotifier(sinks []Sink, bufferSize int) *Notifier {
+	n := Notifier{
+		sinks: sinks,
+		hits:  make(chan Hit, bufferSize),
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		for h := range n.hits {
+			for _, s := range n.sinks {
+				if err := s.Send(h); err != nil {
+					log.Warn().Msgf("failed to deliver watchlist hit: %s", err)
+				}
+			}
+		}
+	}()
+	return &n
+}
+
+// queues a hit for delivery, or drops it if too many hits are queued already
+func (n *Notifier) Notify(h Hit) {
+	select {
+	case n.hits <- h:
+	default:
+		log.Warn().Str("rule", h.Rule).Msgf("dropping watchlist hit for '%s', as delivery is lagging behind", h.Fqdn)
+	}
+}
+
+// delivers the queued hits, after which no hits can be queued
+func (n *Notifier) Close() {
+	close(n.hits)
+	n.wg.Wait()
+}
+
+type Config struct {
+	Rules    []Rule   `yaml:"rules"`
+	Webhooks []string `yaml:"webhooks"`
+	Stdout   bool     `yaml:"stdout"`
+}
+
+// returns the sinks to which hits are delivered
+func (c Config) Sinks() []Sink {
+	var res []Sink
+	if c.Stdout {
+		res = append(res, NewWriterSink(os.Stdout))
+	}
+	for _, url := range c.Webhooks {
+		res = append(res, NewWebhookSink(url))
+	}
+	return res
+}
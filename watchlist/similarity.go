@@ -0,0 +1,112 @@
+package watchlist
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// characters that look alike, mapped to the character they are mistaken for
+var confusables = map[rune]rune{
+	'0': 'o',
+	'1': 'l',
+	'i': 'l',
+	'|': 'l',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'9': 'g',
+	// cyrillic
+	'а': 'a',
+	'в': 'b',
+	'е': 'e',
+	'к': 'k',
+	'м': 'm',
+	'н': 'h',
+	'о': 'o',
+	'р': 'p',
+	'с': 'c',
+	'т': 't',
+	'у': 'y',
+	'х': 'x',
+	'і': 'l',
+	'ј': 'j',
+	'ѕ': 's',
+	'ԁ': 'd',
+	// greek
+	'α': 'a',
+	'β': 'b',
+	'ε': 'e',
+	'ι': 'l',
+	'κ': 'k',
+	'ν': 'v',
+	'ο': 'o',
+	'ρ': 'p',
+	'τ': 't',
+	'υ': 'u',
+	'χ': 'x',
+	// latin
+	'ı': 'l',
+	'ɑ': 'a',
+	'ɡ': 'g',
+	'ɩ': 'l',
+	'ß': 'b',
+}
+
+// sequences of characters that look like a single character
+var confusableSequences = strings.NewReplacer(
+	"rn", "m",
+	"vv", "w",
+	"cl", "d",
+)
+
+// returns the skeleton of a label, such that labels that look alike have the same skeleton. Punycode labels are
+// decoded first.
+func skeleton(s string) string {
+	if strings.HasPrefix(s, "xn--") {
+		if u, err := idna.ToUnicode(s); err == nil {
+			s = u
+		}
+	}
+	s = strings.Map(func(r rune) rune {
+		if c, ok := confusables[r]; ok {
+			return c
+		}
+		return r
+	}, strings.ToLower(s))
+	return confusableSequences.Replace(strings.ReplaceAll(s, "-", ""))
+}
+
+// computes the Levenshtein distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	res := values[0]
+	for _, v := range values[1:] {
+		if v < res {
+			res = v
+		}
+	}
+	return res
+}
@@ -0,0 +1,196 @@
+package watchlist
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	UnknownRuleTypeErr = errors.New("unknown rule type")
+	EmptyRuleErr       = errors.New("rule must have a name and a value")
+)
+
+type RuleType string
+
+const (
+	TypeExact   RuleType = "exact"   // the apex equals the value
+	TypeSuffix  RuleType = "suffix"  // the FQDN equals the value or is a subdomain of it
+	TypeRegex   RuleType = "regex"   // the FQDN matches the regular expression of the value
+	TypeKeyword RuleType = "keyword" // the FQDN contains the value
+	TypeSimilar RuleType = "similar" // the first label of the apex looks like the value, or is within an edit distance of it
+)
+
+// the default edit distance of similarity rules
+const DefaultMaxDistance = 1
+
+// a rule of a watchlist, of which matching domains result in a hit
+type Rule struct {
+	Name        string   `yaml:"name" json:"name"`
+	Type        RuleType `yaml:"type" json:"type"`
+	Value       string   `yaml:"value" json:"value"`
+	MaxDistance int      `yaml:"max-distance" json:"max_distance,omitempty"` // only used by similarity rules
+	Allow       []string `yaml:"allow" json:"allow,omitempty"`               // apexes that never match the rule, such as those of the brand itself
+}
+
+// a match of a domain name with a rule
+type Match struct {
+	Rule   string
+	Type   RuleType
+	Detail string // describes the match of a similarity rule
+}
+
+type compiledRule struct {
+	Rule
+	value    string
+	skeleton string
+	re       *regexp.Regexp
+	allow    map[string]bool
+}
+
+func compile(r Rule) (*compiledRule, error) {
+	if r.Name == "" || r.Value == "" {
+		return nil, EmptyRuleErr
+	}
+	cr := compiledRule{
+		Rule:  r,
+		value: strings.ToLower(r.Value),
+		allow: make(map[string]bool),
+	}
+	for _, a := range r.Allow {
+		cr.allow[toALabels(a)] = true
+	}
+	switch r.Type {
+	case TypeExact, TypeSuffix:
+		cr.value = toALabels(r.Value)
+	case TypeKeyword:
+	case TypeRegex:
+		re, err := regexp.Compile(r.Value)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %s", r.Name, err)
+		}
+		cr.re = re
+	case TypeSimilar:
+		if cr.MaxDistance == 0 {
+			cr.MaxDistance = DefaultMaxDistance
+		}
+		cr.skeleton = skeleton(cr.value)
+	default:
+		return nil, fmt.Errorf("rule '%s': %s: %s", r.Name, UnknownRuleTypeErr, r.Type)
+	}
+	return &cr, nil
+}
+
+// converts a domain name to A-labels, as domain names are matched with A-labels
+func toALabels(s string) string {
+	s = strings.ToLower(s)
+	if a, err := idna.Registration.ToASCII(s); err == nil {
+		return a
+	}
+	return s
+}
+
+// returns the match of the domain name with the rule, if any
+func (cr *compiledRule) match(fqdn, apex string) (Match, bool) {
+	m := Match{
+		Rule: cr.Name,
+		Type: cr.Type,
+	}
+	if cr.allow[apex] {
+		return m, false
+	}
+	switch cr.Type {
+	case TypeExact:
+		return m, apex == cr.value
+	case TypeSuffix:
+		return m, fqdn == cr.value || strings.HasSuffix(fqdn, "."+cr.value)
+	case TypeRegex:
+		return m, cr.re.MatchString(fqdn)
+	case TypeKeyword:
+		return m, strings.Contains(fqdn, cr.value)
+	case TypeSimilar:
+		l := strings.SplitN(apex, ".", 2)[0]
+		if l == cr.value {
+			m.Detail = "equal"
+			return m, true
+		}
+		if skeleton(l) == cr.skeleton {
+			m.Detail = "homoglyph"
+			return m, true
+		}
+		if d := levenshtein(l, cr.value); d <= cr.MaxDistance {
+			m.Detail = fmt.Sprintf("distance %d", d)
+			return m, true
+		}
+	}
+	return m, false
+}
+
+// a set of rules, which can be replaced while domain names are matched against it
+type Watchlist struct {
+	m     sync.RWMutex
+	rules []*compiledRule
+	exact map[string][]*compiledRule // exact rules by value, as watchlists may contain many of them
+	other []*compiledRule
+}
+
+func New(rules []Rule) (*Watchlist, error) {
+	w := Watchlist{}
+	if err := w.Set(rules); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// replaces the rules of the watchlist, unless any of the rules is invalid
+func (w *Watchlist) Set(rules []Rule) error {
+	var compiled, other []*compiledRule
+	exact := make(map[string][]*compiledRule)
+	for _, r := range rules {
+		cr, err := compile(r)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, cr)
+		if cr.Type == TypeExact {
+			exact[cr.value] = append(exact[cr.value], cr)
+		} else {
+			other = append(other, cr)
+		}
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.rules = compiled
+	w.exact = exact
+	w.other = other
+	return nil
+}
+
+func (w *Watchlist) Rules() []Rule {
+	w.m.RLock()
+	defer w.m.RUnlock()
+	var res []Rule
+	for _, cr := range w.rules {
+		res = append(res, cr.Rule)
+	}
+	return res
+}
+
+// returns the matches of a domain name, given its FQDN and apex
+func (w *Watchlist) Match(fqdn, apex string) []Match {
+	w.m.RLock()
+	defer w.m.RUnlock()
+	var res []Match
+	for _, rules := range [][]*compiledRule{w.exact[apex], w.other} {
+		for _, cr := range rules {
+			if m, ok := cr.match(fqdn, apex); ok {
+				res = append(res, m)
+			}
+		}
+	}
+	return res
+}
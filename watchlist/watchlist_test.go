@@ -0,0 +1,252 @@
+package watchlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/idna"
+)
+
+func TestMatch(t *testing.T) {
+	// "еxample" with a cyrillic 'е'
+	cyrillic, err := idna.ToASCII("еxample")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		fqdn     string
+		apex     string
+		expected bool
+		detail   string
+	}{
+		{
+			name:     "exact",
+			rule:     Rule{Name: "r", Type: TypeExact, Value: "Example.com"},
+			fqdn:     "www.example.com",
+			apex:     "example.com",
+			expected: true,
+		},
+		{
+			name:     "exact u-label",
+			rule:     Rule{Name: "r", Type: TypeExact, Value: "blåbær.dk"},
+			fqdn:     "www.xn--blbr-roah.dk",
+			apex:     "xn--blbr-roah.dk",
+			expected: true,
+		},
+		{
+			name: "exact other apex",
+			rule: Rule{Name: "r", Type: TypeExact, Value: "example.com"},
+			fqdn: "example.com.evil.org",
+			apex: "evil.org",
+		},
+		{
+			name:     "suffix subdomain",
+			rule:     Rule{Name: "r", Type: TypeSuffix, Value: "login.example.com"},
+			fqdn:     "a.login.example.com",
+			apex:     "example.com",
+			expected: true,
+		},
+		{
+			name: "suffix partial label",
+			rule: Rule{Name: "r", Type: TypeSuffix, Value: "example.com"},
+			fqdn: "myexample.com",
+			apex: "myexample.com",
+		},
+		{
+			name:     "regex",
+			rule:     Rule{Name: "r", Type: TypeRegex, Value: `^secure-.*\.com$`},
+			fqdn:     "secure-bank.com",
+			apex:     "secure-bank.com",
+			expected: true,
+		},
+		{
+			name:     "keyword",
+			rule:     Rule{Name: "r", Type: TypeKeyword, Value: "paypal"},
+			fqdn:     "paypal-login.example.org",
+			apex:     "example.org",
+			expected: true,
+		},
+		{
+			name: "keyword allowed",
+			rule: Rule{Name: "r", Type: TypeKeyword, Value: "paypal", Allow: []string{"paypal.com"}},
+			fqdn: "www.paypal.com",
+			apex: "paypal.com",
+		},
+		{
+			name:     "similar equal",
+			rule:     Rule{Name: "r", Type: TypeSimilar, Value: "paypal"},
+			fqdn:     "paypal.net",
+			apex:     "paypal.net",
+			expected: true,
+			detail:   "equal",
+		},
+		{
+			name:     "similar homoglyph",
+			rule:     Rule{Name: "r", Type: TypeSimilar, Value: "paypal"},
+			fqdn:     "www.paypa1.com",
+			apex:     "paypa1.com",
+			expected: true,
+			detail:   "homoglyph",
+		},
+		{
+			name:     "similar sequence",
+			rule:     Rule{Name: "r", Type: TypeSimilar, Value: "modern"},
+			fqdn:     "rnodern.com",
+			apex:     "rnodern.com",
+			expected: true,
+			detail:   "homoglyph",
+		},
+		{
+			name:     "similar punycode",
+			rule:     Rule{Name: "r", Type: TypeSimilar, Value: "example"},
+			fqdn:     cyrillic + ".com",
+			apex:     cyrillic + ".com",
+			expected: true,
+			detail:   "homoglyph",
+		},
+		{
+			name:     "similar distance",
+			rule:     Rule{Name: "r", Type: TypeSimilar, Value: "example", MaxDistance: 2},
+			fqdn:     "exampel.com",
+			apex:     "exampel.com",
+			expected: true,
+			detail:   "distance 2",
+		},
+		{
+			name: "similar too distant",
+			rule: Rule{Name: "r", Type: TypeSimilar, Value: "example", MaxDistance: 1},
+			fqdn: "exampel.com",
+			apex: "exampel.com",
+		},
+		{
+			name: "similar subdomain",
+			rule: Rule{Name: "r", Type: TypeSimilar, Value: "example"},
+			fqdn: "example.other.com",
+			apex: "other.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, err := New([]Rule{test.rule})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			matches := w.Match(test.fqdn, test.apex)
+			if (len(matches) > 0) != test.expected {
+				t.Fatalf("expected match to be %t, but got %d matches", test.expected, len(matches))
+			}
+			if test.expected && matches[0].Detail != test.detail {
+				t.Fatalf("expected detail '%s', but got '%s'", test.detail, matches[0].Detail)
+			}
+		})
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		err  error
+	}{
+		{
+			name: "no name",
+			rule: Rule{Type: TypeExact, Value: "example.com"},
+			err:  EmptyRuleErr,
+		},
+		{
+			name: "no value",
+			rule: Rule{Name: "r", Type: TypeExact},
+			err:  EmptyRuleErr,
+		},
+		{
+			name: "unknown type",
+			rule: Rule{Name: "r", Type: "fuzzy", Value: "example"},
+			err:  UnknownRuleTypeErr,
+		},
+		{
+			name: "invalid regex",
+			rule: Rule{Name: "r", Type: TypeRegex, Value: "("},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, err := New([]Rule{{Name: "valid", Type: TypeExact, Value: "example.com"}})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			err = w.Set([]Rule{test.rule})
+			if err == nil {
+				t.Fatalf("expected error, but got none")
+			}
+			if test.err != nil && !strings.Contains(err.Error(), test.err.Error()) {
+				t.Fatalf("expected error '%s', but got '%s'", test.err, err)
+			}
+			if len(w.Rules()) != 1 {
+				t.Fatalf("expected the rules to be unchanged, but got %d rules", len(w.Rules()))
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"example", "example", 0},
+		{"example", "exmple", 1},
+		{"example", "exampel", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, test := range tests {
+		if actual := levenshtein(test.a, test.b); actual != test.expected {
+			t.Fatalf("expected distance between '%s' and '%s' to be %d, but got %d", test.a, test.b, test.expected, actual)
+		}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	received := make(chan Hit, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var h Hit
+		if err := json.NewDecoder(r.Body).Decode(&h); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- h
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	n := NewNotifier([]Sink{NewWriterSink(&buf), NewWebhookSink(srv.URL)}, 10)
+	n.Notify(Hit{Rule: "r", Type: TypeExact, Fqdn: "example.com", Source: "ct"})
+	n.Close()
+
+	var h Hit
+	if err := json.Unmarshal(buf.Bytes(), &h); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if h.Fqdn != "example.com" {
+		t.Fatalf("expected fqdn '%s', but got '%s'", "example.com", h.Fqdn)
+	}
+
+	select {
+	case h := <-received:
+		if h.Rule != "r" || h.Source != "ct" {
+			t.Fatalf("unexpected hit: %v", h)
+		}
+	default:
+		t.Fatalf("expected webhook to receive a hit, but got none")
+	}
+}